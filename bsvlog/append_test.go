@@ -0,0 +1,108 @@
+package bsvlog
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calebcase/bsv/control"
+)
+
+// shortFile writes at most n bytes of the next write and then fails.
+type shortFile struct {
+	file
+
+	n int
+
+	// truncate is returned by Truncate if set.
+	truncate error
+}
+
+func (f *shortFile) Write(p []byte) (n int, err error) {
+	if f.n < 0 || len(p) <= f.n {
+		return f.file.Write(p)
+	}
+
+	n, err = f.file.Write(p[:f.n])
+	f.n = -1
+	if err != nil {
+		return n, err
+	}
+
+	return n, errors.New("short write")
+}
+
+func (f *shortFile) Truncate(size int64) error {
+	if f.truncate != nil {
+		return f.truncate
+	}
+
+	return f.file.Truncate(size)
+}
+
+func appendByte(l *Log, b byte) error {
+	return l.Append(func(e control.Encoder) error {
+		return e.Data([]byte{b, b})
+	})
+}
+
+func TestAppendShortWrite(t *testing.T) {
+	t.Run("truncated", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "log")
+
+		l, err := Open(path, Options{})
+		require.NoError(t, err)
+
+		require.NoError(t, appendByte(l, 1))
+		size := l.Size()
+
+		l.f = &shortFile{file: l.f, n: 3}
+
+		require.Error(t, appendByte(l, 2))
+		require.Equal(t, size, l.Size())
+
+		require.NoError(t, appendByte(l, 3))
+		require.NoError(t, l.Close())
+
+		l, err = Open(path, Options{})
+		require.NoError(t, err)
+		require.Equal(t, int64(0), l.Truncated())
+
+		var values []byte
+
+		it := l.Forward()
+		for it.Next() {
+			values = append(values, it.Record()[1])
+		}
+		require.NoError(t, it.Err())
+		require.Equal(t, []byte{1, 3}, values)
+
+		require.NoError(t, l.Close())
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "log")
+
+		l, err := Open(path, Options{})
+		require.NoError(t, err)
+
+		l.f = &shortFile{file: l.f, n: 3, truncate: errors.New("truncate")}
+
+		require.Error(t, appendByte(l, 1))
+		require.Equal(t, int64(0), l.Size())
+
+		err = appendByte(l, 2)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "log failed")
+
+		require.NoError(t, l.Close())
+
+		// The partial record is removed when the log is reopened.
+		l, err = Open(path, Options{})
+		require.NoError(t, err)
+		require.Equal(t, int64(3), l.Truncated())
+		require.NoError(t, l.Close())
+	})
+}
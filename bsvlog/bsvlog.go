@@ -0,0 +1,391 @@
+// Package bsvlog provides a crash safe append only log of BSV records.
+//
+// Each record is written as a symmetric bounded container holding the
+// record's fields:
+//
+//	cs cb SIZE fields... SIZE cb cs
+//
+// Because the record ends with a copy of its leading blocks the last record
+// can be checked by reading it in reverse from the end of the file. When a
+// crash leaves a partially written record at the end of the log, Open finds
+// the end of the last complete record and truncates the rest.
+package bsvlog
+
+import (
+	"bytes"
+	"io"
+	"os"
+
+	"github.com/calebcase/bsv/control"
+)
+
+// Options configures a log.
+type Options struct {
+	// SyncEvery is the number of appends between calls to fsync. One
+	// syncs after every append. Zero leaves syncing to the operating
+	// system and to explicit calls to Sync or Close.
+	SyncEvery int
+}
+
+// file is the part of *os.File the log uses.
+type file interface {
+	io.ReaderAt
+	io.Writer
+	Truncate(size int64) error
+	Sync() error
+	Close() error
+}
+
+// Log is an append only log of records.
+type Log struct {
+	f    file
+	opts Options
+
+	// failed is set when a failed append couldn't be removed from the
+	// file. No more records can be appended after it.
+	failed error
+
+	size      int64
+	truncated int64
+
+	buf      bytes.Buffer
+	unsynced int
+}
+
+// Open opens the log in the file at path, creating it if it doesn't exist. A
+// partially written record at the end of the log is removed.
+func Open(path string, opts Options) (l *Log, err error) {
+	defer Error.WrapP(&err)
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = f.Close()
+		}
+	}()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	size := fi.Size()
+
+	end, err := lastEnd(f, size)
+	if err != nil {
+		return nil, err
+	}
+
+	if end != size {
+		err = f.Truncate(end)
+		if err != nil {
+			return nil, err
+		}
+
+		err = f.Sync()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Log{
+		f:         f,
+		opts:      opts,
+		size:      end,
+		truncated: size - end,
+	}, nil
+}
+
+// forwardLen returns the length of the record at offset or an error if it
+// isn't a complete record.
+func forwardLen(r io.ReaderAt, offset, size int64) (n int64, err error) {
+	// Nothing in the record can be larger than the rest of the file, so
+	// corrupt sizes are rejected before they are read.
+	d := control.NewDecoderWithOptions(io.NewSectionReader(r, offset, size-offset), limits(size-offset))
+
+	_, err = record(d)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(d.Consumed()), nil
+}
+
+// limits returns the decoder options for reading at most n bytes.
+func limits(n int64) control.DecoderOptions {
+	return control.DecoderOptions{
+		MaxFieldSize:   uint64(n),
+		MaxBoundedSize: uint64(n),
+		MaxTotal:       uint64(n),
+	}
+}
+
+// reverseLen returns the length of the record ending at size or an error if
+// it isn't a complete record.
+func reverseLen(r io.ReaderAt, size int64) (n int64, err error) {
+	d := control.NewReverseDecoder(r, size)
+
+	_, err = record(d)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(d.Consumed()), nil
+}
+
+// lastEnd returns the end of the last complete record. The last record is
+// checked by reading it in reverse and then forward. Only if it is incomplete
+// are all the records read to find the last complete one.
+func lastEnd(r io.ReaderAt, size int64) (end int64, err error) {
+	if size == 0 {
+		return 0, nil
+	}
+
+	n, err := reverseLen(r, size)
+	if err == nil {
+		fn, err := forwardLen(r, size-n, size)
+		if err == nil && fn == n {
+			return size, nil
+		}
+	}
+
+	for end < size {
+		n, err = forwardLen(r, end, size)
+		if err != nil {
+			return end, nil
+		}
+
+		end += n
+	}
+
+	return end, nil
+}
+
+// record reads the next record from d including its closing blocks and
+// returns its fields.
+func record(d control.Decoder) (bsv []byte, err error) {
+	if !d.Next() {
+		if d.Err() != nil {
+			return nil, d.Err()
+		}
+
+		return nil, io.EOF
+	}
+
+	if d.Type() != control.ContainerSymmetric {
+		return nil, Error.New("invalid record: %s", d.Type().Abbr)
+	}
+
+	err = d.Enter()
+	if err != nil {
+		return nil, err
+	}
+
+	if !d.Next() {
+		if d.Err() != nil {
+			return nil, d.Err()
+		}
+
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	if d.Type() != control.ContainerBounded {
+		return nil, Error.New("invalid record: cs(%s)", d.Type().Abbr)
+	}
+
+	bsv, err = d.BSV()
+	if err != nil {
+		return nil, err
+	}
+
+	bsv = append([]byte{}, bsv...)
+
+	// Read the closing blocks.
+	err = d.Seek()
+	if err != nil {
+		return nil, err
+	}
+
+	return bsv, nil
+}
+
+// Truncated returns the number of bytes of a partial record that were removed
+// when the log was opened.
+func (l *Log) Truncated() int64 {
+	return l.truncated
+}
+
+// Size returns the size of the log in bytes.
+func (l *Log) Size() int64 {
+	return l.size
+}
+
+// Append adds a record with the fields fn encodes.
+func (l *Log) Append(fn func(e control.Encoder) error) (err error) {
+	defer Error.WrapP(&err)
+
+	if l.f == nil {
+		return Error.New("log closed")
+	}
+
+	if l.failed != nil {
+		return Error.New("log failed: %v", l.failed)
+	}
+
+	fields := &bytes.Buffer{}
+
+	err = fn(control.NewEncoder(fields))
+	if err != nil {
+		return err
+	}
+
+	if fields.Len() == 0 {
+		return Error.New("empty record")
+	}
+
+	l.buf.Reset()
+
+	err = control.NewEncoder(&l.buf).Symmetric(func(se control.Encoder) error {
+		return se.Bound(fields.Bytes())
+	})
+	if err != nil {
+		return err
+	}
+
+	// Write the record with a single write so that a crash leaves at most
+	// the one partial record.
+	n, err := l.f.Write(l.buf.Bytes())
+	if err == nil && n < l.buf.Len() {
+		err = io.ErrShortWrite
+	}
+	if err != nil {
+		// Remove the partial record so that it isn't followed by the
+		// next one.
+		if n > 0 {
+			terr := l.f.Truncate(l.size)
+			if terr == nil {
+				terr = l.f.Sync()
+			}
+			if terr != nil {
+				l.failed = terr
+			}
+		}
+
+		return err
+	}
+
+	l.size += int64(n)
+
+	l.unsynced++
+	if l.opts.SyncEvery > 0 && l.unsynced >= l.opts.SyncEvery {
+		return l.Sync()
+	}
+
+	return nil
+}
+
+// Sync commits the appended records to stable storage.
+func (l *Log) Sync() (err error) {
+	defer Error.WrapP(&err)
+
+	if l.f == nil {
+		return Error.New("log closed")
+	}
+
+	err = l.f.Sync()
+	if err != nil {
+		return err
+	}
+
+	l.unsynced = 0
+
+	return nil
+}
+
+// Close syncs and closes the log.
+func (l *Log) Close() (err error) {
+	defer Error.WrapP(&err)
+
+	if l.f == nil {
+		return Error.New("log closed")
+	}
+
+	err = l.Sync()
+	if err != nil {
+		_ = l.f.Close()
+		l.f = nil
+
+		return err
+	}
+
+	err = l.f.Close()
+	l.f = nil
+
+	return err
+}
+
+// Iterator reads the records of a log.
+type Iterator struct {
+	d control.Decoder
+
+	bsv []byte
+	err error
+}
+
+// Forward returns an iterator over the records from first to last. Records
+// appended after it is created aren't included.
+func (l *Log) Forward() *Iterator {
+	if l.f == nil {
+		return &Iterator{err: Error.New("log closed")}
+	}
+
+	return &Iterator{
+		d: control.NewDecoderWithOptions(io.NewSectionReader(l.f, 0, l.size), limits(l.size)),
+	}
+}
+
+// Backward returns an iterator over the records from last to first.
+func (l *Log) Backward() *Iterator {
+	if l.f == nil {
+		return &Iterator{err: Error.New("log closed")}
+	}
+
+	return &Iterator{
+		d: control.NewReverseDecoder(l.f, l.size),
+	}
+}
+
+// Next moves to the next record. It returns false when there are no more
+// records or there is an error.
+func (it *Iterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	it.bsv, it.err = record(it.d)
+	if it.err == io.EOF {
+		it.err = nil
+
+		return false
+	}
+
+	return it.err == nil
+}
+
+// Record returns the fields of the current record.
+func (it *Iterator) Record() []byte {
+	return it.bsv
+}
+
+// Decoder returns a decoder for the fields of the current record.
+func (it *Iterator) Decoder() control.Decoder {
+	return control.NewBytesDecoder(it.bsv)
+}
+
+// Err returns the error that stopped the iterator, if any.
+func (it *Iterator) Err() error {
+	return it.err
+}
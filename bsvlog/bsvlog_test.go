@@ -0,0 +1,198 @@
+package bsvlog_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calebcase/bsv/bsvlog"
+	"github.com/calebcase/bsv/control"
+	"github.com/calebcase/oops"
+)
+
+// appendRecords appends records whose first field is i.
+func appendRecords(t *testing.T, l *bsvlog.Log, from, to int) {
+	for i := from; i < to; i++ {
+		err := l.Append(func(e control.Encoder) (err error) {
+			err = e.Data([]byte{byte(i)})
+			if err != nil {
+				return err
+			}
+
+			// Vary the size of the records.
+			return e.Data(make([]byte, i*10+1))
+		})
+		require.NoError(t, err)
+	}
+}
+
+// records returns the first field of each record from the iterator.
+func records(t *testing.T, it *bsvlog.Iterator) (values []byte) {
+	for it.Next() {
+		d := it.Decoder()
+		require.True(t, d.Next())
+
+		data, err := d.Data()
+		require.NoError(t, err)
+
+		values = append(values, data[0])
+	}
+	require.NoError(t, it.Err())
+
+	return values
+}
+
+func TestLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+
+	l, err := bsvlog.Open(path, bsvlog.Options{SyncEvery: 1})
+	require.NoError(t, err)
+	require.Equal(t, int64(0), l.Size())
+
+	require.Empty(t, records(t, l.Forward()))
+	require.Empty(t, records(t, l.Backward()))
+
+	appendRecords(t, l, 0, 5)
+
+	require.Equal(t, []byte{0, 1, 2, 3, 4}, records(t, l.Forward()))
+	require.Equal(t, []byte{4, 3, 2, 1, 0}, records(t, l.Backward()))
+
+	err = l.Append(func(e control.Encoder) error {
+		return nil
+	})
+	require.Error(t, err)
+
+	require.NoError(t, l.Close())
+	require.Error(t, l.Close())
+
+	it := l.Forward()
+	require.False(t, it.Next())
+	require.Error(t, it.Err())
+
+	it = l.Backward()
+	require.False(t, it.Next())
+	require.Error(t, it.Err())
+
+	// Reopen and keep appending.
+	l, err = bsvlog.Open(path, bsvlog.Options{})
+	require.NoError(t, err)
+	require.Equal(t, int64(0), l.Truncated())
+
+	appendRecords(t, l, 5, 7)
+
+	require.Equal(t, []byte{0, 1, 2, 3, 4, 5, 6}, records(t, l.Forward()))
+	require.Equal(t, []byte{6, 5, 4, 3, 2, 1, 0}, records(t, l.Backward()))
+
+	require.NoError(t, l.Close())
+}
+
+func TestOpenTornTail(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "log")
+
+	l, err := bsvlog.Open(path, bsvlog.Options{})
+	require.NoError(t, err)
+
+	appendRecords(t, l, 0, 4)
+	require.NoError(t, l.Close())
+
+	full, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	l, err = bsvlog.Open(path, bsvlog.Options{})
+	require.NoError(t, err)
+
+	appendRecords(t, l, 4, 5)
+	require.NoError(t, l.Close())
+
+	withLast, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	// Every partial write of the last record is removed.
+	for n := len(full) + 1; n < len(withLast); n++ {
+		mark := oops.New("unexpected: %d", n)
+
+		torn := filepath.Join(dir, "torn")
+
+		err = os.WriteFile(torn, withLast[:n], 0o644)
+		require.NoError(t, err, mark)
+
+		l, err := bsvlog.Open(torn, bsvlog.Options{})
+		require.NoError(t, err, mark)
+		require.Equal(t, int64(n-len(full)), l.Truncated(), mark)
+		require.Equal(t, int64(len(full)), l.Size(), mark)
+
+		require.Equal(t, []byte{3, 2, 1, 0}, records(t, l.Backward()), mark)
+
+		// The log can be appended to after the repair.
+		appendRecords(t, l, 4, 5)
+		require.Equal(t, []byte{0, 1, 2, 3, 4}, records(t, l.Forward()), mark)
+		require.NoError(t, l.Close(), mark)
+
+		repaired, err := os.ReadFile(torn)
+		require.NoError(t, err, mark)
+		require.Equal(t, withLast, repaired, mark)
+	}
+
+	// Garbage that isn't a record at all is removed.
+	torn := filepath.Join(dir, "garbage")
+
+	err = os.WriteFile(torn, append(append([]byte{}, full...), 0b_1000_0001), 0o644)
+	require.NoError(t, err)
+
+	l, err = bsvlog.Open(torn, bsvlog.Options{})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), l.Truncated())
+	require.NoError(t, l.Close())
+}
+
+func TestOpenCorruptSize(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "log")
+
+	l, err := bsvlog.Open(path, bsvlog.Options{})
+	require.NoError(t, err)
+
+	appendRecords(t, l, 0, 2)
+	require.NoError(t, l.Close())
+
+	full, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	tails := [][]byte{
+		// A record whose size field claims far more than the file holds.
+		{
+			0b_0000_0111, 0b_0000_0101,
+			0b_0000_1111, 0b_0000_0000,
+			0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+			0b_0000_0001, 0b_0000_0010,
+		},
+		// A data field inside the record claiming more than the file
+		// holds.
+		{
+			0b_0000_0111, 0b_0000_0101, 0b_1000_1011,
+			0b_0000_1111, 0x00, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		},
+	}
+
+	for i, tail := range tails {
+		mark := oops.New("unexpected: %d", i)
+
+		corrupt := filepath.Join(dir, "corrupt")
+
+		err = os.WriteFile(corrupt, append(append([]byte{}, full...), tail...), 0o644)
+		require.NoError(t, err, mark)
+
+		l, err := bsvlog.Open(corrupt, bsvlog.Options{})
+		require.NoError(t, err, mark)
+		require.Equal(t, int64(len(tail)), l.Truncated(), mark)
+		require.Equal(t, int64(len(full)), l.Size(), mark)
+
+		require.Equal(t, []byte{0, 1}, records(t, l.Forward()), mark)
+		require.NoError(t, l.Close(), mark)
+	}
+}
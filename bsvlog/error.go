@@ -0,0 +1,6 @@
+package bsvlog
+
+import "github.com/zeebo/errs"
+
+// Error is the class for this package's errors.
+var Error = errs.Class("bsvlog")
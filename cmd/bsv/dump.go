@@ -0,0 +1,548 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/bits"
+	"strings"
+
+	"github.com/calebcase/bsv/control"
+)
+
+// previewSize is the number of data bytes shown for each field.
+const previewSize = 8
+
+// source gives access to the control blocks of the input by offset.
+type source interface {
+	// at returns the n bytes at offset or nil if they aren't available.
+	at(offset, n uint64) []byte
+
+	// discard allows the bytes before offset to be dropped.
+	discard(offset uint64)
+}
+
+// recorder keeps the bytes read from r that haven't been discarded. Data is
+// discarded before it is read so only the control blocks are kept.
+type recorder struct {
+	r io.Reader
+
+	// off is the offset of the next byte read and base is the offset of
+	// the first byte in buf.
+	off  uint64
+	base uint64
+	buf  []byte
+}
+
+func (rc *recorder) Read(p []byte) (n int, err error) {
+	n, err = rc.r.Read(p)
+
+	start := rc.off
+	rc.off += uint64(n)
+
+	if rc.off > rc.base {
+		skip := uint64(0)
+		if start < rc.base {
+			skip = rc.base - start
+		}
+
+		rc.buf = append(rc.buf, p[skip:n]...)
+	}
+
+	return n, err
+}
+
+func (rc *recorder) at(offset, n uint64) []byte {
+	if offset < rc.base || offset-rc.base+n > uint64(len(rc.buf)) {
+		return nil
+	}
+
+	return rc.buf[offset-rc.base : offset-rc.base+n]
+}
+
+func (rc *recorder) discard(offset uint64) {
+	if offset <= rc.base {
+		return
+	}
+
+	drop := offset - rc.base
+	if drop > uint64(len(rc.buf)) {
+		drop = uint64(len(rc.buf))
+	}
+
+	rc.buf = append(rc.buf[:0], rc.buf[drop:]...)
+	rc.base = offset
+}
+
+// slice is a source over an in memory BSV.
+type slice []byte
+
+func (s slice) at(offset, n uint64) []byte {
+	if offset+n > uint64(len(s)) {
+		return nil
+	}
+
+	return s[offset : offset+n]
+}
+
+func (s slice) discard(offset uint64) {}
+
+// formatBits returns the bits of the control block split at the boundary
+// between the type's prefix and mask. For example a Data block with the value
+// 1 is "1|000_0001".
+func formatBits(t control.Type, b byte) string {
+	split := 8 - bits.OnesCount8(t.Mask)
+
+	var sb strings.Builder
+
+	for i := 0; i < 8; i++ {
+		if i == split && split != 8 {
+			sb.WriteByte('|')
+		} else if i == 4 {
+			sb.WriteByte('_')
+		}
+
+		if b&(0b_1000_0000>>i) != 0 {
+			sb.WriteByte('1')
+		} else {
+			sb.WriteByte('0')
+		}
+	}
+
+	return sb.String()
+}
+
+// formatPreview returns up to previewSize bytes of data as hex and ASCII.
+func formatPreview(data []byte, size uint64) string {
+	var sb strings.Builder
+
+	sb.WriteString(hex.EncodeToString(data))
+
+	if uint64(len(data)) < size {
+		sb.WriteString("..")
+	}
+
+	sb.WriteString(" |")
+
+	for _, b := range data {
+		if b >= 0x20 && b < 0x7f {
+			sb.WriteByte(b)
+		} else {
+			sb.WriteByte('.')
+		}
+	}
+
+	sb.WriteString("|")
+
+	return sb.String()
+}
+
+// counted returns true if the type's control block is repeated at the end of
+// a symmetric field.
+func counted(t control.Type) bool {
+	switch t {
+	case control.DataSize,
+		control.Data1,
+		control.Data2,
+		control.DataSizeSize,
+		control.ContainerBounded,
+		control.SkipSize:
+
+		return true
+	}
+
+	return false
+}
+
+// Kinds of leading blocks of a symmetric field.
+const (
+	controlBlock = iota
+	sizeBytes
+	sizeField
+)
+
+// block is one of the leading blocks of a symmetric field.
+type block struct {
+	kind  int
+	t     control.Type
+	abbr  string
+	depth int
+	size  uint64
+}
+
+// container is a container the fields being read are embedded in.
+type container struct {
+	frame *control.Frame
+
+	// end is the offset following a bounded container.
+	end uint64
+
+	// blocks are the leading blocks of a symmetric field. They are shown
+	// again in reverse once it ends.
+	blocks []block
+}
+
+// dumper writes one line per control block of the fields read by a decoder.
+type dumper struct {
+	w   io.Writer
+	src source
+
+	// base and depth are the offset and depth of the decoder's input.
+	base  uint64
+	depth int
+
+	stack []*container
+}
+
+// dump writes one line per control block in the BSV read from r.
+func dump(w io.Writer, r io.Reader) (err error) {
+	rc := &recorder{r: r}
+	d := control.NewDecoder(rc)
+
+	fmt.Fprintf(w, "%-8s  %-10s  %-5s  %8s  %5s  %s\n", "OFFSET", "BITS", "TYPE", "SIZE", "DEPTH", "PREVIEW")
+
+	dp := &dumper{
+		w:   w,
+		src: rc,
+	}
+
+	err = dp.fields(d)
+	if err != nil {
+		return fmt.Errorf("offset %d: %w", d.Consumed(), err)
+	}
+
+	return nil
+}
+
+// row writes a line.
+func (dp *dumper) row(offset uint64, bits, abbr, size string, depth int, preview string) (err error) {
+	line := fmt.Sprintf(
+		"%08x  %-10s  %-5s  %8s  %5d  %s",
+		dp.base+offset, bits, abbr, size, depth, preview,
+	)
+
+	_, err = fmt.Fprintln(dp.w, strings.TrimRight(line, " "))
+
+	return err
+}
+
+// sizeField writes the lines of the size field of a bounded container.
+func (dp *dumper) sizeField(offset uint64, field []byte, depth int) (err error) {
+	// The symmetric encoder writes a single byte size above 127 without a
+	// symmetric container, repeating its control block after the value.
+	var mirror []byte
+	if len(field) == 3 &&
+		field[0] == control.DataSize.Prefix &&
+		field[2] == control.DataSize.Prefix {
+
+		field, mirror = field[:2], field[2:]
+	}
+
+	sub := &dumper{
+		w:     dp.w,
+		src:   slice(field),
+		base:  dp.base + offset,
+		depth: depth,
+	}
+
+	err = sub.fields(control.NewBytesDecoder(field))
+	if err != nil || mirror == nil {
+		return err
+	}
+
+	return dp.row(offset+2, formatBits(control.DataSize, mirror[0]), "(dz)", "-", depth, "")
+}
+
+// fields writes the lines for each field read by d.
+func (dp *dumper) fields(d control.Decoder) (err error) {
+	for d.Next() {
+		offset := d.Consumed() - 1
+		t := d.Type()
+
+		// Any containers that ended before this field are closed first.
+		err = dp.close(d, offset)
+		if err != nil {
+			return err
+		}
+
+		ctrl := dp.src.at(offset, 1)
+		if ctrl == nil {
+			return fmt.Errorf("control block not available")
+		}
+
+		// The depth is tracked separately from the decoder's stack since
+		// bounded containers are popped as soon as their last byte is
+		// read.
+		depth := dp.depth + len(dp.stack)
+
+		// The symmetric field this is embedded in if this is its first
+		// field.
+		var parent *container
+
+		abbr := t.Abbr
+		if top := dp.top(); top != nil && top.frame.Type == control.ContainerSymmetric {
+			if counted(t) {
+				abbr = "(" + abbr + ")"
+			}
+
+			if len(top.blocks) == 1 {
+				parent = top
+			}
+		}
+
+		if parent != nil && counted(t) {
+			parent.blocks = append(parent.blocks, block{
+				kind:  controlBlock,
+				t:     t,
+				abbr:  abbr,
+				depth: depth,
+				size:  1,
+			})
+		}
+
+		size := "-"
+		preview := ""
+
+		// end is the end of the field's own blocks and data.
+		end := offset + 1
+
+		// more writes the lines following the field's line.
+		var more func() error
+
+		switch t {
+		case control.Data,
+			control.DataSize,
+			control.Data1,
+			control.Data2,
+			control.DataSizeSize:
+
+			dr, n, err := d.DataReader()
+			if err != nil {
+				return err
+			}
+
+			switch t {
+			case control.DataSize, control.DataSizeSize:
+				end += n
+			case control.Data1, control.Data2:
+				end += n - 1
+			}
+
+			if t == control.DataSizeSize {
+				ss := uint64(ctrl[0]&t.Mask) + 1
+				sb := append([]byte{}, dp.src.at(offset+1, ss)...)
+				end += ss
+
+				if parent != nil {
+					parent.blocks = append(parent.blocks, block{
+						kind:  sizeBytes,
+						depth: depth,
+						size:  ss,
+					})
+				}
+
+				more = func() error {
+					return dp.row(offset+1, "", "size", "-", depth, hex.EncodeToString(sb))
+				}
+			}
+
+			// Only the control blocks are kept.
+			dp.src.discard(end)
+
+			data := make([]byte, previewSize)
+			if n < previewSize {
+				data = data[:n]
+			}
+
+			_, err = io.ReadFull(dr, data)
+			if err != nil {
+				return err
+			}
+
+			size = fmt.Sprint(n)
+			preview = formatPreview(data, n)
+		case control.ContainerBounded:
+			n, err := d.Size()
+			if err != nil {
+				return err
+			}
+
+			size = fmt.Sprint(n)
+
+			end = d.Consumed()
+			field := append([]byte{}, dp.src.at(offset+1, end-offset-1)...)
+
+			dp.stack = append(dp.stack, &container{
+				frame: innermost(d),
+				end:   end + n,
+			})
+
+			if parent != nil {
+				parent.blocks = append(parent.blocks, block{
+					kind:  sizeField,
+					depth: depth,
+					size:  uint64(len(field)),
+				})
+			}
+
+			more = func() error {
+				return dp.sizeField(offset+1, field, depth)
+			}
+
+			// Follow the fields embedded in the container.
+			err = d.Enter()
+			if err != nil {
+				return err
+			}
+		case control.ContainerSymmetric:
+			dp.stack = append(dp.stack, &container{
+				frame: innermost(d),
+				blocks: []block{{
+					kind:  controlBlock,
+					t:     t,
+					abbr:  abbr,
+					depth: depth,
+					size:  1,
+				}},
+			})
+
+			err = d.Enter()
+			if err != nil {
+				return err
+			}
+		case control.ContainerUnbounded:
+			dp.stack = append(dp.stack, &container{
+				frame: innermost(d),
+			})
+
+			err = d.Enter()
+			if err != nil {
+				return err
+			}
+		case control.SkipSize:
+			n, err := d.Size()
+			if err != nil {
+				return err
+			}
+
+			end += n
+
+			amount, err := d.Amount()
+			if err != nil {
+				return err
+			}
+
+			size = fmt.Sprint(amount)
+		}
+
+		err = dp.row(offset, formatBits(t, ctrl[0]), abbr, size, depth, preview)
+		if err != nil {
+			return err
+		}
+
+		if more != nil {
+			err = more()
+			if err != nil {
+				return err
+			}
+		}
+
+		dp.src.discard(end)
+	}
+
+	err = d.Err()
+	if err != nil {
+		return err
+	}
+
+	return dp.close(d, d.Consumed())
+}
+
+// innermost returns the decoder's innermost frame.
+func innermost(d control.Decoder) *control.Frame {
+	stack := d.Stack()
+
+	return stack.Top()
+}
+
+// top returns the innermost container or nil if there isn't one.
+func (dp *dumper) top() *container {
+	if len(dp.stack) == 0 {
+		return nil
+	}
+
+	return dp.stack[len(dp.stack)-1]
+}
+
+// close removes the containers that ended before offset and writes the lines
+// for the trailing blocks of the symmetric fields among them. Bounded
+// containers end at a known offset while the others end once the decoder
+// pops them.
+func (dp *dumper) close(d control.Decoder, offset uint64) (err error) {
+	current := map[*control.Frame]bool{}
+	for _, f := range d.Stack() {
+		current[f] = true
+	}
+
+	var closed []*container
+	var size uint64
+
+	for len(dp.stack) > 0 {
+		c := dp.top()
+
+		if c.frame.Type == control.ContainerBounded {
+			if offset < c.end {
+				break
+			}
+		} else if current[c.frame] {
+			break
+		}
+
+		dp.stack = dp.stack[:len(dp.stack)-1]
+		closed = append(closed, c)
+
+		for _, b := range c.blocks {
+			size += b.size
+		}
+	}
+
+	if size > offset {
+		return fmt.Errorf("trailing blocks not available")
+	}
+
+	pos := offset - size
+
+	// The innermost field ends first and the trailing blocks are the
+	// leading blocks in reverse order.
+	for _, c := range closed {
+		for j := len(c.blocks) - 1; j >= 0; j-- {
+			b := c.blocks[j]
+
+			data := dp.src.at(pos, b.size)
+			if data == nil {
+				return fmt.Errorf("trailing blocks not available")
+			}
+
+			switch b.kind {
+			case controlBlock:
+				t := b.t
+				if match, ok := control.Types.Match(data[0]); ok {
+					t = match
+				}
+
+				err = dp.row(pos, formatBits(t, data[0]), b.abbr, "-", b.depth, "")
+			case sizeBytes:
+				err = dp.row(pos, "", "size", "-", b.depth, hex.EncodeToString(data))
+			case sizeField:
+				err = dp.sizeField(pos, append([]byte{}, data...), b.depth)
+			}
+			if err != nil {
+				return err
+			}
+
+			pos += b.size
+		}
+	}
+
+	return nil
+}
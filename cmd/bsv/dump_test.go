@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calebcase/bsv/control"
+)
+
+func TestDump(t *testing.T) {
+	input := &bytes.Buffer{}
+	e := control.NewEncoder(input)
+
+	require.NoError(t, e.Symmetric(func(e control.Encoder) error {
+		return e.Data([]byte{0b_0000_0001, 0b_0000_0010})
+	}))
+	require.NoError(t, e.Bound([]byte{0b_0100_0000, 'A'}))
+	require.NoError(t, e.Data([]byte("hello, world!")))
+	require.NoError(t, e.Unbound(func(e control.Encoder) error {
+		err := e.Skip(6)
+		if err != nil {
+			return err
+		}
+
+		return e.Null()
+	}))
+
+	output := &bytes.Buffer{}
+
+	err := dump(output, input)
+	require.NoError(t, err)
+
+	expected := strings.Join([]string{
+		"OFFSET    BITS        TYPE       SIZE  DEPTH  PREVIEW",
+		"00000000  0000_0111   cs            -      0",
+		"00000001  001|0_0001  (d1)          2      1  0102 |..|",
+		"00000003  001|0_0001  (d1)          -      1",
+		"00000004  0000_0111   cs            -      0",
+		"00000005  0000_0101   cb            2      0",
+		"00000006  1|000_0001  d             1      0  01 |.|",
+		"00000007  01|00_0000  dz            1      1  41 |A|",
+		"00000009  01|00_1100  dz           13      0  68656c6c6f2c2077.. |hello, w|",
+		"00000017  0000_0110   cu            -      0",
+		"00000018  0000_001|0  sz            6      1",
+		"0000001a  0000_0000   n             -      1",
+		"0000001b  0000_0100   ce            -      0",
+		"",
+	}, "\n")
+
+	require.Equal(t, expected, output.String())
+
+	t.Run("symmetric", func(t *testing.T) {
+		input := &bytes.Buffer{}
+		e := control.NewEncoder(input)
+
+		require.NoError(t, e.Symmetric(func(e control.Encoder) error {
+			return e.Data(bytes.Repeat([]byte{'a'}, 300))
+		}))
+		require.NoError(t, e.Symmetric(func(e control.Encoder) error {
+			return e.Bound([]byte{0b_1000_0001})
+		}))
+		require.NoError(t, e.Symmetric(func(e control.Encoder) error {
+			return e.Symmetric(func(e control.Encoder) error {
+				return e.Skip(2)
+			})
+		}))
+
+		output := &bytes.Buffer{}
+
+		err := dump(output, input)
+		require.NoError(t, err)
+
+		expected := strings.Join([]string{
+			"OFFSET    BITS        TYPE       SIZE  DEPTH  PREVIEW",
+			"00000000  0000_0111   cs            -      0",
+			"00000001  0000_1|001  (dzz)       300      1  6161616161616161.. |aaaaaaaa|",
+			"00000002              size          -      1  012b",
+			"00000130              size          -      1  012b",
+			"00000132  0000_1|001  (dzz)         -      1",
+			"00000133  0000_0111   cs            -      0",
+			"00000134  0000_0111   cs            -      0",
+			"00000135  0000_0101   (cb)          1      1",
+			"00000136  1|000_0000  d             1      1  00 |.|",
+			"00000137  1|000_0000  d             1      2  01 |.|",
+			"00000138  1|000_0000  d             1      1  00 |.|",
+			"00000139  0000_0101   (cb)          -      1",
+			"0000013a  0000_0111   cs            -      0",
+			"0000013b  0000_0111   cs            -      0",
+			"0000013c  0000_0111   cs            -      1",
+			"0000013d  0000_001|0  (sz)          2      2",
+			"0000013f  0000_001|0  (sz)          -      2",
+			"00000140  0000_0111   cs            -      1",
+			"00000141  0000_0111   cs            -      0",
+			"",
+		}, "\n")
+
+		require.Equal(t, expected, output.String())
+	})
+
+	t.Run("symmetric size", func(t *testing.T) {
+		bsv := &bytes.Buffer{}
+		require.NoError(t, control.NewEncoder(bsv).Data(bytes.Repeat([]byte{'a'}, 150)))
+
+		input := &bytes.Buffer{}
+		e := control.NewEncoder(input)
+
+		require.NoError(t, e.Symmetric(func(e control.Encoder) error {
+			return e.Bound(bsv.Bytes())
+		}))
+		require.NoError(t, e.Null())
+
+		output := &bytes.Buffer{}
+
+		err := dump(output, input)
+		require.NoError(t, err)
+
+		expected := strings.Join([]string{
+			"OFFSET    BITS        TYPE       SIZE  DEPTH  PREVIEW",
+			"00000000  0000_0111   cs            -      0",
+			"00000001  0000_0101   (cb)        152      1",
+			"00000002  01|00_0000  dz            1      1  97 |.|",
+			"00000004  01|00_0000  (dz)          -      1",
+			"00000005  0000_1|000  dzz         150      2  6161616161616161.. |aaaaaaaa|",
+			"00000006              size          -      2  95",
+			"0000009d  01|00_0000  dz            1      1  97 |.|",
+			"0000009f  01|00_0000  (dz)          -      1",
+			"000000a0  0000_0101   (cb)          -      1",
+			"000000a1  0000_0111   cs            -      0",
+			"000000a2  0000_0000   n             -      0",
+			"",
+		}, "\n")
+
+		require.Equal(t, expected, output.String())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		output := &bytes.Buffer{}
+
+		err := dump(output, bytes.NewReader([]byte{0b_1000_0001, 0b_0000_0100}))
+		require.Error(t, err)
+		require.Contains(t, output.String(), "00000000  1|000_0001  d")
+	})
+}
@@ -0,0 +1,89 @@
+// Command bsv is a tool for inspecting BSV encoded data.
+//
+// Usage:
+//
+//	bsv dump [file ...]
+//
+// The dump subcommand prints one line per control block. If no files are
+// given (or a file is named "-") standard input is read.
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `usage: bsv <command> [arguments]
+
+commands:
+  dump [file ...]  print the control blocks of each file (or stdin)
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+
+	switch os.Args[1] {
+	case "dump":
+		err = dumpFiles(os.Stdout, os.Args[2:])
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(os.Stdout, usage)
+	default:
+		fmt.Fprintf(os.Stderr, "bsv: unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "bsv: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// dumpFiles dumps each of the named files (or stdin if there are none).
+func dumpFiles(w io.Writer, names []string) (err error) {
+	if len(names) == 0 {
+		names = []string{"-"}
+	}
+
+	for i, name := range names {
+		if len(names) > 1 {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+
+			fmt.Fprintf(w, "==> %s <==\n", name)
+		}
+
+		err = dumpFile(w, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func dumpFile(w io.Writer, name string) (err error) {
+	if name == "-" {
+		return dump(w, bufio.NewReader(os.Stdin))
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	err = dump(w, bufio.NewReader(f))
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	return nil
+}
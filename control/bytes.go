@@ -0,0 +1,848 @@
+package control
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+
+	"github.com/calebcase/oops"
+)
+
+// bytesDecoder decodes fields from an in memory BSV. Data and BSV are returned
+// as subslices of the input whenever possible. Data, Data + 1 and Data + 2
+// fields include bits from the control block and are returned in a buffer
+// owned by the decoder that is only valid until the next call to Next.
+type bytesDecoder struct {
+	in  []byte
+	off int
+
+	opts DecoderOptions
+
+	// base, offset and parent are the depth, consumed bytes and scope of
+	// the parent decoder when decoding the size of a bounded container.
+	// sizes is the number of bounded container sizes the parents are
+	// decoding.
+	base   int
+	offset uint64
+	parent *scope
+	sizes  int
+
+	consumed uint64
+
+	stack *Stack
+
+	value    [1]byte
+	t        Type
+	finished bool
+
+	size    uint64
+	data    []byte
+	scratch [3]byte
+	amount  uint64
+
+	err error
+}
+
+// NewBytesDecoder returns a decoder that reads fields directly from bsv
+// without copying.
+func NewBytesDecoder(bsv []byte) Decoder {
+	return NewBytesDecoderWithOptions(bsv, DecoderOptions{})
+}
+
+// NewBytesDecoderWithOptions returns a decoder that reads fields directly from
+// bsv without copying and enforces the limits in opts. Exceeding a limit
+// results in an error wrapping ErrLimitExceeded.
+func NewBytesDecoderWithOptions(bsv []byte, opts DecoderOptions) Decoder {
+	return &bytesDecoder{
+		in:    bsv,
+		opts:  opts,
+		stack: &Stack{},
+	}
+}
+
+// checkTotal verifies that reading size more bytes stays within the total
+// limit.
+func (d *bytesDecoder) checkTotal(size uint64) (err error) {
+	return d.opts.checkTotal(d.offset+d.consumed, size)
+}
+
+// push adds the frame to the stack if doing so stays within the depth limit.
+func (d *bytesDecoder) push(f *Frame) (err error) {
+	err = d.opts.checkDepth(d.base + d.Depth() + 1)
+	if err != nil {
+		return err
+	}
+
+	d.stack.Push(f)
+
+	return nil
+}
+
+// record adds a copy of the block to the leading control blocks of the
+// symmetric container currently being read when verifying symmetric fields.
+func (d *bytesDecoder) record(block []byte) {
+	if !d.opts.StrictSymmetric {
+		return
+	}
+
+	d.stack.record(block)
+}
+
+// uint64Size converts size bytes to a size. Sizes are indexed from 1.
+func uint64Size(sizeBytes []byte) (size uint64, err error) {
+	for len(sizeBytes) > 0 && sizeBytes[0] == 0 {
+		sizeBytes = sizeBytes[1:]
+	}
+
+	if len(sizeBytes) > 8 {
+		return 0, Error.New("unimplemented: size >= 2^64")
+	}
+
+	for _, b := range sizeBytes {
+		size = size<<8 | uint64(b)
+	}
+
+	if size == math.MaxUint64 {
+		return 0, Error.New("unimplemented: size >= 2^64")
+	}
+
+	return size + 1, nil
+}
+
+// read returns the next size bytes of the input.
+func (d *bytesDecoder) read(size uint64) (data []byte, err error) {
+	remaining := uint64(len(d.in) - d.off)
+
+	if size > remaining {
+		return nil, Error.Trace(io.ErrUnexpectedEOF)
+	}
+
+	data = d.in[d.off : d.off+int(size)]
+
+	d.off += int(size)
+	d.consumed += size
+
+	err = d.stack.Consume(size)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// seek moves the current position forward by size bytes.
+func (d *bytesDecoder) seek(size uint64) (err error) {
+	if size > uint64(len(d.in)-d.off) {
+		return Error.Trace(io.ErrUnexpectedEOF)
+	}
+
+	d.off += int(size)
+	d.consumed += size
+
+	err = d.stack.Consume(size)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// trailing skips the trailing control blocks of any symmetric fields that
+// have been fully read.
+func (d *bytesDecoder) trailing() (err error) {
+	for {
+		top := d.stack.Top()
+		if top == nil ||
+			top.Type != ContainerSymmetric ||
+			top.Subtype == Unknown {
+
+			return nil
+		}
+
+		if d.opts.StrictSymmetric {
+			start := d.offset + d.consumed
+
+			var trailing []byte
+
+			trailing, err = d.read(top.Count)
+			if err == nil {
+				err = mirrored(top, trailing, start)
+			}
+		} else {
+			err = d.seek(top.Count)
+		}
+		if err != nil {
+			return err
+		}
+
+		err = d.stack.Pop()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// embedded returns true if the current field is the field embedded in a
+// symmetric container.
+func (d *bytesDecoder) embedded() bool {
+	s := *d.stack
+	if len(s) < 2 {
+		return false
+	}
+
+	return s[len(s)-2].Type == ContainerSymmetric
+}
+
+// Seek moves the reading position to the end of the current field.
+func (d *bytesDecoder) Seek() (err error) {
+	defer func() {
+		if err != nil {
+			err = d.locate(err)
+			d.err = err
+		}
+	}()
+
+	if d.consumed == 0 {
+		return nil
+	}
+
+	switch d.t {
+	case Data:
+		// No additional bytes need to be read.
+	case DataSize, DataSizeSize, ContainerBounded:
+		size, err := d.Size()
+		if err != nil {
+			return err
+		}
+
+		// Seek past the data if we haven't read it yet.
+		if d.data == nil {
+			err = d.seek(size)
+			if err != nil {
+				return err
+			}
+		}
+	case Data1, Data2:
+		// Small enough to just read directly.
+		_, err := d.Data()
+		if err != nil {
+			return err
+		}
+	case ContainerSymmetric:
+		if !d.finished {
+			d.finished = true
+
+			// Move to the embedded field.
+			ok := d.Next()
+			if d.Err() != nil {
+				return d.Err()
+			}
+			if !ok {
+				return
+			}
+
+			// Seek past the embedded field.
+			err = d.Seek()
+			if err != nil {
+				return err
+			}
+		}
+	case ContainerUnbounded:
+		// Read tokens until the matching ContainerEnd is found. Depth
+		// will be one less than our current.
+		target := d.Depth() - 1
+		d.finished = true
+
+		for d.Next() {
+			t := d.Type()
+			if t == ContainerEnd && target == d.Depth() {
+				break
+			}
+		}
+		err = d.Err()
+		if err != nil {
+			return err
+		}
+	case ContainerEnd:
+		// No additional bytes need to be read.
+	case SkipSize:
+		_, err := d.Amount()
+		if err != nil {
+			return err
+		}
+	case Empty:
+		// No additional bytes need to be read.
+	case Null:
+		// No additional bytes need to be read.
+	default:
+		return Error.New("unknown field %q: %0b", d.t.Abbr, d.value)
+	}
+
+	// Skip over trailing symmetric control blocks.
+	err = d.trailing()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// locate adds the current position to decoding errors.
+func (d *bytesDecoder) locate(err error) error {
+	return locate(err, d.offset+d.consumed, d.value[0], d.parent, *d.stack)
+}
+
+func (d *bytesDecoder) Next() (ok bool) {
+	ok = d.next()
+	if d.err != nil {
+		d.err = d.locate(d.err)
+	}
+
+	return ok
+}
+
+func (d *bytesDecoder) next() (ok bool) {
+	// Ensure current field was fully read before moving on...
+	if !d.finished {
+		d.err = d.Seek()
+		if d.err != nil {
+			return false
+		}
+	} else {
+		d.err = d.trailing()
+		if d.err != nil {
+			return false
+		}
+	}
+
+	// Reset state for next field.
+	d.value[0] = 0
+	d.t = Unknown
+
+	d.size = 0
+	d.data = nil
+	d.amount = 0
+	d.finished = false
+
+	if d.off == len(d.in) {
+		if d.Depth() != 0 {
+			d.err = Error.New("unexpected end of input: depth=%d: %w", d.Depth(), io.ErrUnexpectedEOF)
+		}
+
+		return false
+	}
+
+	// Read the field control block.
+	value, err := d.read(1)
+	if err != nil {
+		d.err = err
+
+		return false
+	}
+
+	d.value[0] = value[0]
+
+	d.err = d.checkTotal(0)
+	if d.err != nil {
+		return false
+	}
+
+	t, ok := Types.Match(d.value[0])
+	if !ok {
+		d.err = oops.New("%w: %0b", ErrUnexpectedByte, d.value[0])
+
+		return false
+	}
+
+	switch t {
+	case Data, Empty, Null:
+		// These are single byte fields that don't add to the symmetric
+		// control block count and are fully read.
+	case ContainerUnbounded, ContainerEnd:
+		// These are already symmetric and do not add to the symmetric
+		// control block count.
+	case ContainerSymmetric:
+		// Nested symmetric containers track their own control blocks.
+	default:
+		d.stack.Count(1)
+		d.record(d.value[:])
+	}
+
+	if top := d.stack.Top(); top != nil &&
+		top.Type == ContainerSymmetric &&
+		top.Subtype == Unknown {
+
+		top.Subtype = t
+	}
+
+	switch t {
+	case Data:
+		d.finished = true
+	case ContainerSymmetric:
+		d.err = d.push(&Frame{
+			Type:  t,
+			Count: 1,
+		})
+		if d.err != nil {
+			return false
+		}
+
+		d.record(d.value[:])
+	case ContainerBounded:
+		d.err = d.push(&Frame{
+			Type: t,
+		})
+		if d.err != nil {
+			return false
+		}
+	case ContainerUnbounded:
+		d.err = d.push(&Frame{
+			Type: t,
+		})
+		if d.err != nil {
+			return false
+		}
+	case ContainerEnd:
+		top := d.stack.Top()
+		if top == nil {
+			d.err = oops.New("%w (not in a container)", ErrUnpairedEnd)
+
+			return false
+		}
+
+		if top.Type != ContainerUnbounded {
+			d.err = oops.New(
+				"%w (container not unbounded): %s",
+				ErrUnpairedEnd,
+				top.Type.Abbr,
+			)
+
+			return false
+		}
+
+		d.err = d.stack.Pop()
+		if d.err != nil {
+			return false
+		}
+
+		d.finished = true
+	case Empty:
+		d.finished = true
+	case Null:
+		d.finished = true
+	}
+
+	d.t = t
+
+	return true
+}
+
+func (d *bytesDecoder) Err() error {
+	return d.err
+}
+
+func (d *bytesDecoder) Type() Type {
+	return d.t
+}
+
+func (d *bytesDecoder) Depth() int {
+	return len(*d.stack)
+}
+
+func (d *bytesDecoder) Stack() Stack {
+	return *d.stack
+}
+
+func (d *bytesDecoder) Consumed() uint64 {
+	return d.consumed
+}
+
+func (d *bytesDecoder) Size() (_ uint64, err error) {
+	defer func() {
+		if err != nil {
+			d.size = 0
+			err = d.locate(err)
+			d.err = err
+		}
+	}()
+
+	if d.size != 0 {
+		return d.size, nil
+	}
+
+	switch d.t {
+	case Data:
+		d.size = 1
+	case DataSize:
+		size := uint64(d.value[0]&d.t.Mask) + 1
+
+		err = d.opts.checkFieldSize(size)
+		if err != nil {
+			return 0, err
+		}
+
+		err = d.checkTotal(size)
+		if err != nil {
+			return 0, err
+		}
+
+		d.size = size
+	case Data1:
+		d.size = 2
+	case Data2:
+		d.size = 3
+	case DataSizeSize:
+		sizeSize := uint64(d.value[0]&d.t.Mask) + 1
+
+		err = d.checkTotal(sizeSize)
+		if err != nil {
+			return 0, err
+		}
+
+		sizeBytes, err := d.read(sizeSize)
+		if err != nil {
+			return 0, err
+		}
+
+		d.stack.Count(sizeSize)
+		d.record(sizeBytes)
+
+		size, err := uint64Size(sizeBytes)
+		if err != nil {
+			return 0, err
+		}
+
+		err = d.opts.checkFieldSize(size)
+		if err != nil {
+			return 0, err
+		}
+
+		err = d.checkTotal(size)
+		if err != nil {
+			return 0, err
+		}
+
+		d.size = size
+	case ContainerBounded:
+		err = checkSizeDepth(d.sizes + 1)
+		if err != nil {
+			return 0, err
+		}
+
+		zd := &bytesDecoder{
+			in:     d.in[d.off:],
+			opts:   d.opts,
+			base:   d.base + d.Depth(),
+			offset: d.offset + d.consumed,
+			parent: &scope{d.parent, d.stack},
+			sizes:  d.sizes + 1,
+			stack:  &Stack{},
+		}
+
+		ok := zd.Next()
+		if zd.Err() != nil {
+			return 0, zd.Err()
+		}
+		if !ok {
+			return 0, Error.New("unabled to read container bounded size: %w", io.ErrUnexpectedEOF)
+		}
+
+		bare := zd.Type() == DataSize
+
+		switch zd.Type() {
+		case ContainerSymmetric, ContainerBounded, ContainerUnbounded:
+			err = zd.Enter()
+			if err != nil {
+				return 0, err
+			}
+
+			ok := zd.Next()
+			if zd.Err() != nil {
+				return 0, zd.Err()
+			}
+			if !ok {
+				return 0, Error.New("unabled to read container bounded size: %w", io.ErrUnexpectedEOF)
+			}
+		}
+
+		sizeBytes, err := zd.Data()
+		if err != nil {
+			return 0, err
+		}
+
+		size, err := uint64Size(sizeBytes)
+		if err != nil {
+			return 0, err
+		}
+
+		consumed := zd.Consumed()
+
+		// The symmetric encoder writes a single byte size above 127
+		// without a symmetric container, repeating its control block
+		// after the value instead.
+		if bare && len(sizeBytes) == 1 && d.embedded() {
+			off := d.off + int(consumed)
+			if off >= len(d.in) {
+				return 0, Error.Trace(io.ErrUnexpectedEOF)
+			}
+
+			if d.in[off] != DataSize.Prefix {
+				return 0, oops.New("%w (symmetric bounded size): %0b", ErrUnexpectedByte, d.in[off])
+			}
+
+			consumed++
+		}
+
+		sizeField := d.in[d.off : d.off+int(consumed)]
+
+		err = d.seek(consumed)
+		if err != nil {
+			return 0, err
+		}
+		d.stack.Count(consumed)
+		d.record(sizeField)
+
+		err = d.opts.checkBoundedSize(size)
+		if err != nil {
+			return 0, err
+		}
+
+		err = d.checkTotal(size)
+		if err != nil {
+			return 0, err
+		}
+
+		d.size = size
+
+		top := d.stack.Top()
+		top.Size = d.size
+		top.Remaining = d.size
+
+		d.finished = true
+	case SkipSize:
+		d.size = uint64(d.value[0]&d.t.Mask) + 1
+	default:
+		return 0, oops.Trace(ErrInvalidOperation)
+	}
+
+	return d.size, nil
+}
+
+// Data returns the data bits and bytes from the field. If the field does not
+// contain data it returns nil and ErrInvalidOperation.
+func (d *bytesDecoder) Data() (data []byte, err error) {
+	defer func() {
+		if err != nil {
+			d.data = nil
+			err = d.locate(err)
+			d.err = err
+		}
+	}()
+
+	if d.t != Data && d.t != Data1 && d.t != Data2 && d.t != DataSize && d.t != DataSizeSize {
+		return nil, oops.Trace(ErrInvalidOperation)
+	}
+
+	if d.data != nil {
+		return d.data, nil
+	}
+
+	_, err = d.Size()
+	if err != nil {
+		return nil, err
+	}
+
+	switch d.t {
+	case Data:
+		d.scratch[0] = d.value[0] & d.t.Mask
+		d.data = d.scratch[:1]
+	case DataSize, DataSizeSize:
+		d.data, err = d.read(d.size)
+		if err != nil {
+			return nil, err
+		}
+
+		d.finished = true
+	case Data1, Data2:
+		tail, err := d.read(d.size - 1)
+		if err != nil {
+			return nil, err
+		}
+
+		d.scratch[0] = d.value[0] & d.t.Mask
+		copy(d.scratch[1:], tail)
+		d.data = d.scratch[:d.size]
+
+		d.finished = true
+	}
+
+	// Skip over trailing symmetric control blocks.
+	err = d.trailing()
+	if err != nil {
+		return nil, err
+	}
+
+	return d.data, nil
+}
+
+// DataReader returns a reader over the field's data and the data size. If the
+// field does not contain data it returns nil and ErrInvalidOperation.
+func (d *bytesDecoder) DataReader() (r io.Reader, size uint64, err error) {
+	data, err := d.Data()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return bytes.NewReader(data), uint64(len(data)), nil
+}
+
+// Enter informs decoder that the ContainerBounded or ContainerUnbounded field
+// should be entered.  If the current field type is not ContainerBounded or
+// ContainerUnbounded, then it returns ErrInvalidOperation.
+func (d *bytesDecoder) Enter() (err error) {
+	defer func() {
+		if err != nil {
+			err = d.locate(err)
+			d.err = err
+		}
+	}()
+
+	switch d.t {
+	case ContainerSymmetric:
+		d.finished = true
+	case ContainerBounded:
+		_, err := d.Size()
+		if err != nil {
+			return err
+		}
+	case ContainerUnbounded:
+		d.finished = true
+	default:
+		return oops.Trace(ErrInvalidOperation)
+	}
+
+	return nil
+}
+
+// BSV returns the embedded BSV as a subslice of the input. If the current
+// field type is not ContainerBounded, then it returns nil and
+// ErrInvalidOperation.
+func (d *bytesDecoder) BSV() (bsv []byte, err error) {
+	defer func() {
+		if err != nil {
+			d.data = nil
+			err = d.locate(err)
+			d.err = err
+		}
+	}()
+
+	if d.t != ContainerBounded {
+		return nil, oops.Trace(ErrInvalidOperation)
+	}
+
+	err = d.Enter()
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := d.Size()
+	if err != nil {
+		return nil, err
+	}
+
+	d.data, err = d.read(size)
+	if err != nil {
+		return nil, err
+	}
+
+	// Skip over trailing symmetric control blocks.
+	err = d.trailing()
+	if err != nil {
+		return nil, err
+	}
+
+	return d.data, nil
+}
+
+// Amount returns the skip amount. If the current field type is not SkipSize,
+// then it returns 0 and ErrInvalidOperation.
+func (d *bytesDecoder) Amount() (_ uint64, err error) {
+	defer func() {
+		if err != nil {
+			d.amount = 0
+			err = d.locate(err)
+			d.err = err
+		}
+	}()
+
+	if d.t != SkipSize {
+		return 0, oops.Trace(ErrInvalidOperation)
+	}
+
+	if d.amount != 0 {
+		return d.amount, nil
+	}
+
+	_, err = d.Size()
+	if err != nil {
+		return 0, err
+	}
+
+	amountBytes, err := d.read(d.size)
+	if err != nil {
+		return 0, err
+	}
+
+	var padded [8]byte
+	copy(padded[8-len(amountBytes):], amountBytes)
+
+	d.amount = binary.BigEndian.Uint64(padded[:]) + 1
+
+	d.finished = true
+
+	// Skip over trailing symmetric control blocks.
+	err = d.trailing()
+	if err != nil {
+		return 0, err
+	}
+
+	return d.amount, nil
+}
+
+// SeekToField moves the reading position to the start of field i in the index
+// so the next call to Next reads it. The stack is reset so the field is read
+// as if it were at the top level.
+func (d *bytesDecoder) SeekToField(idx Index, i int) (err error) {
+	defer func() {
+		if err != nil {
+			err = d.locate(err)
+			d.err = err
+		}
+	}()
+
+	entry, err := checkField(idx, i)
+	if err != nil {
+		return err
+	}
+
+	if entry.Offset > uint64(len(d.in)) {
+		return Error.New("invalid field offset: %d", entry.Offset)
+	}
+
+	d.off = int(entry.Offset)
+	d.consumed = entry.Offset
+	d.stack = &Stack{}
+
+	d.value[0] = 0
+	d.t = Unknown
+	d.finished = true
+
+	d.size = 0
+	d.data = nil
+	d.amount = 0
+
+	d.err = nil
+
+	return nil
+}
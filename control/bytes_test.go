@@ -0,0 +1,173 @@
+package control_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calebcase/bsv/control"
+	"github.com/calebcase/oops"
+)
+
+func TestBytesDecoder(t *testing.T) {
+	type TC struct {
+		Fn   func(control.Encoder) error
+		Mark error
+	}
+
+	tcs := []TC{
+		{
+			Fn: func(e control.Encoder) (err error) {
+				return e.Data([]byte{0b_0000_0001})
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Fn: func(e control.Encoder) (err error) {
+				return e.Data([]byte{0b_0000_0001, 0b_0000_0010})
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Fn: func(e control.Encoder) (err error) {
+				return e.Data([]byte{0b_0000_0001, 0b_0000_0010, 0b_0000_0011})
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Fn: func(e control.Encoder) (err error) {
+				return e.Data(bytes.Repeat([]byte{1}, 1024))
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Fn: func(e control.Encoder) (err error) {
+				return e.Bound(bytes.Repeat([]byte{0b_1000_0001}, 200))
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Fn: func(e control.Encoder) (err error) {
+				return e.Skip(512)
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Fn: func(e control.Encoder) (err error) {
+				return e.Unbound(func(e control.Encoder) (err error) {
+					err = e.Empty()
+					if err != nil {
+						return err
+					}
+
+					return e.Null()
+				})
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Fn: func(e control.Encoder) (err error) {
+				return e.Symmetric(func(e control.Encoder) (err error) {
+					return e.Bound([]byte{0b_1000_0001})
+				})
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Fn: func(e control.Encoder) (err error) {
+				return e.Symmetric(func(e control.Encoder) (err error) {
+					return e.Bound(bytes.Repeat([]byte{0b_1000_0001}, 200))
+				})
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Fn: func(e control.Encoder) (err error) {
+				return e.Symmetric(func(e control.Encoder) (err error) {
+					return e.Data(bytes.Repeat([]byte{1}, 100))
+				})
+			},
+			Mark: oops.New("unexpected"),
+		},
+	}
+
+	for i, tc := range tcs {
+		output := &bytes.Buffer{}
+		e := control.NewEncoder(output)
+
+		err := tc.Fn(e)
+		require.NoError(t, err, tc.Mark)
+
+		t.Run(shortName(i, output.Bytes()), func(t *testing.T) {
+			rd := control.NewDecoder(bytes.NewReader(output.Bytes()))
+			bd := control.NewBytesDecoder(output.Bytes())
+
+			require.Equal(t, walk(t, rd, tc.Mark), walk(t, bd, tc.Mark), tc.Mark)
+			require.Equal(t, rd.Consumed(), bd.Consumed(), tc.Mark)
+
+			// Skipping every field must also agree.
+			rd = control.NewDecoder(bytes.NewReader(output.Bytes()))
+			bd = control.NewBytesDecoder(output.Bytes())
+
+			for rd.Next() {
+				require.True(t, bd.Next(), tc.Mark)
+				require.Equal(t, rd.Type(), bd.Type(), tc.Mark)
+				require.Equal(t, rd.Stack(), bd.Stack(), tc.Mark)
+				require.Equal(t, rd.Consumed(), bd.Consumed(), tc.Mark)
+			}
+			require.NoError(t, rd.Err(), tc.Mark)
+			require.False(t, bd.Next(), tc.Mark)
+			require.NoError(t, bd.Err(), tc.Mark)
+			require.Equal(t, rd.Consumed(), bd.Consumed(), tc.Mark)
+
+			// Truncated input must fail in the same way.
+			truncated := output.Bytes()[:output.Len()-1]
+			if len(truncated) == 0 {
+				return
+			}
+
+			rd = control.NewDecoder(bytes.NewBuffer(truncated))
+			for rd.Next() {
+			}
+
+			bd = control.NewBytesDecoder(truncated)
+			for bd.Next() {
+			}
+
+			require.Equal(t, rd.Err() != nil, bd.Err() != nil, tc.Mark)
+		})
+	}
+
+	t.Run("zero copy", func(t *testing.T) {
+		output := &bytes.Buffer{}
+		e := control.NewEncoder(output)
+
+		err := e.Data([]byte{1, 2, 3, 4})
+		require.NoError(t, err)
+
+		err = e.Bound([]byte{0b_1000_0001})
+		require.NoError(t, err)
+
+		input := output.Bytes()
+
+		d := control.NewBytesDecoder(input)
+
+		require.True(t, d.Next())
+		data, err := d.Data()
+		require.NoError(t, err)
+		require.Equal(t, &input[1], &data[0])
+
+		require.True(t, d.Next())
+		bsv, err := d.BSV()
+		require.NoError(t, err)
+		require.Equal(t, &input[len(input)-1], &bsv[0])
+
+		allocs := testing.AllocsPerRun(100, func() {
+			d := control.NewBytesDecoder(input)
+			d.Next()
+			d.Data()
+		})
+		require.LessOrEqual(t, allocs, 2.0)
+	})
+}
@@ -0,0 +1,173 @@
+package control
+
+import (
+	"bytes"
+	"io"
+)
+
+// Canonicalize re-encodes the BSV in src to dst using the blocks the encoder
+// would choose. Bounded container sizes are recomputed from their re-encoded
+// contents. Symmetric, unbounded, skip, empty and null fields are preserved.
+// The limits in opts apply to src, including the contents of bounded
+// containers. Use HardenedDecoderOptions when src is untrusted.
+func Canonicalize(dst io.Writer, src io.Reader, opts DecoderOptions) (err error) {
+	return canonicalizeAll(
+		&encoder{w: dst},
+		NewDecoderWithOptions(src, opts),
+		opts,
+		0,
+	)
+}
+
+// canonicalizeAll re-encodes every remaining field of d with e. The fields of
+// d are nested in base containers.
+func canonicalizeAll(e *encoder, d Decoder, opts DecoderOptions, base int) (err error) {
+	for d.Next() {
+		err = canonicalize(e, d, opts, base)
+		if err != nil {
+			return err
+		}
+	}
+
+	return d.Err()
+}
+
+// canonicalize re-encodes the current field of d with e.
+func canonicalize(e *encoder, d Decoder, opts DecoderOptions, base int) (err error) {
+	switch d.Type() {
+	case ContainerSymmetric, ContainerBounded, ContainerUnbounded:
+		err = opts.checkDepth(base + d.Depth())
+		if err != nil {
+			return err
+		}
+	}
+
+	switch d.Type() {
+	case Data, DataSize, Data1, Data2, DataSizeSize:
+		size, err := d.Size()
+		if err != nil {
+			return err
+		}
+
+		// Large fields are streamed instead of being read into memory.
+		if size > 64 {
+			r, size, err := d.DataReader()
+			if err != nil {
+				return err
+			}
+
+			return e.DataFrom(r, size)
+		}
+
+		data, err := d.Data()
+		if err != nil {
+			return err
+		}
+
+		return e.Data(data)
+	case ContainerSymmetric:
+		err = d.Enter()
+		if err != nil {
+			return err
+		}
+
+		if !d.Next() {
+			if d.Err() != nil {
+				return d.Err()
+			}
+
+			return Error.New("unexpected end of input (symmetric container empty)")
+		}
+
+		// The symmetric container is written here rather than by
+		// Encoder.Symmetric so it is kept even where the encoder would
+		// leave it out.
+		se := &encoder{w: e.w}
+
+		switch d.Type() {
+		case ContainerSymmetric, ContainerUnbounded, Empty, Null:
+			// These have no trailing control blocks.
+		default:
+			se.symmetric = true
+		}
+
+		_, err = e.w.Write([]byte{ContainerSymmetric.Prefix})
+		if err != nil {
+			return err
+		}
+
+		err = canonicalize(se, d, opts, base)
+		if err != nil {
+			return err
+		}
+
+		_, err = e.w.Write([]byte{ContainerSymmetric.Prefix})
+		if err != nil {
+			return err
+		}
+
+		return nil
+	case ContainerBounded:
+		depth := d.Depth()
+
+		bsv, err := d.BSV()
+		if err != nil {
+			return err
+		}
+
+		buf := buffers.Get().(*bytes.Buffer)
+		defer buffers.Put(buf)
+		buf.Reset()
+
+		err = canonicalizeAll(
+			&encoder{w: buf},
+			NewDecoderWithOptions(bytes.NewReader(bsv), opts),
+			opts,
+			base+depth,
+		)
+		if err != nil {
+			return err
+		}
+
+		return e.Bound(buf.Bytes())
+	case ContainerUnbounded:
+		err = d.Enter()
+		if err != nil {
+			return err
+		}
+
+		depth := d.Depth()
+
+		return e.Unbound(func(Encoder) error {
+			for d.Next() {
+				if d.Type() == ContainerEnd && d.Depth() == depth-1 {
+					return nil
+				}
+
+				err := canonicalize(e, d, opts, base)
+				if err != nil {
+					return err
+				}
+			}
+
+			if d.Err() != nil {
+				return d.Err()
+			}
+
+			return Error.New("unexpected end of input (unbounded container not ended)")
+		})
+	case SkipSize:
+		amount, err := d.Amount()
+		if err != nil {
+			return err
+		}
+
+		return e.Skip(amount)
+	case Empty:
+		return e.Empty()
+	case Null:
+		return e.Null()
+	default:
+		return Error.New("unexpected field: %s", d.Type().Abbr)
+	}
+}
@@ -0,0 +1,247 @@
+package control_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calebcase/bsv/control"
+	"github.com/calebcase/oops"
+)
+
+func TestCanonicalize(t *testing.T) {
+	type TC struct {
+		Input  []byte
+		Output []byte
+		Mark   error
+	}
+
+	tcs := []TC{
+		{
+			Input:  []byte{0b_0100_0000, 0b_0000_0101},
+			Output: []byte{0b_1000_0101},
+			Mark:   oops.New("unexpected"),
+		},
+		{
+			Input:  []byte{0b_0100_0001, 0b_0000_0001, 0b_0000_0010},
+			Output: []byte{0b_0010_0001, 0b_0000_0010},
+			Mark:   oops.New("unexpected"),
+		},
+		{
+			Input:  []byte{0b_0000_1000, 0b_0000_0010, 0b_0000_0001, 0b_0000_0010, 0b_0000_0011},
+			Output: []byte{0b_0001_0001, 0b_0000_0010, 0b_0000_0011},
+			Mark:   oops.New("unexpected"),
+		},
+		{
+			Input: append(
+				[]byte{0b_0000_1001, 0b_0000_0000, 0b_0100_0000},
+				bytes.Repeat([]byte{1}, 65)...,
+			),
+			Output: append(
+				[]byte{0b_0000_1000, 0b_0100_0000},
+				bytes.Repeat([]byte{1}, 65)...,
+			),
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Input:  []byte{0b_0000_0011, 0b_0000_0000, 0b_0000_0101},
+			Output: []byte{0b_0000_0010, 0b_0000_0101},
+			Mark:   oops.New("unexpected"),
+		},
+		{
+			// Bounded container with a fixed width size and a non-minimal
+			// embedded field.
+			Input: []byte{
+				0b_0000_0101,
+				0b_0100_0111, 0, 0, 0, 0, 0, 0, 0, 1,
+				0b_0100_0000, 0b_0000_0101,
+			},
+			Output: []byte{
+				0b_0000_0101,
+				0b_1000_0000,
+				0b_1000_0101,
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Input: []byte{
+				0b_0000_0110,
+				0b_0100_0000, 0b_0000_0101,
+				0b_0000_0001,
+				0b_0000_0000,
+				0b_0000_0100,
+			},
+			Output: []byte{
+				0b_0000_0110,
+				0b_1000_0101,
+				0b_0000_0001,
+				0b_0000_0000,
+				0b_0000_0100,
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Input: []byte{
+				0b_0000_0111,
+				0b_0100_0001, 0b_1000_0001, 0b_0000_0010, 0b_0100_0001,
+				0b_0000_0111,
+			},
+			Output: []byte{
+				0b_0000_0111,
+				0b_0100_0001, 0b_1000_0001, 0b_0000_0010, 0b_0100_0001,
+				0b_0000_0111,
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Input: []byte{
+				0b_0000_0111,
+				0b_0100_0000, 0b_0000_0101, 0b_0100_0000,
+				0b_0000_0111,
+			},
+			Output: []byte{
+				0b_0000_0111,
+				0b_1000_0101,
+				0b_0000_0111,
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Input: []byte{
+				0b_0000_0111,
+				0b_0100_0000, 0b_1000_0101, 0b_0100_0000,
+				0b_0000_0111,
+			},
+			Output: []byte{
+				0b_0000_0111,
+				0b_0100_0000, 0b_1000_0101, 0b_0100_0000,
+				0b_0000_0111,
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Input: []byte{
+				0b_0000_0111,
+				0b_0000_0110,
+				0b_1000_0001,
+				0b_0000_0100,
+				0b_0000_0111,
+			},
+			Output: []byte{
+				0b_0000_0111,
+				0b_0000_0110,
+				0b_1000_0001,
+				0b_0000_0100,
+				0b_0000_0111,
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Input: []byte{
+				0b_0000_0111,
+				0b_0000_0110,
+				0b_0100_0000, 0b_0000_0101,
+				0b_0000_0100,
+				0b_0000_0111,
+			},
+			Output: []byte{
+				0b_0000_0111,
+				0b_0000_0110,
+				0b_1000_0101,
+				0b_0000_0100,
+				0b_0000_0111,
+			},
+			Mark: oops.New("unexpected"),
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(shortName(i, tc.Input), func(t *testing.T) {
+			output := &bytes.Buffer{}
+
+			err := control.Canonicalize(output, bytes.NewReader(tc.Input), control.DecoderOptions{})
+			require.NoError(t, err, tc.Mark)
+			require.Equal(t, tc.Output, output.Bytes(), tc.Mark)
+
+			problems, err := control.Validate(bytes.NewReader(output.Bytes()), control.ValidateOptions{
+				Canonical: true,
+			})
+			require.NoError(t, err, tc.Mark)
+			require.Empty(t, problems, tc.Mark)
+		})
+	}
+
+	t.Run("idempotent", func(t *testing.T) {
+		input := &bytes.Buffer{}
+		e := control.NewEncoder(input)
+		require.NoError(t, e.Data([]byte{0xff}))
+		require.NoError(t, e.Data([]byte{1, 2, 3}))
+		require.NoError(t, e.Data(bytes.Repeat([]byte{1}, 1024)))
+		require.NoError(t, e.Bound(bytes.Repeat([]byte{0b_1000_0001}, 200)))
+		require.NoError(t, e.Skip(1000))
+		require.NoError(t, e.Symmetric(func(e control.Encoder) error {
+			return e.Bound([]byte{0b_1000_0001})
+		}))
+		require.NoError(t, e.Symmetric(func(e control.Encoder) error {
+			return e.Bound(bytes.Repeat([]byte{0b_1000_0001}, 200))
+		}))
+		require.NoError(t, e.Symmetric(func(e control.Encoder) error {
+			return e.Data(bytes.Repeat([]byte{2}, 100))
+		}))
+		require.NoError(t, e.Symmetric(func(e control.Encoder) error {
+			return e.Skip(3)
+		}))
+		require.NoError(t, e.Unbound(func(e control.Encoder) error {
+			return e.Symmetric(func(e control.Encoder) error {
+				return e.Symmetric(func(e control.Encoder) error {
+					return e.Data([]byte{0xff, 0xff, 0xff})
+				})
+			})
+		}))
+
+		output := &bytes.Buffer{}
+
+		err := control.Canonicalize(output, bytes.NewReader(input.Bytes()), control.DecoderOptions{})
+		require.NoError(t, err)
+		require.Equal(t, input.Bytes(), output.Bytes())
+	})
+
+	t.Run("limits", func(t *testing.T) {
+		// A bounded container nested in another.
+		input := []byte{
+			0b_0000_0101, 0b_1000_0010,
+			0b_0000_0101, 0b_1000_0000,
+			0b_1000_0001,
+		}
+
+		err := control.Canonicalize(&bytes.Buffer{}, bytes.NewReader(input), control.DecoderOptions{
+			MaxDepth: 2,
+		})
+		require.NoError(t, err)
+
+		err = control.Canonicalize(&bytes.Buffer{}, bytes.NewReader(input), control.DecoderOptions{
+			MaxDepth: 1,
+		})
+		require.ErrorIs(t, err, control.ErrLimitExceeded)
+
+		err = control.Canonicalize(&bytes.Buffer{}, bytes.NewReader(input), control.DecoderOptions{
+			MaxTotal: 4,
+		})
+		require.ErrorIs(t, err, control.ErrLimitExceeded)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		inputs := [][]byte{
+			{0b_0000_0100},
+			{0b_0100_0011, 0b_0000_0001},
+			{0b_0000_0110, 0b_1000_0001},
+			{0b_0000_0111},
+		}
+
+		for _, input := range inputs {
+			err := control.Canonicalize(&bytes.Buffer{}, bytes.NewReader(input), control.DecoderOptions{})
+			require.Error(t, err)
+		}
+	})
+}
@@ -1,9 +1,11 @@
 package control
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"io"
+	"math"
 	"math/big"
 
 	"github.com/calebcase/oops"
@@ -23,6 +25,7 @@ type Decoder interface {
 
 	Size() (_ uint64, err error)
 	Data() (data []byte, err error)
+	DataReader() (r io.Reader, size uint64, err error)
 	Enter() (err error)
 	BSV() (bsv []byte, err error)
 	Amount() (_ uint64, err error)
@@ -32,6 +35,17 @@ type decoder struct {
 	r io.Reader
 	s io.Seeker
 
+	opts DecoderOptions
+
+	// base, offset and parent are the depth, consumed bytes and scope of
+	// the parent decoder when decoding the size of a bounded container.
+	// sizes is the number of bounded container sizes the parents are
+	// decoding.
+	base   int
+	offset uint64
+	parent *scope
+	sizes  int
+
 	consumed uint64
 
 	stack *Stack
@@ -42,14 +56,22 @@ type decoder struct {
 
 	size   uint64
 	data   []byte
+	stream *dataReader
 	amount uint64
 
 	err error
 }
 
 func NewDecoder(r io.Reader) Decoder {
+	return NewDecoderWithOptions(r, DecoderOptions{})
+}
+
+// NewDecoderWithOptions returns a decoder that enforces the limits in opts.
+// Exceeding a limit results in an error wrapping ErrLimitExceeded.
+func NewDecoderWithOptions(r io.Reader, opts DecoderOptions) Decoder {
 	d := &decoder{
 		r:     r,
+		opts:  opts,
 		stack: &Stack{},
 	}
 
@@ -58,17 +80,52 @@ func NewDecoder(r io.Reader) Decoder {
 	return d
 }
 
+// checkTotal verifies that reading size more bytes stays within the total
+// limit.
+func (d *decoder) checkTotal(size uint64) (err error) {
+	return d.opts.checkTotal(d.offset+d.consumed, size)
+}
+
+// push adds the frame to the stack if doing so stays within the depth limit.
+func (d *decoder) push(f *Frame) (err error) {
+	err = d.opts.checkDepth(d.base + d.Depth() + 1)
+	if err != nil {
+		return err
+	}
+
+	d.stack.Push(f)
+
+	return nil
+}
+
 // seek moves the input stream's current position using an io.Seeker if
 // available otherwise it falls back to a discarding copy.
 func (d *decoder) seek(size uint64) (err error) {
+	if size == 0 {
+		return nil
+	}
+
 	if d.s != nil {
-		n, err := d.s.Seek(int64(size), io.SeekCurrent)
+		// Seeking past the end of the input succeeds, so the last byte
+		// is read instead of seeked over to detect truncated input.
+		_, err := d.s.Seek(int64(size)-1, io.SeekCurrent)
 		if err != nil {
 			return Error.Trace(err)
 		}
 
-		d.consumed += uint64(n)
-		err = d.stack.Consume(uint64(n))
+		var last [1]byte
+
+		_, err = io.ReadFull(d.r, last[:])
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+
+			return Error.Trace(err)
+		}
+
+		d.consumed += size
+		err = d.stack.Consume(size)
 		if err != nil {
 			return err
 		}
@@ -78,6 +135,10 @@ func (d *decoder) seek(size uint64) (err error) {
 
 	n, err := io.CopyN(io.Discard, d.r, int64(size))
 	if err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+
 		return Error.Trace(err)
 	}
 
@@ -90,10 +151,146 @@ func (d *decoder) seek(size uint64) (err error) {
 	return nil
 }
 
+// readChunk is the most that is allocated up front when reading data. Larger
+// fields grow their buffer as the input is read.
+const readChunk = 64 << 10
+
+// readFull reads size bytes from the input. The buffer grows as the input is
+// read so a declared size larger than the input results in a truncation error
+// instead of a large allocation.
+func (d *decoder) readFull(size uint64) (data []byte, err error) {
+	if size <= readChunk {
+		data = make([]byte, size)
+
+		_, err = io.ReadFull(d.r, data)
+		if err != nil {
+			return nil, Error.Trace(err)
+		}
+
+		return data, nil
+	}
+
+	limit := int64(math.MaxInt64)
+	if size < math.MaxInt64 {
+		limit = int64(size)
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, readChunk))
+
+	n, err := buf.ReadFrom(io.LimitReader(d.r, limit))
+	if err != nil {
+		return nil, Error.Trace(err)
+	}
+
+	if uint64(n) < size {
+		return nil, Error.Trace(io.ErrUnexpectedEOF)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// trailing skips the trailing control blocks of any symmetric fields that
+// have been fully read.
+func (d *decoder) trailing() (err error) {
+	for {
+		top := d.stack.Top()
+		if top == nil ||
+			top.Type != ContainerSymmetric ||
+			top.Subtype == Unknown {
+
+			return nil
+		}
+
+		if d.opts.StrictSymmetric {
+			err = d.verify(top)
+		} else {
+			err = d.seek(top.Count)
+		}
+		if err != nil {
+			return err
+		}
+
+		err = d.stack.Pop()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// embedded returns true if the current field is the field embedded in a
+// symmetric container.
+func (d *decoder) embedded() bool {
+	s := *d.stack
+	if len(s) < 2 {
+		return false
+	}
+
+	return s[len(s)-2].Type == ContainerSymmetric
+}
+
+// record adds a copy of the block to the leading control blocks of the
+// symmetric container currently being read when verifying symmetric fields.
+func (d *decoder) record(block []byte) {
+	if !d.opts.StrictSymmetric {
+		return
+	}
+
+	d.stack.record(block)
+}
+
+// verify reads the trailing control blocks of the symmetric container f and
+// checks they are its leading control blocks in reverse order.
+func (d *decoder) verify(f *Frame) (err error) {
+	trailing := make([]byte, f.Count)
+
+	_, err = io.ReadFull(d.r, trailing)
+	if err != nil {
+		return Error.Trace(err)
+	}
+
+	start := d.offset + d.consumed
+
+	d.consumed += f.Count
+	err = d.stack.Consume(f.Count)
+	if err != nil {
+		return err
+	}
+
+	return mirrored(f, trailing, start)
+}
+
+// mirrored checks the trailing control blocks of the symmetric container f,
+// which start at offset start, are its leading control blocks in reverse
+// order.
+func mirrored(f *Frame, trailing []byte, start uint64) (err error) {
+	leading := make([]byte, 0, f.Count)
+	for i := len(f.blocks) - 1; i >= 0; i-- {
+		leading = append(leading, f.blocks[i]...)
+	}
+
+	if bytes.Equal(leading, trailing) {
+		return nil
+	}
+
+	i := 0
+	for i < len(leading) && i < len(trailing) && leading[i] == trailing[i] {
+		i++
+	}
+
+	return oops.New(
+		"%w: trailing control blocks differ at offset %d: leading=%x trailing=%x",
+		ErrAsymmetric,
+		start+uint64(i),
+		leading,
+		trailing,
+	)
+}
+
 // Seek moves the reading position to the end of the current field.
 func (d *decoder) Seek() (err error) {
 	defer func() {
 		if err != nil {
+			err = d.locate(err)
 			d.err = err
 		}
 	}()
@@ -107,7 +304,12 @@ func (d *decoder) Seek() (err error) {
 		// No additional bytes need to be read.
 	case DataSize:
 		// Seek past the data if we haven't read it yet.
-		if len(d.data) == 0 {
+		if d.stream != nil {
+			err = d.seekStream()
+			if err != nil {
+				return err
+			}
+		} else if len(d.data) == 0 {
 			size, err := d.Size()
 			if err != nil {
 				return err
@@ -137,7 +339,12 @@ func (d *decoder) Seek() (err error) {
 		}
 
 		// Seek past the data if we haven't read it yet.
-		if len(d.data) == 0 {
+		if d.stream != nil {
+			err = d.seekStream()
+			if err != nil {
+				return err
+			}
+		} else if len(d.data) == 0 {
 			err = d.seek(size)
 			if err != nil {
 				return err
@@ -156,8 +363,6 @@ func (d *decoder) Seek() (err error) {
 				return
 			}
 
-			d.stack.Top().Subtype = d.t
-
 			// Seek past the embedded field.
 			err = d.Seek()
 			if err != nil {
@@ -209,31 +414,48 @@ func (d *decoder) Seek() (err error) {
 	}
 
 	// Skip over trailing symmetric control blocks.
-	if top := d.stack.Top(); top != nil &&
-		top.Type == ContainerSymmetric &&
-		top.Subtype != Unknown {
-
-		err = d.seek(top.Count)
-		if err != nil {
-			return err
-		}
-
-		err = d.stack.Pop()
-		if err != nil {
-			return err
-		}
+	err = d.trailing()
+	if err != nil {
+		return err
 	}
 
 	return nil
 }
 
+// locate adds the current position to decoding errors.
+func (d *decoder) locate(err error) error {
+	return locate(err, d.offset+d.consumed, d.value[0], d.parent, *d.stack)
+}
+
 func (d *decoder) Next() (ok bool) {
+	ok = d.next()
+	if d.err != nil {
+		d.err = d.locate(d.err)
+	}
+
+	return ok
+}
+
+func (d *decoder) next() (ok bool) {
+	// Reading the data from DataReader failed and the input is no longer
+	// at a known position.
+	if d.stream != nil && d.stream.err != nil {
+		d.err = d.stream.err
+
+		return false
+	}
+
 	// Ensure current field was fully read before moving on...
 	if !d.finished {
 		d.err = d.Seek()
 		if d.err != nil {
 			return false
 		}
+	} else {
+		d.err = d.trailing()
+		if d.err != nil {
+			return false
+		}
 	}
 
 	// Reset state for next field.
@@ -242,6 +464,7 @@ func (d *decoder) Next() (ok bool) {
 
 	d.size = 0
 	d.data = d.data[:0]
+	d.stream = nil
 	d.amount = 0
 	d.finished = false
 
@@ -250,6 +473,10 @@ func (d *decoder) Next() (ok bool) {
 	if d.err != nil {
 		if errors.Is(d.err, io.EOF) {
 			d.err = nil
+			if d.Depth() != 0 {
+				d.err = Error.New("unexpected end of input: depth=%d: %w", d.Depth(), io.ErrUnexpectedEOF)
+			}
+
 			return false
 		}
 
@@ -259,11 +486,19 @@ func (d *decoder) Next() (ok bool) {
 	}
 
 	d.consumed += 1
-	d.stack.Consume(1)
+	d.err = d.stack.Consume(1)
+	if d.err != nil {
+		return false
+	}
+
+	d.err = d.checkTotal(0)
+	if d.err != nil {
+		return false
+	}
 
 	t, ok := Types.Match(d.value[0])
 	if !ok {
-		d.err = Error.New("unexpected byte: %0b", d.value[0])
+		d.err = oops.New("%w: %0b", ErrUnexpectedByte, d.value[0])
 
 		return false
 	}
@@ -275,8 +510,11 @@ func (d *decoder) Next() (ok bool) {
 	case ContainerUnbounded, ContainerEnd:
 		// These are already symmetric and do not add to the symmetric
 		// control block count.
+	case ContainerSymmetric:
+		// Nested symmetric containers track their own control blocks.
 	default:
 		d.stack.Count(1)
+		d.record(d.value[:])
 	}
 
 	if top := d.stack.Top(); top != nil &&
@@ -290,29 +528,41 @@ func (d *decoder) Next() (ok bool) {
 	case Data:
 		d.finished = true
 	case ContainerSymmetric:
-		d.stack.Push(&Frame{
+		d.err = d.push(&Frame{
 			Type:  t,
 			Count: 1,
 		})
+		if d.err != nil {
+			return false
+		}
+
+		d.record(d.value[:])
 	case ContainerBounded:
-		d.stack.Push(&Frame{
+		d.err = d.push(&Frame{
 			Type: t,
 		})
+		if d.err != nil {
+			return false
+		}
 	case ContainerUnbounded:
-		d.stack.Push(&Frame{
+		d.err = d.push(&Frame{
 			Type: t,
 		})
+		if d.err != nil {
+			return false
+		}
 	case ContainerEnd:
 		top := d.stack.Top()
 		if top == nil {
-			d.err = Error.New("unexpected container end (not in a container)")
+			d.err = oops.New("%w (not in a container)", ErrUnpairedEnd)
 
 			return false
 		}
 
 		if top.Type != ContainerUnbounded {
-			d.err = Error.New(
-				"unexpected container end (container not unbounded): %s",
+			d.err = oops.New(
+				"%w (container not unbounded): %s",
+				ErrUnpairedEnd,
 				top.Type.Abbr,
 			)
 
@@ -360,6 +610,7 @@ func (d *decoder) Size() (_ uint64, err error) {
 	defer func() {
 		if err != nil {
 			d.size = 0
+			err = d.locate(err)
 			d.err = err
 		}
 	}()
@@ -372,7 +623,19 @@ func (d *decoder) Size() (_ uint64, err error) {
 	case Data:
 		d.size = 1
 	case DataSize:
-		d.size = uint64(d.value[0]&d.t.Mask) + 1
+		size := uint64(d.value[0]&d.t.Mask) + 1
+
+		err = d.opts.checkFieldSize(size)
+		if err != nil {
+			return 0, err
+		}
+
+		err = d.checkTotal(size)
+		if err != nil {
+			return 0, err
+		}
+
+		d.size = size
 	case Data1:
 		d.size = 2
 	case Data2:
@@ -380,6 +643,11 @@ func (d *decoder) Size() (_ uint64, err error) {
 	case DataSizeSize:
 		sizeSize := uint64(d.value[0]&d.t.Mask) + 1
 
+		err = d.checkTotal(sizeSize)
+		if err != nil {
+			return 0, err
+		}
+
 		sizeBytes := make([]byte, sizeSize)
 		_, err = io.ReadFull(d.r, sizeBytes)
 		if err != nil {
@@ -387,8 +655,12 @@ func (d *decoder) Size() (_ uint64, err error) {
 		}
 
 		d.consumed += sizeSize
-		d.stack.Consume(sizeSize)
+		err = d.stack.Consume(sizeSize)
+		if err != nil {
+			return 0, err
+		}
 		d.stack.Count(sizeSize)
+		d.record(sizeBytes)
 
 		size := new(big.Int).SetBytes(sizeBytes)
 		size.Add(size, big.NewInt(1))
@@ -396,17 +668,53 @@ func (d *decoder) Size() (_ uint64, err error) {
 			return 0, Error.New("unimplemented: size >= 2^64")
 		}
 
+		err = d.opts.checkFieldSize(size.Uint64())
+		if err != nil {
+			return 0, err
+		}
+
+		err = d.checkTotal(size.Uint64())
+		if err != nil {
+			return 0, err
+		}
+
 		d.size = size.Uint64()
 	case ContainerBounded:
-		zd := NewDecoder(d.r)
+		err = checkSizeDepth(d.sizes + 1)
+		if err != nil {
+			return 0, err
+		}
 
-		ok := zd.Next()
-		if !ok {
-			return 0, Error.New("unabled to read container bounded size")
+		// When verifying symmetric fields the size field is kept as
+		// one of the leading control blocks.
+		var sizeField *bytes.Buffer
+
+		zr, zs := d.r, d.s
+		if d.opts.StrictSymmetric {
+			sizeField = &bytes.Buffer{}
+			zr, zs = io.TeeReader(d.r, sizeField), nil
+		}
+
+		zd := &decoder{
+			r:      zr,
+			s:      zs,
+			opts:   d.opts,
+			base:   d.base + d.Depth(),
+			offset: d.offset + d.consumed,
+			parent: &scope{d.parent, d.stack},
+			sizes:  d.sizes + 1,
+			stack:  &Stack{},
 		}
+
+		ok := zd.Next()
 		if zd.Err() != nil {
 			return 0, zd.Err()
 		}
+		if !ok {
+			return 0, Error.New("unabled to read container bounded size: %w", io.ErrUnexpectedEOF)
+		}
+
+		bare := zd.Type() == DataSize
 
 		switch zd.Type() {
 		case ContainerSymmetric, ContainerBounded, ContainerUnbounded:
@@ -416,12 +724,12 @@ func (d *decoder) Size() (_ uint64, err error) {
 			}
 
 			ok := zd.Next()
-			if !ok {
-				return 0, Error.New("unabled to read container bounded size")
-			}
 			if zd.Err() != nil {
 				return 0, zd.Err()
 			}
+			if !ok {
+				return 0, Error.New("unabled to read container bounded size: %w", io.ErrUnexpectedEOF)
+			}
 		}
 
 		sizeBytes, err := zd.Data()
@@ -429,9 +737,38 @@ func (d *decoder) Size() (_ uint64, err error) {
 			return 0, err
 		}
 
-		d.consumed += zd.Consumed()
-		d.stack.Consume(zd.Consumed())
-		d.stack.Count(zd.Consumed())
+		consumed := zd.Consumed()
+
+		// The symmetric encoder writes a single byte size above 127
+		// without a symmetric container, repeating its control block
+		// after the value instead.
+		if bare && len(sizeBytes) == 1 && d.embedded() {
+			var mirror [1]byte
+
+			_, err = io.ReadFull(zr, mirror[:])
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			if err != nil {
+				return 0, Error.Trace(err)
+			}
+
+			if mirror[0] != DataSize.Prefix {
+				return 0, oops.New("%w (symmetric bounded size): %0b", ErrUnexpectedByte, mirror[0])
+			}
+
+			consumed++
+		}
+
+		d.consumed += consumed
+		err = d.stack.Consume(consumed)
+		if err != nil {
+			return 0, err
+		}
+		d.stack.Count(consumed)
+		if sizeField != nil {
+			d.record(sizeField.Bytes())
+		}
 
 		size := new(big.Int).SetBytes(sizeBytes)
 		size.Add(size, big.NewInt(1))
@@ -439,6 +776,16 @@ func (d *decoder) Size() (_ uint64, err error) {
 			return 0, Error.New("unimplemented: size >= 2^64")
 		}
 
+		err = d.opts.checkBoundedSize(size.Uint64())
+		if err != nil {
+			return 0, err
+		}
+
+		err = d.checkTotal(size.Uint64())
+		if err != nil {
+			return 0, err
+		}
+
 		d.size = size.Uint64()
 
 		top := d.stack.Top()
@@ -461,6 +808,7 @@ func (d *decoder) Data() (data []byte, err error) {
 	defer func() {
 		if err != nil {
 			d.data = d.data[:0]
+			err = d.locate(err)
 			d.err = err
 		}
 	}()
@@ -473,6 +821,11 @@ func (d *decoder) Data() (data []byte, err error) {
 		return d.data, nil
 	}
 
+	// The data was already (at least partially) read by DataReader.
+	if d.stream != nil {
+		return nil, oops.Trace(ErrInvalidOperation)
+	}
+
 	_, err = d.Size()
 	if err != nil {
 		return d.data[:0], err
@@ -482,15 +835,16 @@ func (d *decoder) Data() (data []byte, err error) {
 	case Data:
 		d.data = []byte{d.value[0] & d.t.Mask}
 	case DataSize:
-		d.data = make([]byte, d.size)
-
-		_, err = io.ReadFull(d.r, d.data)
+		d.data, err = d.readFull(d.size)
 		if err != nil {
-			return nil, Error.Trace(err)
+			return nil, err
 		}
 
 		d.consumed += d.size
-		d.stack.Consume(d.size)
+		err = d.stack.Consume(d.size)
+		if err != nil {
+			return nil, err
+		}
 
 		d.finished = true
 	case Data1:
@@ -503,7 +857,10 @@ func (d *decoder) Data() (data []byte, err error) {
 		}
 
 		d.consumed += 1
-		d.stack.Consume(1)
+		err = d.stack.Consume(1)
+		if err != nil {
+			return nil, err
+		}
 
 		d.finished = true
 	case Data2:
@@ -516,40 +873,104 @@ func (d *decoder) Data() (data []byte, err error) {
 		}
 
 		d.consumed += 2
-		d.stack.Consume(2)
+		err = d.stack.Consume(2)
+		if err != nil {
+			return nil, err
+		}
 
 		d.finished = true
 	case DataSizeSize:
-		d.data = make([]byte, d.size)
-
-		_, err = io.ReadFull(d.r, d.data)
+		d.data, err = d.readFull(d.size)
 		if err != nil {
-			return nil, Error.Trace(err)
+			return nil, err
 		}
 
 		d.consumed += d.size
-		d.stack.Consume(d.size)
+		err = d.stack.Consume(d.size)
+		if err != nil {
+			return nil, err
+		}
 
 		d.finished = true
 	}
 
 	// Skip over trailing symmetric control blocks.
-	if top := d.stack.Top(); top != nil &&
-		top.Type == ContainerSymmetric &&
-		top.Subtype != Unknown {
+	err = d.trailing()
+	if err != nil {
+		return nil, err
+	}
+
+	return d.data, nil
+}
+
+// seekStream moves past any data not yet read from the DataReader.
+func (d *decoder) seekStream() (err error) {
+	remaining := d.stream.remaining
+	d.stream.remaining = 0
 
-		err = d.seek(top.Count)
+	return d.seek(remaining)
+}
+
+// DataReader returns a reader over the field's data and the data size. Data
+// Size and Data Size Size fields are read directly from the input as the
+// reader is read. Any unread data is skipped by Next. If the field does not
+// contain data it returns nil and ErrInvalidOperation.
+func (d *decoder) DataReader() (r io.Reader, size uint64, err error) {
+	defer func() {
 		if err != nil {
-			return nil, err
+			err = d.locate(err)
+			d.err = err
 		}
+	}()
 
-		err = d.stack.Pop()
+	switch d.t {
+	case Data, Data1, Data2:
+		data, err := d.Data()
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
+
+		return bytes.NewReader(data), uint64(len(data)), nil
+	case DataSize, DataSizeSize:
+	default:
+		return nil, 0, oops.Trace(ErrInvalidOperation)
 	}
 
-	return d.data, nil
+	if len(d.data) != 0 {
+		return bytes.NewReader(d.data), uint64(len(d.data)), nil
+	}
+
+	if d.stream != nil {
+		return nil, 0, oops.Trace(ErrInvalidOperation)
+	}
+
+	size, err = d.Size()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	available, ok := d.stack.Available()
+	if !ok || available > size {
+		available = size
+	}
+
+	d.stream = &dataReader{
+		r:         d.r,
+		remaining: size,
+		available: available,
+		consume: func(n uint64) error {
+			d.consumed += n
+
+			return d.stack.Consume(n)
+		},
+		fail: func(err error) error {
+			d.err = d.locate(err)
+
+			return d.err
+		},
+	}
+
+	return d.stream, size, nil
 }
 
 // Enter informs decoder that the ContainerBounded or ContainerUnbounded field
@@ -558,6 +979,7 @@ func (d *decoder) Data() (data []byte, err error) {
 func (d *decoder) Enter() (err error) {
 	defer func() {
 		if err != nil {
+			err = d.locate(err)
 			d.err = err
 		}
 	}()
@@ -585,6 +1007,7 @@ func (d *decoder) BSV() (bsv []byte, err error) {
 	defer func() {
 		if err != nil {
 			d.data = d.data[:0]
+			err = d.locate(err)
 			d.err = err
 		}
 	}()
@@ -603,30 +1026,21 @@ func (d *decoder) BSV() (bsv []byte, err error) {
 		return nil, err
 	}
 
-	d.data = make([]byte, size)
-
-	_, err = io.ReadFull(d.r, d.data)
+	d.data, err = d.readFull(size)
 	if err != nil {
-		return nil, Error.Trace(err)
+		return nil, err
 	}
 
 	d.consumed += size
-	d.stack.Consume(size)
+	err = d.stack.Consume(size)
+	if err != nil {
+		return nil, err
+	}
 
 	// Skip over trailing symmetric control blocks.
-	if top := d.stack.Top(); top != nil &&
-		top.Type == ContainerSymmetric &&
-		top.Subtype != Unknown {
-
-		err = d.seek(top.Count)
-		if err != nil {
-			return nil, err
-		}
-
-		err = d.stack.Pop()
-		if err != nil {
-			return nil, err
-		}
+	err = d.trailing()
+	if err != nil {
+		return nil, err
 	}
 
 	return d.data, nil
@@ -638,6 +1052,7 @@ func (d *decoder) Amount() (_ uint64, err error) {
 	defer func() {
 		if err != nil {
 			d.amount = 0
+			err = d.locate(err)
 			d.err = err
 		}
 	}()
@@ -663,7 +1078,10 @@ func (d *decoder) Amount() (_ uint64, err error) {
 	}
 
 	d.consumed += d.size
-	d.stack.Consume(d.size)
+	err = d.stack.Consume(d.size)
+	if err != nil {
+		return 0, err
+	}
 
 	padSize := 8 - len(amountBytes)
 	padBytes := make([]byte, padSize)
@@ -675,20 +1093,54 @@ func (d *decoder) Amount() (_ uint64, err error) {
 	d.finished = true
 
 	// Skip over trailing symmetric control blocks.
-	if top := d.stack.Top(); top != nil &&
-		top.Type == ContainerSymmetric &&
-		top.Subtype != Unknown {
+	err = d.trailing()
+	if err != nil {
+		return 0, err
+	}
 
-		err = d.seek(top.Count)
-		if err != nil {
-			return 0, err
-		}
+	return d.amount, nil
+}
 
-		err = d.stack.Pop()
+// SeekToField moves the reading position to the start of field i in the index
+// so the next call to Next reads it. The index offsets must be relative to the
+// start of the decoder's input. The stack is reset so the field is read as if
+// it were at the top level. If the input isn't an io.Seeker it returns
+// ErrInvalidOperation.
+func (d *decoder) SeekToField(idx Index, i int) (err error) {
+	defer func() {
 		if err != nil {
-			return 0, err
+			err = d.locate(err)
+			d.err = err
 		}
+	}()
+
+	entry, err := checkField(idx, i)
+	if err != nil {
+		return err
 	}
 
-	return d.amount, nil
+	if d.s == nil {
+		return oops.Trace(ErrInvalidOperation)
+	}
+
+	_, err = d.s.Seek(int64(entry.Offset)-int64(d.consumed), io.SeekCurrent)
+	if err != nil {
+		return Error.Trace(err)
+	}
+
+	d.consumed = entry.Offset
+	d.stack = &Stack{}
+
+	d.value[0] = 0
+	d.t = Unknown
+	d.finished = true
+
+	d.size = 0
+	d.data = d.data[:0]
+	d.stream = nil
+	d.amount = 0
+
+	d.err = nil
+
+	return nil
 }
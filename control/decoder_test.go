@@ -2,6 +2,8 @@ package control_test
 
 import (
 	"bytes"
+	"errors"
+	"io"
 	"strings"
 	"testing"
 
@@ -258,6 +260,59 @@ func TestDecoder(t *testing.T) {
 				},
 				Mark: oops.New("unexpected"),
 			},
+			{
+				// The symmetric encoder's size field for 129
+				// bytes.
+				Input: append(append(
+					[]byte{
+						0b_0000_0111, // cs
+						0b_0000_0101, // cb
+						0b_0100_0000, // dz
+						0b_1000_0000,
+						0b_0100_0000, // dz
+					},
+					make([]byte, 129)...),
+					0b_0100_0000, // dz
+					0b_1000_0000,
+					0b_0100_0000, // dz
+					0b_0000_0101, // cb
+					0b_0000_0111, // cs
+					0b_1000_0000, // d
+				),
+				Types: []control.Type{
+					control.ContainerSymmetric,
+					control.Data,
+				},
+				Mark: oops.New("unexpected"),
+			},
+			{
+				// The same size field in a symmetric container.
+				Input: append(append(
+					[]byte{
+						0b_0000_0111, // cs
+						0b_0000_0101, // cb
+						0b_0000_0111, // cs
+						0b_0100_0000, // dz
+						0b_1000_0000,
+						0b_0100_0000, // dz
+						0b_0000_0111, // cs
+					},
+					make([]byte, 129)...),
+					0b_0000_0111, // cs
+					0b_0100_0000, // dz
+					0b_1000_0000,
+					0b_0100_0000, // dz
+					0b_0000_0111, // cs
+					0b_0000_0101, // cb
+					0b_0000_0111, // cs
+					0b_1000_0000, // d
+				),
+				Types: []control.Type{
+					control.ContainerSymmetric,
+					control.Data,
+				},
+				Mark: oops.New("unexpected"),
+			},
 		}
 
 		for _, tc := range tcs {
@@ -287,3 +342,102 @@ func TestDecoder(t *testing.T) {
 		}
 	})
 }
+
+func TestDecoderTruncated(t *testing.T) {
+	type TC struct {
+		Input []byte
+		Mark  error
+	}
+
+	// Symmetric fields cut short in their trailing control blocks. The
+	// reverse decoder reads them with the leading control blocks cut
+	// short instead.
+	tcs := []TC{
+		{
+			Input: []byte{
+				0b_0000_0111, 0b_0100_0001, 0x01, 0x02,
+				0b_0100_0001, 0b_0000_0111,
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Input: []byte{
+				0b_0000_0111, 0b_0010_0001, 0x02,
+				0b_0010_0001, 0b_0000_0111,
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Input: []byte{
+				0b_0000_0111, 0b_0000_1000, 0x01, 0x01, 0x02,
+				0x01, 0b_0000_1000, 0b_0000_0111,
+			},
+			Mark: oops.New("unexpected"),
+		},
+	}
+
+	// nonSeeker hides the io.Seeker of the reader it wraps.
+	type nonSeeker struct {
+		io.Reader
+	}
+
+	// next reads the fields without reading their data.
+	next := func(d control.Decoder) error {
+		for d.Next() {
+		}
+
+		return d.Err()
+	}
+
+	// data reads the fields and the data of the data fields.
+	data := func(d control.Decoder) error {
+		for d.Next() {
+			switch d.Type() {
+			case control.ContainerSymmetric:
+				err := d.Enter()
+				if err != nil {
+					return err
+				}
+			case control.DataSize, control.Data1, control.Data2, control.DataSizeSize:
+				_, err := d.Data()
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		return d.Err()
+	}
+
+	for _, tc := range tcs {
+		for n := 1; n <= 2; n++ {
+			forward := tc.Input[:len(tc.Input)-n]
+			backward := tc.Input[n:]
+
+			decoders := map[string]func() control.Decoder{
+				"seeker": func() control.Decoder {
+					return control.NewDecoder(bytes.NewReader(forward))
+				},
+				"reader": func() control.Decoder {
+					return control.NewDecoder(nonSeeker{bytes.NewReader(forward)})
+				},
+				"bytes": func() control.Decoder {
+					return control.NewBytesDecoder(forward)
+				},
+				"reverse": func() control.Decoder {
+					return control.NewReverseDecoder(bytes.NewReader(backward), int64(len(backward)))
+				},
+			}
+
+			for name, decoder := range decoders {
+				mark := oops.New("%s: %x: %v", name, forward, tc.Mark)
+
+				err := next(decoder())
+				require.True(t, errors.Is(err, io.ErrUnexpectedEOF), mark.Error())
+
+				err = data(decoder())
+				require.True(t, errors.Is(err, io.ErrUnexpectedEOF), mark.Error())
+			}
+		}
+	}
+}
@@ -1,14 +1,20 @@
 package control
 
 import (
+	"bytes"
+	"encoding/binary"
 	"io"
 	"math"
 	"math/big"
+	"sync"
 )
 
 type Encoder interface {
 	Data(data []byte) (err error)
+	DataPolicy(data []byte, p Policy) (err error)
+	DataFrom(r io.Reader, size uint64) (err error)
 	Bound(bsv []byte) (err error)
+	BoundFunc(fn func(Encoder) error) (err error)
 	Unbound(fn func(Encoder) error) (err error)
 	Symmetric(fn func(Encoder) error) (err error)
 	Skip(amount uint64) (err error)
@@ -25,17 +31,110 @@ type encoder struct {
 	// it has, then attempts to write another field must fail.
 	symmetric bool
 	written   bool
+
+	opts EncoderOptions
 }
 
 func NewEncoder(w io.Writer) Encoder {
+	return NewEncoderWithOptions(w, EncoderOptions{})
+}
+
+// NewEncoderWithOptions returns an encoder that uses opts.Policy for all data
+// fields.
+func NewEncoderWithOptions(w io.Writer, opts EncoderOptions) Encoder {
 	e := &encoder{
-		w: w,
+		w:    w,
+		opts: opts,
 	}
 
 	return e
 }
 
 func (e *encoder) Data(data []byte) (err error) {
+	return e.DataPolicy(data, e.opts.Policy)
+}
+
+// fixedBlocks returns the leading and (when symmetric) trailing blocks of a
+// data field with size bytes of data using the fixed block policy p.
+func fixedBlocks(size uint64, p Policy) (leading, trailing []byte, err error) {
+	switch p.Type {
+	case DataSize:
+		if size > 64 {
+			return nil, nil, Error.New("invalid: size=%d policy=%s", size, p.Type.Abbr)
+		}
+
+		ctrl := DataSize.Prefix | byte(size-1)
+
+		return []byte{ctrl}, []byte{ctrl}, nil
+	case DataSizeSize:
+		sb := minimalSize(size)
+
+		if p.SizeSize != 0 {
+			if p.SizeSize > 8 || len(sb) > p.SizeSize {
+				return nil, nil, Error.New(
+					"invalid: size=%d policy=%s size size=%d",
+					size, p.Type.Abbr, p.SizeSize,
+				)
+			}
+
+			sb = append(make([]byte, p.SizeSize-len(sb)), sb...)
+		}
+
+		ctrl := DataSizeSize.Prefix | byte(len(sb)-1)
+
+		leading = append([]byte{ctrl}, sb...)
+		trailing = append(append([]byte{}, sb...), ctrl)
+
+		return leading, trailing, nil
+	default:
+		return nil, nil, Error.New("invalid: policy=%s", p.Type.Abbr)
+	}
+}
+
+// DataPolicy writes a data field using the block selected by p.
+func (e *encoder) DataPolicy(data []byte, p Policy) (err error) {
+	if p.Type == Unknown {
+		return e.data(data)
+	}
+
+	if e.symmetric && e.written {
+		return Error.New("invalid: symmetric field already written")
+	}
+	defer func() {
+		e.written = true
+	}()
+
+	if len(data) == 0 {
+		return Error.New("invalid: size=0")
+	}
+
+	leading, trailing, err := fixedBlocks(uint64(len(data)), p)
+	if err != nil {
+		return err
+	}
+
+	_, err = e.w.Write(leading)
+	if err != nil {
+		return Error.Trace(err)
+	}
+
+	_, err = e.w.Write(data)
+	if err != nil {
+		return Error.Trace(err)
+	}
+
+	if e.symmetric {
+		_, err = e.w.Write(trailing)
+		if err != nil {
+			return Error.Trace(err)
+		}
+	}
+
+	return nil
+}
+
+// data writes a data field using the most compact block.
+func (e *encoder) data(data []byte) (err error) {
 	if e.symmetric && e.written {
 		return Error.New("invalid: symmetric field already written")
 	}
@@ -207,6 +306,56 @@ func (e *encoder) Data(data []byte) (err error) {
 	return nil
 }
 
+// DataFrom writes a data field with size bytes of data copied from r. The data
+// is streamed to the writer without buffering. Unless a fixed block policy is
+// set a Data Size Size block is used.
+func (e *encoder) DataFrom(r io.Reader, size uint64) (err error) {
+	if e.symmetric && e.written {
+		return Error.New("invalid: symmetric field already written")
+	}
+	defer func() {
+		e.written = true
+	}()
+
+	if size == 0 {
+		return Error.New("invalid: size=0")
+	}
+
+	// The data is copied with io.CopyN which takes an int64.
+	if size > math.MaxInt64 {
+		return Error.New("unimplemented: size>2^63-1")
+	}
+
+	p := e.opts.Policy
+	if p.Type == Unknown {
+		p = Policy{Type: DataSizeSize}
+	}
+
+	leading, trailing, err := fixedBlocks(size, p)
+	if err != nil {
+		return err
+	}
+
+	_, err = e.w.Write(leading)
+	if err != nil {
+		return Error.Trace(err)
+	}
+
+	n, err := io.CopyN(e.w, r, int64(size))
+	if err != nil {
+		return Error.New("short data: size=%d copied=%d: %w", size, n, err)
+	}
+
+	if e.symmetric {
+		_, err = e.w.Write(trailing)
+		if err != nil {
+			return Error.Trace(err)
+		}
+	}
+
+	return nil
+}
+
 func (e *encoder) Bound(bsv []byte) (err error) {
 	if e.symmetric && e.written {
 		return Error.New("invalid: symmetric field already written")
@@ -237,7 +386,7 @@ func (e *encoder) Bound(bsv []byte) (err error) {
 			sizeBytes = []byte{0b_0000_0000}
 		}
 
-		err = e.Data(sizeBytes)
+		err = e.DataPolicy(sizeBytes, Compact)
 		if err != nil {
 			return err
 		}
@@ -283,6 +432,144 @@ func (e *encoder) Bound(bsv []byte) (err error) {
 	return err
 }
 
+// buffers holds the buffers used by BoundFunc when the writer can't seek.
+var buffers = sync.Pool{
+	New: func() interface{} {
+		return &bytes.Buffer{}
+	},
+}
+
+// boundSize returns a fixed width size field for a bounded container. The
+// size is always encoded in a Data Size block with 8 bytes so that it can be
+// written before the size is known and then replaced.
+func boundSize(size uint64, symmetric bool) (field []byte) {
+	field = make([]byte, 0, 12)
+
+	if symmetric {
+		field = append(field, ContainerSymmetric.Prefix)
+	}
+
+	field = append(field, DataSize.Prefix|(8-1))
+
+	var sizeBytes [8]byte
+	if size > 0 {
+		binary.BigEndian.PutUint64(sizeBytes[:], size-1)
+	}
+
+	field = append(field, sizeBytes[:]...)
+
+	if symmetric {
+		field = append(field, DataSize.Prefix|(8-1))
+		field = append(field, ContainerSymmetric.Prefix)
+	}
+
+	return field
+}
+
+// BoundFunc writes a bounded container with the BSV written by fn. If the
+// writer is an io.WriteSeeker that can seek, then a fixed width size is
+// reserved and replaced after fn returns. Otherwise the BSV is buffered and
+// written with Bound.
+func (e *encoder) BoundFunc(fn func(Encoder) error) (err error) {
+	if e.symmetric && e.written {
+		return Error.New("invalid: symmetric field already written")
+	}
+
+	var start int64
+
+	ws, ok := e.w.(io.WriteSeeker)
+	if ok {
+		// Files such as pipes and terminals are io.WriteSeekers, but
+		// fail to seek.
+		start, err = ws.Seek(0, io.SeekCurrent)
+		ok = err == nil
+	}
+
+	if !ok {
+		buf := buffers.Get().(*bytes.Buffer)
+		defer buffers.Put(buf)
+
+		buf.Reset()
+
+		err = fn(NewEncoderWithOptions(buf, e.opts))
+		if err != nil {
+			return err
+		}
+
+		return e.Bound(buf.Bytes())
+	}
+
+	defer func() {
+		e.written = true
+	}()
+
+	_, err = ws.Write([]byte{
+		0b_0000_0101,
+	})
+	if err != nil {
+		return Error.Trace(err)
+	}
+
+	_, err = ws.Write(boundSize(0, e.symmetric))
+	if err != nil {
+		return Error.Trace(err)
+	}
+
+	begin, err := ws.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return Error.Trace(err)
+	}
+
+	err = fn(NewEncoderWithOptions(ws, e.opts))
+	if err != nil {
+		return err
+	}
+
+	end, err := ws.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return Error.Trace(err)
+	}
+
+	size := uint64(end - begin)
+
+	if size == 0 {
+		return Error.New("invalid: size=0")
+	}
+
+	sizeField := boundSize(size, e.symmetric)
+
+	_, err = ws.Seek(start+1, io.SeekStart)
+	if err != nil {
+		return Error.Trace(err)
+	}
+
+	_, err = ws.Write(sizeField)
+	if err != nil {
+		return Error.Trace(err)
+	}
+
+	_, err = ws.Seek(end, io.SeekStart)
+	if err != nil {
+		return Error.Trace(err)
+	}
+
+	if e.symmetric {
+		_, err = ws.Write(sizeField)
+		if err != nil {
+			return Error.Trace(err)
+		}
+
+		_, err = ws.Write([]byte{
+			0b_0000_0101,
+		})
+		if err != nil {
+			return Error.Trace(err)
+		}
+	}
+
+	return nil
+}
+
 func (e *encoder) Unbound(fn func(Encoder) error) (err error) {
 	if e.symmetric && e.written {
 		return Error.New("invalid: symmetric field already written")
@@ -318,10 +605,37 @@ type symmetric struct {
 }
 
 func (se *symmetric) Data(data []byte) (err error) {
-	if len(data) == 1 {
-		return se.e.Data(data)
+	return se.DataPolicy(data, se.e.opts.Policy)
+}
+
+func (se *symmetric) DataPolicy(data []byte, p Policy) (err error) {
+	if p.Type == Unknown && len(data) == 1 {
+		return se.e.DataPolicy(data, p)
+	}
+
+	_, err = se.e.w.Write([]byte{
+		0b_0000_0111,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = se.e.DataPolicy(data, p)
+	if err != nil {
+		return err
+	}
+
+	_, err = se.e.w.Write([]byte{
+		0b_0000_0111,
+	})
+	if err != nil {
+		return err
 	}
 
+	return nil
+}
+
+func (se *symmetric) DataFrom(r io.Reader, size uint64) (err error) {
 	_, err = se.e.w.Write([]byte{
 		0b_0000_0111,
 	})
@@ -329,7 +643,7 @@ func (se *symmetric) Data(data []byte) (err error) {
 		return err
 	}
 
-	err = se.e.Data(data)
+	err = se.e.DataFrom(r, size)
 	if err != nil {
 		return err
 	}
@@ -367,6 +681,29 @@ func (se *symmetric) Bound(bsv []byte) (err error) {
 	return nil
 }
 
+func (se *symmetric) BoundFunc(fn func(Encoder) error) (err error) {
+	_, err = se.e.w.Write([]byte{
+		0b_0000_0111,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = se.e.BoundFunc(fn)
+	if err != nil {
+		return err
+	}
+
+	_, err = se.e.w.Write([]byte{
+		0b_0000_0111,
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (se *symmetric) Unbound(fn func(Encoder) error) (err error) {
 	return se.e.Unbound(fn)
 }
@@ -436,6 +773,7 @@ func (e *encoder) Symmetric(fn func(Encoder) error) (err error) {
 	se := &symmetric{&encoder{
 		w:         e.w,
 		symmetric: true,
+		opts:      e.opts,
 	}}
 
 	err = fn(se)
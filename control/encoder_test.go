@@ -2,7 +2,9 @@ package control_test
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"strings"
 	"testing"
 
@@ -55,6 +57,49 @@ func shortName(i int, data []byte) string {
 	return sb.String()
 }
 
+// seekBuffer is an in memory io.WriteSeeker.
+type seekBuffer struct {
+	data []byte
+	pos  int
+}
+
+func (sb *seekBuffer) Write(p []byte) (n int, err error) {
+	if end := sb.pos + len(p); end > len(sb.data) {
+		sb.data = append(sb.data, make([]byte, end-len(sb.data))...)
+	}
+
+	n = copy(sb.data[sb.pos:], p)
+	sb.pos += n
+
+	return n, nil
+}
+
+func (sb *seekBuffer) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+		sb.pos = int(offset)
+	case io.SeekCurrent:
+		sb.pos += int(offset)
+	case io.SeekEnd:
+		sb.pos = len(sb.data) + int(offset)
+	}
+
+	return int64(sb.pos), nil
+}
+
+func (sb *seekBuffer) Bytes() []byte {
+	return sb.data
+}
+
+// pipe is an io.WriteSeeker that can't seek like a pipe or a terminal.
+type pipe struct {
+	bytes.Buffer
+}
+
+func (p *pipe) Seek(offset int64, whence int) (int64, error) {
+	return 0, errors.New("illegal seek")
+}
+
 func TestEncoder(t *testing.T) {
 	t.Run("data", func(t *testing.T) {
 		type TC struct {
@@ -180,6 +225,94 @@ func TestEncoder(t *testing.T) {
 		}
 	})
 
+	t.Run("bound func", func(t *testing.T) {
+		type TC struct {
+			Fn       func(control.Encoder) error
+			Buffered []byte
+			Seeked   []byte
+			Mark     error
+		}
+
+		tcs := []TC{
+			{
+				Fn: func(e control.Encoder) (err error) {
+					return e.Data([]byte{0b_0000_0000})
+				},
+				Buffered: []byte{0b_0000_0101, 0b_1000_0000, 0b_1000_0000},
+				Seeked: []byte{
+					0b_0000_0101,
+					0b_0100_0111, 0, 0, 0, 0, 0, 0, 0, 0,
+					0b_1000_0000,
+				},
+				Mark: oops.New("unexpected"),
+			},
+			{
+				Fn: func(e control.Encoder) (err error) {
+					return e.BoundFunc(func(e control.Encoder) (err error) {
+						return e.Data([]byte{0b_0000_0001, 0b_0000_0000})
+					})
+				},
+				Buffered: []byte{
+					0b_0000_0101,
+					0b_1000_0011,
+					0b_0000_0101,
+					0b_1000_0001,
+					0b_0010_0001, 0b_0000_0000,
+				},
+				Seeked: []byte{
+					0b_0000_0101,
+					0b_0100_0111, 0, 0, 0, 0, 0, 0, 0, 11,
+					0b_0000_0101,
+					0b_0100_0111, 0, 0, 0, 0, 0, 0, 0, 1,
+					0b_0010_0001, 0b_0000_0000,
+				},
+				Mark: oops.New("unexpected"),
+			},
+		}
+
+		for i, tc := range tcs {
+			t.Run(shortName(i, tc.Buffered), func(t *testing.T) {
+				output := &bytes.Buffer{}
+				e := control.NewEncoder(output)
+
+				err := e.BoundFunc(tc.Fn)
+				require.NoError(t, err, tc.Mark)
+				require.Equal(t, tc.Buffered, output.Bytes(), tc.Mark)
+
+				seeker := &seekBuffer{}
+				e = control.NewEncoder(seeker)
+
+				err = e.BoundFunc(tc.Fn)
+				require.NoError(t, err, tc.Mark)
+				require.Equal(t, tc.Seeked, seeker.Bytes(), tc.Mark)
+
+				// Writers that fail to seek are buffered.
+				p := &pipe{}
+				e = control.NewEncoder(p)
+
+				err = e.BoundFunc(tc.Fn)
+				require.NoError(t, err, tc.Mark)
+				require.Equal(t, tc.Buffered, p.Bytes(), tc.Mark)
+			})
+		}
+
+		t.Run("empty", func(t *testing.T) {
+			e := control.NewEncoder(&bytes.Buffer{})
+
+			err := e.BoundFunc(func(e control.Encoder) (err error) {
+				return nil
+			})
+			require.Error(t, err)
+
+			e = control.NewEncoder(&seekBuffer{})
+
+			err = e.BoundFunc(func(e control.Encoder) (err error) {
+				return nil
+			})
+			require.Error(t, err)
+		})
+	})
+
 	t.Run("unbound", func(t *testing.T) {
 		type TC struct {
 			Input  []byte
@@ -529,4 +662,129 @@ func TestEncoder(t *testing.T) {
 		require.Equal(t, 1, len(output.Bytes()))
 		require.Equal(t, []byte{0b_0000_0000}, output.Bytes())
 	})
+
+	t.Run("policy", func(t *testing.T) {
+		type TC struct {
+			Fn     func(control.Encoder) error
+			Policy control.Policy
+			Output []byte
+			Mark   error
+		}
+
+		tcs := []TC{
+			{
+				Fn: func(e control.Encoder) (err error) {
+					return e.Data([]byte{0b_0000_0001})
+				},
+				Policy: control.FixedDataSize,
+				Output: []byte{0b_0100_0000, 0b_0000_0001},
+				Mark:   oops.New("unexpected"),
+			},
+			{
+				Fn: func(e control.Encoder) (err error) {
+					return e.Data([]byte{0b_0000_0001, 0b_0000_0010})
+				},
+				Policy: control.FixedDataSizeSize,
+				Output: []byte{
+					0b_0000_1111, 0, 0, 0, 0, 0, 0, 0, 1,
+					0b_0000_0001, 0b_0000_0010,
+				},
+				Mark: oops.New("unexpected"),
+			},
+			{
+				Fn: func(e control.Encoder) (err error) {
+					return e.Data([]byte{0b_0000_0001, 0b_0000_0010})
+				},
+				Policy: control.Policy{Type: control.DataSizeSize},
+				Output: []byte{
+					0b_0000_1000, 1,
+					0b_0000_0001, 0b_0000_0010,
+				},
+				Mark: oops.New("unexpected"),
+			},
+			{
+				Fn: func(e control.Encoder) (err error) {
+					return e.DataFrom(bytes.NewReader([]byte{0b_0000_0001}), 1)
+				},
+				Policy: control.FixedDataSize,
+				Output: []byte{0b_0100_0000, 0b_0000_0001},
+				Mark:   oops.New("unexpected"),
+			},
+			{
+				Fn: func(e control.Encoder) (err error) {
+					return e.Symmetric(func(e control.Encoder) (err error) {
+						return e.Data([]byte{0b_0000_0001})
+					})
+				},
+				Policy: control.FixedDataSize,
+				Output: []byte{
+					0b_0000_0111,
+					0b_0100_0000, 0b_0000_0001, 0b_0100_0000,
+					0b_0000_0111,
+				},
+				Mark: oops.New("unexpected"),
+			},
+			{
+				// Bounded container sizes are always compact.
+				Fn: func(e control.Encoder) (err error) {
+					return e.Bound([]byte{0b_1000_0001})
+				},
+				Policy: control.FixedDataSize,
+				Output: []byte{0b_0000_0101, 0b_1000_0000, 0b_1000_0001},
+				Mark:   oops.New("unexpected"),
+			},
+			{
+				Fn: func(e control.Encoder) (err error) {
+					return e.BoundFunc(func(e control.Encoder) (err error) {
+						return e.Data([]byte{0b_0000_0001})
+					})
+				},
+				Policy: control.FixedDataSize,
+				Output: []byte{
+					0b_0000_0101, 0b_1000_0001,
+					0b_0100_0000, 0b_0000_0001,
+				},
+				Mark: oops.New("unexpected"),
+			},
+		}
+
+		for i, tc := range tcs {
+			t.Run(shortName(i, tc.Output), func(t *testing.T) {
+				output := &bytes.Buffer{}
+				e := control.NewEncoderWithOptions(output, control.EncoderOptions{
+					Policy: tc.Policy,
+				})
+
+				err := tc.Fn(e)
+				require.NoError(t, err, tc.Mark)
+				require.Equal(t, tc.Output, output.Bytes(), tc.Mark)
+
+				// The same policy per call.
+				if tc.Policy.Type == control.DataSizeSize {
+					output.Reset()
+					e = control.NewEncoder(output)
+
+					err = e.DataPolicy([]byte{0b_0000_0001, 0b_0000_0010}, tc.Policy)
+					require.NoError(t, err, tc.Mark)
+					require.Equal(t, tc.Output, output.Bytes(), tc.Mark)
+				}
+			})
+		}
+
+		t.Run("invalid", func(t *testing.T) {
+			e := control.NewEncoder(&bytes.Buffer{})
+
+			err := e.DataPolicy(bytes.Repeat([]byte{1}, 65), control.FixedDataSize)
+			require.Error(t, err)
+
+			err = e.DataPolicy([]byte{1}, control.Policy{Type: control.Data1})
+			require.Error(t, err)
+
+			err = e.DataPolicy(
+				bytes.Repeat([]byte{1}, 257),
+				control.Policy{Type: control.DataSizeSize, SizeSize: 1},
+			)
+			require.Error(t, err)
+		})
+	})
 }
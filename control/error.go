@@ -1,6 +1,160 @@
 package control
 
-import "github.com/calebcase/oops"
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/calebcase/oops"
+)
 
 // Error is the namespace for this package's errors.
 var Error = oops.Namespace("control")
+
+var (
+	// ErrTruncated is returned when the input ends in the middle of a
+	// field or container.
+	ErrTruncated = Error.New("truncated")
+
+	// ErrUnexpectedByte is returned when a control byte isn't valid at
+	// its position.
+	ErrUnexpectedByte = Error.New("unexpected byte")
+
+	// ErrBoundedOverflow is returned when a field extends past the end of
+	// the bounded container it is in.
+	ErrBoundedOverflow = Error.New("bounded container overflow")
+
+	// ErrUnpairedEnd is returned when a container end doesn't close an
+	// unbounded container.
+	ErrUnpairedEnd = Error.New("unpaired container end")
+
+	// ErrAsymmetric is returned when the trailing control blocks of a
+	// symmetric field don't mirror its leading control blocks.
+	ErrAsymmetric = Error.New("asymmetric field")
+)
+
+// kinds are the classes of DecodeError other than ErrTruncated.
+var kinds = []error{
+	ErrUnexpectedByte,
+	ErrBoundedOverflow,
+	ErrUnpairedEnd,
+	ErrAsymmetric,
+	ErrLimitExceeded,
+}
+
+// DecodeError reports where in the input decoding failed. It matches its Kind
+// with errors.Is and the underlying error is available with errors.Unwrap.
+type DecodeError struct {
+	// Kind is one of ErrTruncated, ErrUnexpectedByte, ErrBoundedOverflow,
+	// ErrUnpairedEnd, ErrAsymmetric or ErrLimitExceeded.
+	Kind error
+
+	// Offset is the number of bytes consumed (see Decoder.Consumed) when
+	// the error occurred.
+	Offset uint64
+
+	// Byte is the control byte of the field being decoded.
+	Byte byte
+
+	// Path is the type of each container the field is in from the
+	// outermost inwards.
+	Path []Type
+
+	Err error
+}
+
+func (de *DecodeError) Error() string {
+	abbrs := make([]string, len(de.Path))
+	for i, t := range de.Path {
+		abbrs[i] = t.Abbr
+	}
+
+	return fmt.Sprintf(
+		"%v (offset=%d byte=%08b path=/%s)",
+		de.Err,
+		de.Offset,
+		de.Byte,
+		strings.Join(abbrs, "/"),
+	)
+}
+
+func (de *DecodeError) Unwrap() error {
+	return de.Err
+}
+
+func (de *DecodeError) Is(target error) bool {
+	return target == de.Kind
+}
+
+// kind returns the class of err or nil if it isn't a decoding error.
+func kind(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrTruncated
+	}
+
+	for _, k := range kinds {
+		if errors.Is(err, k) {
+			return k
+		}
+	}
+
+	return nil
+}
+
+// locate returns err as a DecodeError at the given position. Errors that
+// already have a position and errors that aren't decoding errors are returned
+// unchanged.
+func locate(err error, offset uint64, value byte, parent *scope, stack Stack) error {
+	if err == nil {
+		return nil
+	}
+
+	var de *DecodeError
+	if errors.As(err, &de) {
+		return err
+	}
+
+	k := kind(err)
+	if k == nil {
+		return err
+	}
+
+	return &DecodeError{
+		Kind:   k,
+		Offset: offset,
+		Byte:   value,
+		Path:   parent.path(stack),
+		Err:    err,
+	}
+}
+
+// scope links the stack of a decoder reading the size of a bounded container
+// to the stacks of the decoders it is embedded in. The path is only built
+// when an error is located so deeply nested sizes aren't copied at each level.
+type scope struct {
+	parent *scope
+	stack  *Stack
+}
+
+// path returns the container types in the scope from the outermost inwards
+// followed by those in stack.
+func (s *scope) path(stack Stack) []Type {
+	var stacks []Stack
+	for ; s != nil; s = s.parent {
+		stacks = append(stacks, *s.stack)
+	}
+
+	p := []Type{}
+	for i := len(stacks) - 1; i >= 0; i-- {
+		for _, f := range stacks[i] {
+			p = append(p, f.Type)
+		}
+	}
+
+	for _, f := range stack {
+		p = append(p, f.Type)
+	}
+
+	return p
+}
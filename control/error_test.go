@@ -0,0 +1,232 @@
+package control_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calebcase/bsv/control"
+	"github.com/calebcase/oops"
+)
+
+// decodeAll enters every container and reads every data field.
+func decodeAll(d control.Decoder) (err error) {
+	for d.Next() {
+		switch d.Type() {
+		case control.ContainerSymmetric, control.ContainerBounded, control.ContainerUnbounded:
+			err = d.Enter()
+		case control.Data, control.DataSize, control.Data1, control.Data2, control.DataSizeSize:
+			_, err = d.Data()
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return d.Err()
+}
+
+func TestDecodeError(t *testing.T) {
+	type TC struct {
+		Input   []byte
+		Options control.DecoderOptions
+		Kind    error
+		Offset  uint64
+		Byte    byte
+		Path    []control.Type
+		Mark    error
+	}
+
+	tcs := []TC{
+		{
+			Input:  []byte{0b_0000_0100},
+			Kind:   control.ErrUnpairedEnd,
+			Offset: 1,
+			Byte:   0b_0000_0100,
+			Path:   []control.Type{},
+			Mark:   oops.New("unexpected"),
+		},
+		{
+			Input: []byte{
+				0b_0000_0110,
+				0b_0000_0111, 0b_0000_0100,
+			},
+			Kind:   control.ErrUnpairedEnd,
+			Offset: 3,
+			Byte:   0b_0000_0100,
+			Path:   []control.Type{control.ContainerUnbounded, control.ContainerSymmetric},
+			Mark:   oops.New("unexpected"),
+		},
+		{
+			Input: []byte{
+				0b_0000_0110,
+				0b_0000_0110,
+				0b_0100_0010, 0xaa,
+			},
+			Kind:   control.ErrTruncated,
+			Offset: 3,
+			Byte:   0b_0100_0010,
+			Path:   []control.Type{control.ContainerUnbounded, control.ContainerUnbounded},
+			Mark:   oops.New("unexpected"),
+		},
+		{
+			// The bounded container's size is missing.
+			Input: []byte{
+				0b_0000_0111,
+				0b_0000_0101,
+			},
+			Kind:   control.ErrTruncated,
+			Offset: 2,
+			Byte:   0b_0000_0101,
+			Path:   []control.Type{control.ContainerSymmetric, control.ContainerBounded},
+			Mark:   oops.New("unexpected"),
+		},
+		{
+			// A field larger than its bounded container.
+			Input: []byte{
+				0b_0000_0101, 0b_1000_0001,
+				0b_0100_0010, 0xaa, 0xbb, 0xcc,
+			},
+			Kind:   control.ErrBoundedOverflow,
+			Offset: 6,
+			Byte:   0b_0100_0010,
+			Path:   []control.Type{control.ContainerBounded},
+			Mark:   oops.New("unexpected"),
+		},
+	}
+
+	decoders := map[string]func(tc TC) control.Decoder{
+		"reader": func(tc TC) control.Decoder {
+			return control.NewDecoderWithOptions(bytes.NewReader(tc.Input), tc.Options)
+		},
+		"bytes": func(tc TC) control.Decoder {
+			return control.NewBytesDecoder(tc.Input)
+		},
+	}
+
+	for name, newDecoder := range decoders {
+		for _, tc := range tcs {
+			err := decodeAll(newDecoder(tc))
+			require.Error(t, err, name, tc.Mark)
+			require.True(t, errors.Is(err, tc.Kind), name, err, tc.Mark)
+
+			de := &control.DecodeError{}
+			require.True(t, errors.As(err, &de), name, tc.Mark)
+			require.Equal(t, tc.Kind, de.Kind, name, tc.Mark)
+			require.Equal(t, tc.Offset, de.Offset, name, tc.Mark)
+			require.Equal(t, tc.Byte, de.Byte, name, tc.Mark)
+			require.Equal(t, tc.Path, de.Path, name, tc.Mark)
+		}
+	}
+}
+
+func TestDecodeErrorLimit(t *testing.T) {
+	input := []byte{
+		0b_0000_0110,
+		0b_0100_0010, 0xaa, 0xbb, 0xcc,
+		0b_0000_0100,
+	}
+
+	d := control.NewDecoderWithOptions(bytes.NewReader(input), control.DecoderOptions{
+		MaxFieldSize: 2,
+	})
+
+	err := decodeAll(d)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, control.ErrLimitExceeded))
+
+	le := &control.LimitError{}
+	require.True(t, errors.As(err, &le))
+	require.Equal(t, "field size", le.Limit)
+
+	de := &control.DecodeError{}
+	require.True(t, errors.As(err, &de))
+	require.Equal(t, control.ErrLimitExceeded, de.Kind)
+	require.Equal(t, uint64(2), de.Offset)
+	require.Equal(t, []control.Type{control.ContainerUnbounded}, de.Path)
+	require.Contains(t, de.Error(), "offset=2 byte=01000010 path=/cu")
+}
+
+func TestDecodeErrorReverse(t *testing.T) {
+	// The data of the Data Size field is missing.
+	input := []byte{0b_1000_0000, 0b_0100_0000}
+
+	err := decodeAll(control.NewReverseDecoder(bytes.NewReader(input), int64(len(input))))
+	require.Error(t, err)
+	require.True(t, errors.Is(err, control.ErrTruncated))
+
+	de := &control.DecodeError{}
+	require.True(t, errors.As(err, &de))
+	require.Equal(t, uint64(2), de.Offset)
+	require.Equal(t, byte(0b_0100_0000), de.Byte)
+}
+
+func TestDecodeErrorDeep(t *testing.T) {
+	// Each bounded container's size starts with another bounded container
+	// up to the deepest nesting allowed.
+	const depth = 64
+
+	input := bytes.Repeat([]byte{0b_0000_0101}, depth)
+
+	for _, d := range []control.Decoder{
+		control.NewDecoder(bytes.NewReader(input)),
+		control.NewBytesDecoder(input),
+	} {
+		err := decodeAll(d)
+		require.Error(t, err)
+		require.True(t, errors.Is(err, control.ErrTruncated))
+
+		de := &control.DecodeError{}
+		require.True(t, errors.As(err, &de))
+		require.Equal(t, uint64(depth), de.Offset)
+		require.Len(t, de.Path, depth)
+	}
+}
+
+func TestDecodeErrorOpen(t *testing.T) {
+	type TC struct {
+		Input  []byte
+		Offset uint64
+		Path   []control.Type
+		Mark   error
+	}
+
+	tcs := []TC{
+		{
+			Input:  []byte{0b_0000_0110, 0b_1000_0001},
+			Offset: 2,
+			Path:   []control.Type{control.ContainerUnbounded},
+			Mark:   oops.New("unexpected"),
+		},
+		{
+			Input:  []byte{0b_0000_0110, 0b_0000_0110, 0b_0000_0100},
+			Offset: 3,
+			Path:   []control.Type{control.ContainerUnbounded},
+			Mark:   oops.New("unexpected"),
+		},
+		{
+			Input:  []byte{0b_0000_0101, 0b_1000_0100, 0b_1000_0001},
+			Offset: 3,
+			Path:   []control.Type{control.ContainerBounded},
+			Mark:   oops.New("unexpected"),
+		},
+	}
+
+	for _, tc := range tcs {
+		for _, d := range []control.Decoder{
+			control.NewDecoder(bytes.NewReader(tc.Input)),
+			control.NewBytesDecoder(tc.Input),
+		} {
+			err := decodeAll(d)
+			require.Error(t, err, tc.Mark)
+			require.True(t, errors.Is(err, control.ErrTruncated), tc.Mark)
+
+			de := &control.DecodeError{}
+			require.True(t, errors.As(err, &de), tc.Mark)
+			require.Equal(t, tc.Offset, de.Offset, tc.Mark)
+			require.Equal(t, tc.Path, de.Path, tc.Mark)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package control_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calebcase/bsv/control"
+)
+
+// encode returns the BSV written by fn.
+func encode(t *testing.T, fn func(e control.Encoder) error) []byte {
+	buf := &bytes.Buffer{}
+
+	require.NoError(t, fn(control.NewEncoder(buf)))
+
+	return buf.Bytes()
+}
+
+// records writes count bounded records. Each holds the record's index followed
+// by a field with size(index) copies of it.
+func records(e control.Encoder, count int, size func(i int) int) (err error) {
+	for i := 0; i < count; i++ {
+		err = e.BoundFunc(func(be control.Encoder) (err error) {
+			err = be.Data([]byte{byte(i)})
+			if err != nil {
+				return err
+			}
+
+			return be.Data(bytes.Repeat([]byte{byte(i)}, size(i)))
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
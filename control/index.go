@@ -0,0 +1,283 @@
+package control
+
+import (
+	"bytes"
+	"io"
+	"math"
+
+	"github.com/calebcase/bsv/internal/wire"
+	"github.com/calebcase/oops"
+)
+
+// Entry locates a field in a BSV stream.
+type Entry struct {
+	// Offset of the field's first control block.
+	Offset uint64
+
+	Type Type
+
+	// Length is the number of bytes in the field including all of its
+	// control blocks.
+	Length uint64
+
+	// Depth is the container depth of the field. Top level fields have
+	// depth 0.
+	Depth int
+}
+
+// Index is a list of field locations in stream order. Containers come before
+// the fields embedded in them.
+type Index []Entry
+
+// BuildIndex returns an index of the top level fields in r.
+func BuildIndex(r io.Reader) (idx Index, err error) {
+	return BuildIndexDepth(r, 0)
+}
+
+// BuildIndexDepth returns an index of the fields in r with depth at most
+// depth. Only bounded and unbounded containers are descended into. A
+// symmetric container is indexed as a single field.
+func BuildIndexDepth(r io.Reader, depth int) (idx Index, err error) {
+	d := NewDecoder(r)
+
+	// container is an entered container. The decoder pops bounded
+	// containers as soon as their last byte is read, so the depth of the
+	// fields is tracked here instead.
+	type container struct {
+		i int
+
+		// end is the end of the BSV embedded in a bounded container or
+		// zero for an unbounded container.
+		end uint64
+	}
+
+	var containers []container
+
+	// open holds the entries whose end hasn't been found yet.
+	var open []int
+
+	finish := func(depth int, end uint64) {
+		for len(open) > 0 {
+			i := open[len(open)-1]
+			if idx[i].Depth < depth {
+				return
+			}
+
+			idx[i].Length = end - idx[i].Offset
+			open = open[:len(open)-1]
+		}
+	}
+
+	// leave finishes the bounded containers that end at or before offset.
+	leave := func(offset uint64) {
+		for len(containers) > 0 {
+			c := containers[len(containers)-1]
+			if c.end == 0 || c.end > offset {
+				return
+			}
+
+			finish(len(containers), c.end)
+			containers = containers[:len(containers)-1]
+		}
+	}
+
+	for d.Next() {
+		offset := d.Consumed() - 1
+		t := d.Type()
+
+		leave(offset)
+
+		fieldDepth := len(containers)
+		finish(fieldDepth, offset)
+
+		if t == ContainerEnd {
+			if fieldDepth == 0 {
+				return nil, Error.New("unexpected container end at offset %d", offset)
+			}
+
+			// The end block belongs to the unbounded container.
+			c := containers[len(containers)-1]
+			idx[c.i].Length = offset + 1 - idx[c.i].Offset
+			containers = containers[:len(containers)-1]
+
+			continue
+		}
+
+		idx = append(idx, Entry{
+			Offset: offset,
+			Type:   t,
+			Depth:  fieldDepth,
+		})
+		i := len(idx) - 1
+
+		if fieldDepth < depth {
+			switch t {
+			case ContainerBounded:
+				size, err := d.Size()
+				if err != nil {
+					return nil, err
+				}
+
+				end := d.Consumed() + size
+				idx[i].Length = end - offset
+
+				err = d.Enter()
+				if err != nil {
+					return nil, err
+				}
+
+				containers = append(containers, container{i, end})
+
+				continue
+			case ContainerUnbounded:
+				err = d.Enter()
+				if err != nil {
+					return nil, err
+				}
+
+				containers = append(containers, container{i, 0})
+
+				continue
+			}
+		}
+
+		open = append(open, i)
+	}
+
+	err = d.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	leave(d.Consumed())
+	finish(0, d.Consumed())
+
+	return idx, nil
+}
+
+// WriteTo writes the index as BSV. Each entry is a bounded container with the
+// offset, control type prefix, length and depth as data fields.
+func (idx Index) WriteTo(w io.Writer) (n int64, err error) {
+	cw := &wire.CountingWriter{W: w}
+	e := NewEncoder(cw)
+
+	buf := &bytes.Buffer{}
+
+	for _, entry := range idx {
+		buf.Reset()
+		ee := NewEncoder(buf)
+
+		for _, v := range []uint64{
+			entry.Offset,
+			uint64(entry.Type.Prefix),
+			entry.Length,
+			uint64(entry.Depth),
+		} {
+			err = ee.Data(wire.UintBytes(v))
+			if err != nil {
+				return cw.N, err
+			}
+		}
+
+		err = e.Bound(buf.Bytes())
+		if err != nil {
+			return cw.N, err
+		}
+	}
+
+	return cw.N, nil
+}
+
+// ReadIndex reads an index written by Index.WriteTo.
+func ReadIndex(r io.Reader) (idx Index, err error) {
+	d := NewDecoder(r)
+
+	for d.Next() {
+		if d.Type() != ContainerBounded {
+			return nil, Error.New("invalid index entry: %s", d.Type().Abbr)
+		}
+
+		err = d.Enter()
+		if err != nil {
+			return nil, err
+		}
+
+		var values [4]uint64
+
+		for i := range values {
+			if !d.Next() {
+				if d.Err() != nil {
+					return nil, d.Err()
+				}
+
+				return nil, Error.New("invalid index entry: missing field %d", i)
+			}
+
+			data, err := d.Data()
+			if err != nil {
+				return nil, err
+			}
+
+			values[i], err = wire.BytesUint(data)
+			if err != nil {
+				return nil, Error.Trace(err)
+			}
+		}
+
+		if d.Depth() != 0 {
+			return nil, Error.New("invalid index entry: unexpected fields")
+		}
+
+		t, ok := Types.Match(byte(values[1]))
+		if !ok || values[1] > math.MaxUint8 || t.Prefix != byte(values[1]) {
+			return nil, Error.New("invalid index entry: type %d", values[1])
+		}
+
+		if values[3] > math.MaxInt32 {
+			return nil, Error.New("invalid index entry: depth %d", values[3])
+		}
+
+		idx = append(idx, Entry{
+			Offset: values[0],
+			Type:   t,
+			Length: values[2],
+			Depth:  int(values[3]),
+		})
+	}
+
+	err = d.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return idx, nil
+}
+
+// FieldSeeker is implemented by decoders that can move directly to a field in
+// an index. It isn't part of Decoder so that other implementations of Decoder
+// aren't required to support it.
+type FieldSeeker interface {
+	SeekToField(idx Index, i int) (err error)
+}
+
+// SeekToField moves d to field i in the index so the next call to Next reads
+// it. The decoders returned by NewDecoder, NewBytesDecoder and
+// NewReverseDecoder support it. For any other decoder it returns
+// ErrInvalidOperation.
+func SeekToField(d Decoder, idx Index, i int) (err error) {
+	fs, ok := d.(FieldSeeker)
+	if !ok {
+		return oops.Trace(ErrInvalidOperation)
+	}
+
+	return fs.SeekToField(idx, i)
+}
+
+// checkField returns the entry for field i of the index.
+func checkField(idx Index, i int) (entry Entry, err error) {
+	if i < 0 || i >= len(idx) {
+		return entry, Error.New("invalid field: %d (index has %d)", i, len(idx))
+	}
+
+	return idx[i], nil
+}
@@ -0,0 +1,298 @@
+package control_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calebcase/bsv/control"
+	"github.com/calebcase/oops"
+)
+
+// indexFixture encodes top level fields of each kind including nested
+// containers.
+func indexFixture(t *testing.T) []byte {
+	return encode(t, func(e control.Encoder) (err error) {
+		err = e.Data([]byte{0b_0000_0001})
+		if err != nil {
+			return err
+		}
+
+		err = e.Data(bytes.Repeat([]byte{0xff}, 100))
+		if err != nil {
+			return err
+		}
+
+		err = e.Null()
+		if err != nil {
+			return err
+		}
+
+		err = e.Bound([]byte{0b_1000_0001, 0b_0000_0000})
+		if err != nil {
+			return err
+		}
+
+		err = e.Unbound(func(ue control.Encoder) error {
+			err := ue.Data([]byte{0b_0000_0001, 0xff})
+			if err != nil {
+				return err
+			}
+
+			return ue.Unbound(func(ue control.Encoder) error {
+				return ue.Empty()
+			})
+		})
+		if err != nil {
+			return err
+		}
+
+		return e.Symmetric(func(se control.Encoder) error {
+			return se.Data([]byte{0xff, 0xff})
+		})
+	})
+}
+
+// notSeeker hides the io.Seeker of a reader.
+type notSeeker struct {
+	io.Reader
+}
+
+func TestBuildIndex(t *testing.T) {
+	mark := oops.New("unexpected")
+
+	bsv := indexFixture(t)
+
+	idx, err := control.BuildIndex(bytes.NewReader(bsv))
+	require.NoError(t, err, mark)
+	require.Len(t, idx, 6, mark)
+
+	types := []control.Type{
+		control.Data,
+		control.DataSizeSize,
+		control.Null,
+		control.ContainerBounded,
+		control.ContainerUnbounded,
+		control.ContainerSymmetric,
+	}
+
+	var offset uint64
+	for i, entry := range idx {
+		require.Equal(t, offset, entry.Offset, mark)
+		require.Equal(t, types[i], entry.Type, mark)
+		require.Equal(t, 0, entry.Depth, mark)
+
+		offset += entry.Length
+	}
+	require.Equal(t, uint64(len(bsv)), offset, mark)
+
+	// Each entry covers exactly one top level field.
+	for _, entry := range idx {
+		fields, err := control.BuildIndex(bytes.NewReader(bsv[entry.Offset : entry.Offset+entry.Length]))
+		require.NoError(t, err, mark)
+		require.Len(t, fields, 1, mark)
+		require.Equal(t, entry.Type, fields[0].Type, mark)
+	}
+}
+
+func TestBuildIndexDepth(t *testing.T) {
+	mark := oops.New("unexpected")
+
+	bsv := indexFixture(t)
+
+	idx, err := control.BuildIndexDepth(bytes.NewReader(bsv), 2)
+	require.NoError(t, err, mark)
+
+	type want struct {
+		Type  control.Type
+		Depth int
+	}
+
+	wants := []want{
+		{control.Data, 0},
+		{control.DataSizeSize, 0},
+		{control.Null, 0},
+		{control.ContainerBounded, 0},
+		{control.Data, 1},
+		{control.Null, 1},
+		{control.ContainerUnbounded, 0},
+		{control.Data1, 1},
+		{control.ContainerUnbounded, 1},
+		{control.Empty, 2},
+		{control.ContainerSymmetric, 0},
+	}
+
+	require.Len(t, idx, len(wants), mark)
+
+	for i, w := range wants {
+		require.Equal(t, w.Type, idx[i].Type, i)
+		require.Equal(t, w.Depth, idx[i].Depth, i)
+	}
+
+	// The fields in the bounded container are clamped to its contents.
+	require.Equal(t, uint64(1), idx[4].Length, mark)
+	require.Equal(t, uint64(1), idx[5].Length, mark)
+	require.Equal(t, idx[3].Offset+idx[3].Length, idx[5].Offset+idx[5].Length, mark)
+
+	// The unbounded containers include their end blocks.
+	require.Equal(t, idx[6].Offset+idx[6].Length, idx[10].Offset, mark)
+	require.Equal(t, uint64(3), idx[8].Length, mark)
+	require.Equal(t, idx[8].Offset+idx[8].Length+1, idx[10].Offset, mark)
+
+	// Depth 0 is the same as BuildIndex.
+	top, err := control.BuildIndexDepth(bytes.NewReader(bsv), 0)
+	require.NoError(t, err, mark)
+
+	expected, err := control.BuildIndex(bytes.NewReader(bsv))
+	require.NoError(t, err, mark)
+	require.Equal(t, expected, top, mark)
+}
+
+func TestIndexWriteTo(t *testing.T) {
+	mark := oops.New("unexpected")
+
+	idx, err := control.BuildIndexDepth(bytes.NewReader(indexFixture(t)), 2)
+	require.NoError(t, err, mark)
+
+	buf := &bytes.Buffer{}
+
+	n, err := idx.WriteTo(buf)
+	require.NoError(t, err, mark)
+	require.Equal(t, int64(buf.Len()), n, mark)
+
+	problems, err := control.Validate(bytes.NewReader(buf.Bytes()), control.ValidateOptions{Canonical: true})
+	require.NoError(t, err, mark)
+	require.Empty(t, problems, mark)
+
+	read, err := control.ReadIndex(buf)
+	require.NoError(t, err, mark)
+	require.Equal(t, idx, read, mark)
+
+	empty, err := control.ReadIndex(bytes.NewReader(nil))
+	require.NoError(t, err, mark)
+	require.Empty(t, empty, mark)
+
+	_, err = control.ReadIndex(bytes.NewReader([]byte{0b_1000_0001}))
+	require.Error(t, err, mark)
+}
+
+func TestSeekToField(t *testing.T) {
+	mark := oops.New("unexpected")
+
+	bsv := indexFixture(t)
+
+	idx, err := control.BuildIndex(bytes.NewReader(bsv))
+	require.NoError(t, err, mark)
+
+	decoders := map[string]func() control.Decoder{
+		"reader": func() control.Decoder {
+			return control.NewDecoder(bytes.NewReader(bsv))
+		},
+		"bytes": func() control.Decoder {
+			return control.NewBytesDecoder(bsv)
+		},
+	}
+
+	for name, fn := range decoders {
+		t.Run(name, func(t *testing.T) {
+			// Seek in reverse order to exercise seeking backwards.
+			d := fn()
+			for i := len(idx) - 1; i >= 0; i-- {
+				entry := idx[i]
+
+				err := control.SeekToField(d, idx, i)
+				require.NoError(t, err, i)
+
+				expected := walk(t, control.NewBytesDecoder(bsv[entry.Offset:entry.Offset+entry.Length]), mark)
+
+				fields := walk(t, &limitDecoder{d, 1}, mark)
+
+				require.Equal(t, expected, fields, i)
+				require.Equal(t, entry.Offset+entry.Length, d.Consumed(), i)
+			}
+
+			err := control.SeekToField(d, idx, len(idx))
+			require.Error(t, err, mark)
+
+			err = control.SeekToField(fn(), idx, -1)
+			require.Error(t, err, mark)
+		})
+	}
+
+	t.Run("not seeker", func(t *testing.T) {
+		d := control.NewDecoder(notSeeker{bytes.NewReader(bsv)})
+
+		err := control.SeekToField(d, idx, 1)
+		require.True(t, errors.Is(err, control.ErrInvalidOperation), mark)
+
+		// Other implementations of Decoder aren't required to
+		// support it.
+		err = control.SeekToField(&limitDecoder{control.NewBytesDecoder(bsv), 1}, idx, 1)
+		require.True(t, errors.Is(err, control.ErrInvalidOperation), mark)
+	})
+}
+
+func TestReverseSeekToField(t *testing.T) {
+	mark := oops.New("unexpected")
+
+	buf := &bytes.Buffer{}
+	e := control.NewEncoder(buf)
+
+	for _, fn := range []func(control.Encoder) error{
+		func(se control.Encoder) error {
+			return se.Data([]byte{0b_0000_0001})
+		},
+		func(se control.Encoder) error {
+			return se.Data([]byte{0xff, 0xff})
+		},
+		func(se control.Encoder) error {
+			return se.Null()
+		},
+		func(se control.Encoder) error {
+			return se.Bound([]byte{0b_1000_0001})
+		},
+	} {
+		require.NoError(t, e.Symmetric(fn), mark)
+	}
+	bsv := buf.Bytes()
+
+	idx, err := control.BuildIndex(bytes.NewReader(bsv))
+	require.NoError(t, err, mark)
+	require.Len(t, idx, 4, mark)
+
+	d := control.NewReverseDecoder(bytes.NewReader(bsv), int64(len(bsv)))
+
+	for _, i := range []int{1, 3, 0, 2} {
+		entry := idx[i]
+
+		err = control.SeekToField(d, idx, i)
+		require.NoError(t, err, i)
+
+		field := bsv[entry.Offset : entry.Offset+entry.Length]
+		expected := walk(t, control.NewReverseDecoder(bytes.NewReader(field), int64(len(field))), mark)
+
+		fields := walk(t, &limitDecoder{d, 1}, mark)
+		require.Equal(t, expected, fields, i)
+	}
+}
+
+// limitDecoder stops after reading n top level fields.
+type limitDecoder struct {
+	control.Decoder
+	n int
+}
+
+func (ld *limitDecoder) Next() bool {
+	if ld.Depth() == 0 {
+		if ld.n == 0 {
+			return false
+		}
+
+		ld.n--
+	}
+
+	return ld.Decoder.Next()
+}
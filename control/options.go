@@ -0,0 +1,142 @@
+package control
+
+import "fmt"
+
+// ErrLimitExceeded is returned (wrapped in a LimitError) when decoding would
+// exceed one of the limits in DecoderOptions.
+var ErrLimitExceeded = Error.New("limit exceeded")
+
+// LimitError reports which limit was exceeded and by how much.
+type LimitError struct {
+	Limit string
+	Max   uint64
+	Value uint64
+}
+
+func (le *LimitError) Error() string {
+	return fmt.Sprintf("limit exceeded: %s: %d > %d", le.Limit, le.Value, le.Max)
+}
+
+func (le *LimitError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// DecoderOptions limits the resources a decoder will use and selects how
+// strictly it checks the input. A zero value for any of the limits means that
+// limit is not enforced.
+type DecoderOptions struct {
+	// MaxFieldSize is the largest data size allowed for Data Size and
+	// Data Size Size fields.
+	MaxFieldSize uint64
+
+	// MaxBoundedSize is the largest embedded BSV size allowed for
+	// Container Bounded fields.
+	MaxBoundedSize uint64
+
+	// MaxDepth is the deepest nesting of containers allowed. This includes
+	// containers used to encode the size of bounded containers.
+	MaxDepth int
+
+	// MaxTotal is the most bytes that will be read from the input.
+	MaxTotal uint64
+
+	// StrictSymmetric reads the trailing control blocks of symmetric
+	// fields and verifies they mirror the leading control blocks instead
+	// of skipping them. A mismatch results in an error wrapping
+	// ErrAsymmetric.
+	StrictSymmetric bool
+}
+
+// HardenedDecoderOptions are limits suitable for decoding input from
+// untrusted sources.
+var HardenedDecoderOptions = DecoderOptions{
+	MaxFieldSize:   16 << 20,
+	MaxBoundedSize: 64 << 20,
+	MaxDepth:       64,
+	MaxTotal:       1 << 30,
+}
+
+// maxSizeDepth is the deepest nesting of bounded containers used to encode the
+// size of a bounded container. Each one is read by recursion, so this limit is
+// enforced even if MaxDepth is not.
+const maxSizeDepth = 64
+
+// checkSizeDepth verifies that reading a bounded container's size nested in
+// depth others stays within maxSizeDepth.
+func checkSizeDepth(depth int) (err error) {
+	if depth > maxSizeDepth {
+		return Error.Trace(&LimitError{"size depth", maxSizeDepth, uint64(depth)})
+	}
+
+	return nil
+}
+
+func (o DecoderOptions) checkFieldSize(size uint64) (err error) {
+	if o.MaxFieldSize != 0 && size > o.MaxFieldSize {
+		return Error.Trace(&LimitError{"field size", o.MaxFieldSize, size})
+	}
+
+	return nil
+}
+
+func (o DecoderOptions) checkBoundedSize(size uint64) (err error) {
+	if o.MaxBoundedSize != 0 && size > o.MaxBoundedSize {
+		return Error.Trace(&LimitError{"bounded size", o.MaxBoundedSize, size})
+	}
+
+	return nil
+}
+
+func (o DecoderOptions) checkDepth(depth int) (err error) {
+	if o.MaxDepth != 0 && depth > o.MaxDepth {
+		return Error.Trace(&LimitError{"depth", uint64(o.MaxDepth), uint64(depth)})
+	}
+
+	return nil
+}
+
+// checkTotal verifies that reading size more bytes after consumed stays within
+// the total limit.
+func (o DecoderOptions) checkTotal(consumed, size uint64) (err error) {
+	if o.MaxTotal == 0 {
+		return nil
+	}
+
+	if consumed > o.MaxTotal || size > o.MaxTotal-consumed {
+		return Error.Trace(&LimitError{"total", o.MaxTotal, consumed + size})
+	}
+
+	return nil
+}
+
+// Policy selects the block used to encode data fields. Fixed block policies
+// trade compactness for a layout that depends only on the size of the data,
+// so fields can later be updated in place with Patch.
+type Policy struct {
+	// Type is DataSize or DataSizeSize to always use that block, or
+	// Unknown to use the most compact block.
+	Type Type
+
+	// SizeSize is the number of size bytes used with DataSizeSize. Zero
+	// uses the fewest bytes needed.
+	SizeSize int
+}
+
+var (
+	// Compact uses the most compact block for the data.
+	Compact = Policy{}
+
+	// FixedDataSize always uses a Data Size block. The data must be at
+	// most 64 bytes.
+	FixedDataSize = Policy{Type: DataSize}
+
+	// FixedDataSizeSize always uses a Data Size Size block with an 8 byte
+	// size.
+	FixedDataSizeSize = Policy{Type: DataSizeSize, SizeSize: 8}
+)
+
+// EncoderOptions configures an encoder.
+type EncoderOptions struct {
+	// Policy is used for data fields written with Data and DataFrom.
+	Policy Policy
+}
@@ -0,0 +1,242 @@
+package control_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calebcase/bsv/control"
+	"github.com/calebcase/oops"
+)
+
+func TestDecoderOptions(t *testing.T) {
+	type TC struct {
+		Input []byte
+		Opts  control.DecoderOptions
+		Limit string
+		Mark  error
+	}
+
+	encode := func(fn func(control.Encoder) error) []byte {
+		output := &bytes.Buffer{}
+
+		err := fn(control.NewEncoder(output))
+		require.NoError(t, err)
+
+		return output.Bytes()
+	}
+
+	nested := func(depth int) func(control.Encoder) error {
+		var fn func(e control.Encoder) error
+		fn = func(e control.Encoder) error {
+			depth--
+			if depth == 0 {
+				return e.Null()
+			}
+
+			return e.Unbound(fn)
+		}
+
+		return fn
+	}
+
+	tcs := []TC{
+		{
+			Input: encode(func(e control.Encoder) error {
+				return e.Data(bytes.Repeat([]byte{1}, 1024))
+			}),
+			Opts:  control.DecoderOptions{MaxFieldSize: 1023},
+			Limit: "field size",
+			Mark:  oops.New("unexpected"),
+		},
+		{
+			Input: encode(func(e control.Encoder) error {
+				return e.Data(bytes.Repeat([]byte{1}, 64))
+			}),
+			Opts:  control.DecoderOptions{MaxFieldSize: 63},
+			Limit: "field size",
+			Mark:  oops.New("unexpected"),
+		},
+		{
+			Input: encode(func(e control.Encoder) error {
+				return e.Bound(bytes.Repeat([]byte{0b_1000_0001}, 200))
+			}),
+			Opts:  control.DecoderOptions{MaxBoundedSize: 199},
+			Limit: "bounded size",
+			Mark:  oops.New("unexpected"),
+		},
+		{
+			Input: encode(nested(10)),
+			Opts:  control.DecoderOptions{MaxDepth: 8},
+			Limit: "depth",
+			Mark:  oops.New("unexpected"),
+		},
+		{
+			Input: encode(func(e control.Encoder) error {
+				return e.Symmetric(func(e control.Encoder) error {
+					return e.Symmetric(func(e control.Encoder) error {
+						return e.Data([]byte{1, 2})
+					})
+				})
+			}),
+			Opts:  control.DecoderOptions{MaxDepth: 1},
+			Limit: "depth",
+			Mark:  oops.New("unexpected"),
+		},
+		{
+			Input: encode(func(e control.Encoder) error {
+				return e.Data(bytes.Repeat([]byte{1}, 1024))
+			}),
+			Opts:  control.DecoderOptions{MaxTotal: 1000},
+			Limit: "total",
+			Mark:  oops.New("unexpected"),
+		},
+		{
+			Input: bytes.Repeat([]byte{0b_1000_0001}, 100),
+			Opts:  control.DecoderOptions{MaxTotal: 99},
+			Limit: "total",
+			Mark:  oops.New("unexpected"),
+		},
+		{
+			// Data Size Size claiming 2^60 bytes of data.
+			Input: append([]byte{0b_0000_1111, 0x0f}, bytes.Repeat([]byte{0xff}, 7)...),
+			Opts:  control.HardenedDecoderOptions,
+			Limit: "field size",
+			Mark:  oops.New("unexpected"),
+		},
+		{
+			// Container Bounded claiming 2^60 bytes of embedded BSV.
+			Input: append([]byte{0b_0000_0101, 0b_0100_0111, 0x0f}, bytes.Repeat([]byte{0xff}, 7)...),
+			Opts:  control.HardenedDecoderOptions,
+			Limit: "bounded size",
+			Mark:  oops.New("unexpected"),
+		},
+		{
+			// Container Bounded size with 2^60 bytes of data.
+			Input: append([]byte{0b_0000_0101, 0b_0000_1111, 0x0f}, bytes.Repeat([]byte{0xff}, 7)...),
+			Opts:  control.HardenedDecoderOptions,
+			Limit: "field size",
+			Mark:  oops.New("unexpected"),
+		},
+		{
+			// Container Bounded sizes recursively encoded with Container
+			// Bounded.
+			Input: bytes.Repeat([]byte{0b_0000_0101}, 100000),
+			Opts:  control.HardenedDecoderOptions,
+			Limit: "depth",
+			Mark:  oops.New("unexpected"),
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(shortName(i, tc.Input), func(t *testing.T) {
+			for _, d := range []control.Decoder{
+				control.NewDecoderWithOptions(bytes.NewReader(tc.Input), tc.Opts),
+				control.NewBytesDecoderWithOptions(tc.Input, tc.Opts),
+			} {
+				for d.Next() {
+				}
+
+				err := d.Err()
+				require.Error(t, err, tc.Mark)
+				require.True(t, errors.Is(err, control.ErrLimitExceeded), tc.Mark)
+
+				var le *control.LimitError
+				require.True(t, errors.As(err, &le), tc.Mark)
+				require.Equal(t, tc.Limit, le.Limit, tc.Mark)
+				require.Greater(t, le.Value, le.Max, tc.Mark)
+			}
+		})
+	}
+
+	t.Run("size depth", func(t *testing.T) {
+		// Container Bounded sizes recursively encoded with Container
+		// Bounded are limited even without a depth limit.
+		input := bytes.Repeat([]byte{0b_0000_0101}, 2<<20)
+
+		for _, d := range []control.Decoder{
+			control.NewDecoder(bytes.NewReader(input)),
+			control.NewBytesDecoder(input),
+		} {
+			for d.Next() {
+			}
+
+			err := d.Err()
+			require.Error(t, err)
+			require.True(t, errors.Is(err, control.ErrLimitExceeded))
+
+			var le *control.LimitError
+			require.True(t, errors.As(err, &le))
+			require.Equal(t, "size depth", le.Limit)
+
+			var de *control.DecodeError
+			require.True(t, errors.As(err, &de))
+			require.Equal(t, control.ErrLimitExceeded, de.Kind)
+		}
+	})
+
+	t.Run("declared size", func(t *testing.T) {
+		inputs := [][]byte{
+			// Data Size Size claiming 2^60 bytes of data.
+			append([]byte{0b_0000_1111, 0x0f}, bytes.Repeat([]byte{0xff}, 7)...),
+
+			// Container Bounded claiming 2^60 bytes of embedded BSV.
+			append([]byte{0b_0000_0101, 0b_0100_0111, 0x0f}, bytes.Repeat([]byte{0xff}, 7)...),
+
+			// Data Size Size claiming a byte more than the input.
+			append([]byte{0b_0000_1010, 0x03, 0xff, 0xff}, bytes.Repeat([]byte{0x01}, 1<<18-1)...),
+		}
+
+		for _, input := range inputs {
+			mark := oops.New("unexpected: %x", input[:4])
+
+			// Without limits the data is read instead of skipped.
+			for _, d := range []control.Decoder{
+				control.NewDecoder(bytes.NewReader(input)),
+				control.NewBytesDecoder(input),
+			} {
+				require.True(t, d.Next(), mark)
+
+				var err error
+				if d.Type() == control.ContainerBounded {
+					_, err = d.BSV()
+				} else {
+					_, err = d.Data()
+				}
+				require.Error(t, err, mark)
+				require.True(t, errors.Is(err, io.ErrUnexpectedEOF), mark)
+			}
+		}
+	})
+
+	t.Run("within limits", func(t *testing.T) {
+		input := encode(func(e control.Encoder) error {
+			err := e.Data(bytes.Repeat([]byte{1}, 1024))
+			if err != nil {
+				return err
+			}
+
+			return e.Bound(bytes.Repeat([]byte{0b_1000_0001}, 200))
+		})
+
+		opts := control.DecoderOptions{
+			MaxFieldSize:   1024,
+			MaxBoundedSize: 200,
+			MaxDepth:       1,
+			MaxTotal:       uint64(len(input)),
+		}
+
+		for _, d := range []control.Decoder{
+			control.NewDecoderWithOptions(bytes.NewReader(input), opts),
+			control.NewBytesDecoderWithOptions(input, opts),
+		} {
+			for d.Next() {
+			}
+			require.NoError(t, d.Err())
+			require.Equal(t, uint64(len(input)), d.Consumed())
+		}
+	})
+}
@@ -0,0 +1,161 @@
+package control
+
+import (
+	"io"
+	"runtime"
+	"sync"
+)
+
+// ParallelDecode decodes a stream of top level bounded containers with
+// workers goroutines. Each container is sliced off the input using its size
+// and decode is called with its index in the stream and a decoder for its
+// contents. decode is called concurrently and in no particular order. The
+// results are passed to commit one at a time in input order.
+//
+// Errors are returned in input order: if more than one record fails in decode
+// or commit, the error for the first of them is returned. No records are
+// committed and no new records are started once an error occurs. If workers
+// is zero or less GOMAXPROCS workers are used.
+func ParallelDecode(
+	r io.Reader,
+	workers int,
+	decode func(idx int, d Decoder) (interface{}, error),
+	commit func(idx int, result interface{}) error,
+) error {
+	return ParallelDecodeWithOptions(r, workers, DecoderOptions{}, decode, commit)
+}
+
+// ParallelDecodeWithOptions is like ParallelDecode, but the stream is decoded
+// with the limits in opts. MaxBoundedSize limits the memory held by each
+// record.
+func ParallelDecodeWithOptions(
+	r io.Reader,
+	workers int,
+	opts DecoderOptions,
+	decode func(idx int, d Decoder) (interface{}, error),
+	commit func(idx int, result interface{}) error,
+) (err error) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	type result struct {
+		value interface{}
+		err   error
+	}
+
+	type record struct {
+		idx  int
+		bsv  []byte
+		slot chan result
+	}
+
+	// Records are handed to the workers through records and their slots
+	// are queued in input order in pending.
+	records := make(chan record, workers)
+	pending := make(chan chan result, workers)
+
+	var (
+		mu     sync.Mutex
+		failed bool
+	)
+
+	stopped := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return failed
+	}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for rec := range records {
+				if stopped() {
+					rec.slot <- result{}
+
+					continue
+				}
+
+				value, err := decode(rec.idx, NewBytesDecoder(rec.bsv))
+				rec.slot <- result{value, err}
+			}
+		}()
+	}
+
+	// The results are committed in input order. Once a record fails the
+	// remaining results are only drained.
+	var ferr error
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		idx := 0
+		for slot := range pending {
+			res := <-slot
+
+			if ferr == nil {
+				ferr = res.err
+				if ferr == nil {
+					ferr = commit(idx, res.value)
+				}
+
+				if ferr != nil {
+					mu.Lock()
+					failed = true
+					mu.Unlock()
+				}
+			}
+
+			idx++
+		}
+	}()
+
+	d := NewDecoderWithOptions(r, opts)
+
+	for idx := 0; !stopped(); idx++ {
+		if !d.Next() {
+			err = d.Err()
+
+			break
+		}
+
+		if d.Type() != ContainerBounded {
+			err = Error.New("record %d: unexpected field: %s", idx, d.Type().Abbr)
+
+			break
+		}
+
+		var bsv []byte
+
+		bsv, err = d.BSV()
+		if err != nil {
+			err = Error.New("record %d: %w", idx, err)
+
+			break
+		}
+
+		slot := make(chan result, 1)
+
+		pending <- slot
+		records <- record{idx: idx, bsv: bsv, slot: slot}
+	}
+
+	close(records)
+	close(pending)
+	wg.Wait()
+	<-done
+
+	// Every failed record comes before the one the read stopped at.
+	if ferr != nil {
+		return ferr
+	}
+
+	return err
+}
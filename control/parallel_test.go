@@ -0,0 +1,174 @@
+package control_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calebcase/bsv/control"
+	"github.com/calebcase/oops"
+)
+
+func TestParallelDecode(t *testing.T) {
+	const count = 100
+
+	bsv := encode(t, func(e control.Encoder) error {
+		return records(e, count, func(i int) int {
+			return i*7 + 1
+		})
+	})
+
+	for _, workers := range []int{0, 1, 4, 200} {
+		mark := oops.New("unexpected: workers=%d", workers)
+
+		var values, sizes []int
+
+		decode := func(idx int, d control.Decoder) (interface{}, error) {
+			return walk(t, d, mark), nil
+		}
+
+		commit := func(idx int, result interface{}) error {
+			require.Equal(t, len(values), idx, mark)
+
+			fields := result.([]field)
+
+			values = append(values, int(fields[0].Data[0]))
+			sizes = append(sizes, len(fields[1].Data))
+
+			return nil
+		}
+
+		err := control.ParallelDecode(bytes.NewReader(bsv), workers, decode, commit)
+		require.NoError(t, err, mark)
+
+		require.Len(t, values, count, mark)
+
+		for i := 0; i < count; i++ {
+			require.Equal(t, i, values[i], mark)
+			require.Equal(t, i*7+1, sizes[i], mark)
+		}
+	}
+}
+
+func TestParallelDecodeErrors(t *testing.T) {
+	bsv := encode(t, func(e control.Encoder) error {
+		return records(e, 20, func(i int) int {
+			return i*7 + 1
+		})
+	})
+
+	idx, err := control.BuildIndex(bytes.NewReader(bsv))
+	require.NoError(t, err)
+
+	type TC struct {
+		Input  []byte
+		Fail   func(idx int) error
+		Commit func(idx int) error
+		Err    string
+		Mark   error
+	}
+
+	tcs := []TC{
+		{
+			// The error for the first failed record is returned.
+			Input: bsv,
+			Fail: func(idx int) error {
+				if idx >= 5 {
+					return fmt.Errorf("fail %d", idx)
+				}
+
+				return nil
+			},
+			Err:  "fail 5",
+			Mark: oops.New("unexpected"),
+		},
+		{
+			// A failed commit stops later records.
+			Input: bsv,
+			Commit: func(idx int) error {
+				if idx == 7 {
+					return fmt.Errorf("commit %d", idx)
+				}
+
+				return nil
+			},
+			Err:  "commit 7",
+			Mark: oops.New("unexpected"),
+		},
+		{
+			// A failed record comes before a later failed commit.
+			Input: bsv,
+			Fail: func(idx int) error {
+				if idx == 3 {
+					return fmt.Errorf("fail %d", idx)
+				}
+
+				return nil
+			},
+			Commit: func(idx int) error {
+				if idx == 7 {
+					return fmt.Errorf("commit %d", idx)
+				}
+
+				return nil
+			},
+			Err:  "fail 3",
+			Mark: oops.New("unexpected"),
+		},
+		{
+			// Not a bounded container.
+			Input: append(append([]byte{}, bsv[:idx[3].Offset]...), 0b_1000_0001),
+			Err:   "record 3: unexpected field: d",
+			Mark:  oops.New("unexpected"),
+		},
+		{
+			// Truncated record.
+			Input: bsv[:len(bsv)-1],
+			Err:   "record 19:",
+			Mark:  oops.New("unexpected"),
+		},
+		{
+			// A failed record comes before a read error.
+			Input: bsv[:len(bsv)-1],
+			Fail: func(idx int) error {
+				if idx == 2 {
+					return errors.New("fail 2")
+				}
+
+				return nil
+			},
+			Err:  "fail 2",
+			Mark: oops.New("unexpected"),
+		},
+	}
+
+	for _, tc := range tcs {
+		committed := 0
+
+		decode := func(idx int, d control.Decoder) (interface{}, error) {
+			if tc.Fail == nil {
+				return nil, nil
+			}
+
+			return nil, tc.Fail(idx)
+		}
+
+		commit := func(idx int, result interface{}) error {
+			require.Equal(t, committed, idx, tc.Mark)
+			committed++
+
+			if tc.Commit == nil {
+				return nil
+			}
+
+			return tc.Commit(idx)
+		}
+
+		err := control.ParallelDecode(bytes.NewReader(tc.Input), 4, decode, commit)
+		require.Error(t, err, tc.Mark)
+		require.Contains(t, err.Error(), tc.Err, tc.Mark)
+	}
+}
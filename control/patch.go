@@ -0,0 +1,172 @@
+package control
+
+import (
+	"bytes"
+	"io"
+	"math/big"
+
+	"github.com/calebcase/oops"
+)
+
+// PatchOptions configures PatchWithOptions.
+type PatchOptions struct {
+	// Policy is the fixed block policy the field was written with. When
+	// set the field's leading blocks must be the ones the policy uses for
+	// the new data.
+	Policy Policy
+
+	// Symmetric is set if the field was written in symmetric mode. It is
+	// only used with Policy.
+	Symmetric bool
+}
+
+// readWriterAt is the interface required by Patch.
+type readWriterAt interface {
+	io.ReaderAt
+	io.WriterAt
+}
+
+// Patch replaces the data of the data field at offset. The field's blocks are
+// kept as they are, so the new data must be the same size as the old and fit
+// in the same block. This is the case for any data of the same size in fields
+// written with a fixed block Policy. The trailing blocks of symmetric fields
+// are updated as well. The field's blocks are read to verify the size is
+// unchanged.
+func Patch(rw readWriterAt, offset int64, data []byte) (err error) {
+	return PatchWithOptions(rw, offset, data, PatchOptions{})
+}
+
+// PatchWithOptions is like Patch, but if opts.Policy is set the field's
+// leading blocks are compared to the ones the policy uses instead of being
+// decoded.
+func PatchWithOptions(rw readWriterAt, offset int64, data []byte, opts PatchOptions) (err error) {
+	if opts.Policy.Type != Unknown {
+		return patchPolicy(rw, offset, data, opts)
+	}
+
+	return patch(rw, offset, data, false)
+}
+
+// patchPolicy writes the data of a field written with opts.Policy.
+func patchPolicy(rw readWriterAt, offset int64, data []byte, opts PatchOptions) (err error) {
+	if len(data) == 0 {
+		return Error.New("invalid: size=0")
+	}
+
+	leading, _, err := fixedBlocks(uint64(len(data)), opts.Policy)
+	if err != nil {
+		return err
+	}
+
+	if opts.Symmetric {
+		leading = append([]byte{ContainerSymmetric.Prefix}, leading...)
+	}
+
+	existing := make([]byte, len(leading))
+
+	_, err = rw.ReadAt(existing, offset)
+	if err != nil {
+		return Error.Trace(err)
+	}
+
+	if !bytes.Equal(existing, leading) {
+		return Error.New(
+			"invalid: blocks at offset %d don't match policy: blocks=%0b expected=%0b",
+			offset,
+			existing,
+			leading,
+		)
+	}
+
+	_, err = rw.WriteAt(data, offset+int64(len(leading)))
+	if err != nil {
+		return Error.Trace(err)
+	}
+
+	return nil
+}
+
+func patch(rw readWriterAt, offset int64, data []byte, symmetric bool) (err error) {
+	var value [1]byte
+
+	_, err = rw.ReadAt(value[:], offset)
+	if err != nil {
+		return Error.Trace(err)
+	}
+
+	t, ok := Types.Match(value[0])
+	if !ok {
+		return oops.New("%w: %0b", ErrUnexpectedByte, value[0])
+	}
+
+	var size uint64
+
+	switch t {
+	case ContainerSymmetric:
+		if symmetric {
+			return Error.New("invalid: nested symmetric field at offset %d", offset)
+		}
+
+		return patch(rw, offset+1, data, true)
+	case Data:
+		size = 1
+	case Data1:
+		size = 2
+	case Data2:
+		size = 3
+	case DataSize:
+		size = uint64(value[0]&t.Mask) + 1
+	case DataSizeSize:
+		sizeBytes := make([]byte, int(value[0]&t.Mask)+1)
+
+		_, err = rw.ReadAt(sizeBytes, offset+1)
+		if err != nil {
+			return Error.Trace(err)
+		}
+
+		s := new(big.Int).SetBytes(sizeBytes)
+		s.Add(s, big.NewInt(1))
+		if !s.IsUint64() {
+			return Error.New("unimplemented: size >= 2^64")
+		}
+
+		size = s.Uint64()
+		offset += int64(len(sizeBytes))
+	default:
+		return Error.New("invalid: not a data field at offset %d: %s", offset, t.Abbr)
+	}
+
+	if uint64(len(data)) != size {
+		return Error.New("invalid: size change: size=%d new=%d", size, len(data))
+	}
+
+	switch t {
+	case Data, Data1, Data2:
+		if data[0]&t.Mask != data[0] {
+			return Error.New("invalid: data does not fit in %s block: %0b", t.Abbr, data[0])
+		}
+
+		ctrl := []byte{t.Prefix | data[0]}
+
+		_, err = rw.WriteAt(append(ctrl, data[1:]...), offset)
+		if err != nil {
+			return Error.Trace(err)
+		}
+
+		// The trailing block of symmetric Data1 and Data2 fields holds
+		// the high bits as well.
+		if symmetric && t != Data {
+			_, err = rw.WriteAt(ctrl, offset+int64(size))
+			if err != nil {
+				return Error.Trace(err)
+			}
+		}
+	default:
+		_, err = rw.WriteAt(data, offset+1)
+		if err != nil {
+			return Error.Trace(err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,213 @@
+package control_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calebcase/bsv/control"
+	"github.com/calebcase/oops"
+)
+
+// memFile is an in memory io.ReaderAt and io.WriterAt.
+type memFile []byte
+
+func (mf memFile) ReadAt(p []byte, off int64) (n int, err error) {
+	return bytes.NewReader(mf).ReadAt(p, off)
+}
+
+func (mf memFile) WriteAt(p []byte, off int64) (n int, err error) {
+	return copy(mf[off:], p), nil
+}
+
+func TestPatch(t *testing.T) {
+	type TC struct {
+		Fn        func(control.Encoder) error
+		Policy    control.Policy
+		Symmetric bool
+		Offset    int64
+		Data      []byte
+		Mark      error
+	}
+
+	tcs := []TC{
+		{
+			Fn: func(e control.Encoder) (err error) {
+				err = e.Null()
+				if err != nil {
+					return err
+				}
+
+				return e.Data([]byte{0b_0000_0001})
+			},
+			Offset: 1,
+			Data:   []byte{0b_0111_1111},
+			Mark:   oops.New("unexpected"),
+		},
+		{
+			Fn: func(e control.Encoder) (err error) {
+				return e.Data([]byte{0b_0000_0001})
+			},
+			Policy: control.FixedDataSize,
+			Data:   []byte{0b_1111_1111},
+			Mark:   oops.New("unexpected"),
+		},
+		{
+			Fn: func(e control.Encoder) (err error) {
+				return e.Data([]byte{0, 0, 0, 0, 0, 0, 0, 1})
+			},
+			Policy: control.FixedDataSizeSize,
+			Data:   []byte{0xff, 0, 0, 0, 0, 0, 0, 2},
+			Mark:   oops.New("unexpected"),
+		},
+		{
+			Fn: func(e control.Encoder) (err error) {
+				return e.Symmetric(func(e control.Encoder) (err error) {
+					return e.Data([]byte{0b_0000_0001, 0b_0000_0010})
+				})
+			},
+			Data: []byte{0b_0001_1111, 0b_0000_0011},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Fn: func(e control.Encoder) (err error) {
+				return e.Symmetric(func(e control.Encoder) (err error) {
+					return e.Data([]byte{0, 0, 0, 1})
+				})
+			},
+			Policy:    control.FixedDataSizeSize,
+			Symmetric: true,
+			Data:      []byte{0, 0, 0, 2},
+			Mark:      oops.New("unexpected"),
+		},
+	}
+
+	for i, tc := range tcs {
+		output := &bytes.Buffer{}
+		e := control.NewEncoderWithOptions(output, control.EncoderOptions{
+			Policy: tc.Policy,
+		})
+
+		err := tc.Fn(e)
+		require.NoError(t, err, tc.Mark)
+
+		// The patched file must be the same as if the new data had been
+		// encoded in the first place.
+		expected := &bytes.Buffer{}
+		e = control.NewEncoderWithOptions(expected, control.EncoderOptions{
+			Policy: tc.Policy,
+		})
+
+		err = tc.Fn(&replaceEncoder{e, tc.Data})
+		require.NoError(t, err, tc.Mark)
+
+		t.Run(shortName(i, output.Bytes()), func(t *testing.T) {
+			file := memFile(append([]byte{}, output.Bytes()...))
+
+			err := control.Patch(file, tc.Offset, tc.Data)
+			require.NoError(t, err, tc.Mark)
+			require.Equal(t, expected.Bytes(), []byte(file), tc.Mark)
+
+			fields := walk(t, control.NewDecoder(bytes.NewReader(file)), tc.Mark)
+			require.Equal(t, tc.Data, fields[len(fields)-1].Data, tc.Mark)
+
+			if tc.Policy.Type == control.Unknown {
+				return
+			}
+
+			// Fields with a fixed block policy can be patched by
+			// comparing their blocks to the policy's.
+			file = memFile(append([]byte{}, output.Bytes()...))
+
+			err = control.PatchWithOptions(file, tc.Offset, tc.Data, control.PatchOptions{
+				Policy:    tc.Policy,
+				Symmetric: tc.Symmetric,
+			})
+			require.NoError(t, err, tc.Mark)
+			require.Equal(t, expected.Bytes(), []byte(file), tc.Mark)
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		output := &bytes.Buffer{}
+		e := control.NewEncoder(output)
+		require.NoError(t, e.Data([]byte{0b_0000_0001}))
+		require.NoError(t, e.Data([]byte{0b_0000_0001, 0b_0000_0010}))
+		require.NoError(t, e.Data([]byte{0b_1111_1111}))
+		require.NoError(t, e.Null())
+
+		file := memFile(output.Bytes())
+		original := append([]byte{}, file...)
+
+		// Doesn't fit in a Data block.
+		require.Error(t, control.Patch(file, 0, []byte{0b_1111_1111}))
+
+		// Size change.
+		require.Error(t, control.Patch(file, 1, []byte{0b_0000_0001}))
+		require.Error(t, control.Patch(file, 3, []byte{0b_1111_1111, 0}))
+
+		// Doesn't fit in a Data1 block.
+		require.Error(t, control.Patch(file, 1, []byte{0b_1111_1111, 0}))
+
+		// Not a data field.
+		require.Error(t, control.Patch(file, 5, []byte{0b_0000_0001}))
+
+		// Doesn't fit the policy.
+		err := control.PatchWithOptions(file, 0, bytes.Repeat([]byte{1}, 65), control.PatchOptions{
+			Policy: control.FixedDataSize,
+		})
+		require.Error(t, err)
+
+		// Not written with the policy.
+		err = control.PatchWithOptions(file, 1, []byte{0b_0000_0001, 0b_0000_0010}, control.PatchOptions{
+			Policy: control.FixedDataSize,
+		})
+		require.Error(t, err)
+
+		require.Equal(t, original, []byte(file))
+	})
+
+	t.Run("policy size change", func(t *testing.T) {
+		output := &bytes.Buffer{}
+		e := control.NewEncoderWithOptions(output, control.EncoderOptions{
+			Policy: control.FixedDataSize,
+		})
+		require.NoError(t, e.Data([]byte{1, 2, 3, 4}))
+		require.NoError(t, e.Data([]byte{5, 6}))
+		require.Equal(t, []byte{0x43, 0x01, 0x02, 0x03, 0x04, 0x41, 0x05, 0x06}, output.Bytes())
+
+		file := memFile(output.Bytes())
+		original := append([]byte{}, file...)
+
+		err := control.PatchWithOptions(file, 0, bytes.Repeat([]byte{9}, 6), control.PatchOptions{
+			Policy: control.FixedDataSize,
+		})
+		require.Error(t, err)
+
+		err = control.PatchWithOptions(file, 0, bytes.Repeat([]byte{9}, 4), control.PatchOptions{
+			Policy:    control.FixedDataSize,
+			Symmetric: true,
+		})
+		require.Error(t, err)
+
+		require.Equal(t, original, []byte(file))
+	})
+}
+
+// replaceEncoder replaces the data of every data field with data.
+type replaceEncoder struct {
+	control.Encoder
+
+	data []byte
+}
+
+func (re *replaceEncoder) Data([]byte) (err error) {
+	return re.Encoder.Data(re.data)
+}
+
+func (re *replaceEncoder) Symmetric(fn func(control.Encoder) error) (err error) {
+	return re.Encoder.Symmetric(func(e control.Encoder) error {
+		return fn(&replaceEncoder{e, re.data})
+	})
+}
@@ -0,0 +1,754 @@
+package control
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math/big"
+
+	"github.com/calebcase/oops"
+)
+
+// reverseDecoder reads fields from the end of the input towards the start.
+// Only symmetric fields (Data, Empty, Null, Container Unbounded and fields
+// embedded in Container Symmetric blocks) can be read in reverse. Fields the
+// symmetric encoder writes with repeated control blocks, but without a
+// Container Symmetric block, are read as if they had one.
+//
+// The pairing of unbounded containers is inverted when reading in reverse.
+// The Container End block that begins an unbounded container is reported as
+// ContainerUnbounded and the Container Unbounded block that closes it is
+// reported as ContainerEnd. This allows the same processing to be used for
+// both directions.
+type reverseDecoder struct {
+	r io.ReaderAt
+
+	// pos is the offset of the last byte read. Reading proceeds towards
+	// the start of the input.
+	pos int64
+
+	// offset and parent are the consumed bytes and scope of the parent
+	// decoder when decoding the size of a bounded container.
+	offset uint64
+	parent *scope
+
+	consumed uint64
+
+	stack *Stack
+
+	value    [1]byte
+	t        Type
+	finished bool
+
+	size     uint64
+	data     []byte
+	streamed bool
+	amount   uint64
+
+	err error
+}
+
+// NewReverseDecoder returns a decoder that reads the fields in r from the end
+// (at size) backwards.
+func NewReverseDecoder(r io.ReaderAt, size int64) Decoder {
+	return &reverseDecoder{
+		r:     r,
+		pos:   size,
+		stack: &Stack{},
+	}
+}
+
+// read returns the size bytes preceding the current position and moves the
+// position to the start of them.
+func (d *reverseDecoder) read(size uint64) (data []byte, err error) {
+	if uint64(d.pos) < size {
+		return nil, Error.Trace(io.ErrUnexpectedEOF)
+	}
+
+	data = make([]byte, size)
+
+	_, err = d.r.ReadAt(data, d.pos-int64(size))
+	if err != nil {
+		return nil, Error.Trace(err)
+	}
+
+	d.pos -= int64(size)
+	d.consumed += size
+
+	err = d.stack.Consume(size)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// seek moves the position size bytes towards the start of the input.
+func (d *reverseDecoder) seek(size uint64) (err error) {
+	if uint64(d.pos) < size {
+		return Error.Trace(io.ErrUnexpectedEOF)
+	}
+
+	d.pos -= int64(size)
+	d.consumed += size
+
+	err = d.stack.Consume(size)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// leading skips over the leading control blocks of any symmetric fields that
+// have been fully read.
+func (d *reverseDecoder) leading() (err error) {
+	for {
+		top := d.stack.Top()
+		if top == nil ||
+			top.Type != ContainerSymmetric ||
+			top.Subtype == Unknown {
+
+			return nil
+		}
+
+		err = d.seek(top.Count)
+		if err != nil {
+			return err
+		}
+
+		err = d.stack.Pop()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Seek moves the reading position to the start of the current field.
+func (d *reverseDecoder) Seek() (err error) {
+	defer func() {
+		if err != nil {
+			err = d.locate(err)
+			d.err = err
+		}
+	}()
+
+	if d.consumed == 0 {
+		return nil
+	}
+
+	switch d.t {
+	case Data:
+		// No additional bytes need to be read.
+	case DataSize, DataSizeSize:
+		size, err := d.Size()
+		if err != nil {
+			return err
+		}
+
+		// Seek past the data if we haven't read it yet.
+		if len(d.data) == 0 && !d.streamed {
+			err = d.seek(size)
+			if err != nil {
+				return err
+			}
+		}
+	case Data1, Data2:
+		// Small enough to just read directly.
+		_, err := d.Data()
+		if err != nil {
+			return err
+		}
+	case ContainerSymmetric:
+		if !d.finished {
+			d.finished = true
+
+			// Move to the embedded field.
+			ok := d.Next()
+			if d.Err() != nil {
+				return d.Err()
+			}
+			if !ok {
+				return Error.New("symmetric container empty")
+			}
+
+			// Seek past the embedded field.
+			err = d.Seek()
+			if err != nil {
+				return err
+			}
+		}
+	case ContainerBounded:
+		size, err := d.Size()
+		if err != nil {
+			return err
+		}
+
+		// Seek past the bsv if we haven't read it yet.
+		if len(d.data) == 0 {
+			err = d.seek(size)
+			if err != nil {
+				return err
+			}
+		}
+	case ContainerUnbounded:
+		// Read tokens until the matching ContainerEnd is found. Depth
+		// will be one less than our current.
+		target := d.Depth() - 1
+		d.finished = true
+
+		for d.Next() {
+			t := d.Type()
+			if t == ContainerEnd && target == d.Depth() {
+				break
+			}
+		}
+		err = d.Err()
+		if err != nil {
+			return err
+		}
+	case ContainerEnd:
+		// No additional bytes need to be read.
+	case SkipSize:
+		_, err := d.Amount()
+		if err != nil {
+			return err
+		}
+	case Empty:
+		// No additional bytes need to be read.
+	case Null:
+		// No additional bytes need to be read.
+	default:
+		return Error.New("unknown field %q: %0b", d.t.Abbr, d.value)
+	}
+
+	// Skip over leading symmetric control blocks.
+	err = d.leading()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// locate adds the current position to decoding errors.
+func (d *reverseDecoder) locate(err error) error {
+	return locate(err, d.offset+d.consumed, d.value[0], d.parent, *d.stack)
+}
+
+func (d *reverseDecoder) Next() (ok bool) {
+	ok = d.next()
+	if d.err != nil {
+		d.err = d.locate(d.err)
+	}
+
+	return ok
+}
+
+func (d *reverseDecoder) next() (ok bool) {
+	// Ensure current field was fully read before moving on...
+	if !d.finished {
+		d.err = d.Seek()
+		if d.err != nil {
+			return false
+		}
+	} else {
+		d.err = d.leading()
+		if d.err != nil {
+			return false
+		}
+	}
+
+	// Reset state for next field.
+	d.value[0] = 0
+	d.t = Unknown
+
+	d.size = 0
+	d.data = d.data[:0]
+	d.streamed = false
+	d.amount = 0
+	d.finished = false
+
+	if d.pos == 0 {
+		if d.Depth() != 0 {
+			d.err = Error.New("unexpected start of input: depth=%d: %w", d.Depth(), io.ErrUnexpectedEOF)
+		}
+
+		return false
+	}
+
+	// Read the field control block.
+	value, err := d.read(1)
+	if err != nil {
+		d.err = err
+
+		return false
+	}
+
+	d.value[0] = value[0]
+
+	t, ok := Types.Match(d.value[0])
+	if !ok {
+		d.err = oops.New("%w: %0b", ErrUnexpectedByte, d.value[0])
+
+		return false
+	}
+
+	top := d.stack.Top()
+	embedded := top != nil &&
+		top.Type == ContainerSymmetric &&
+		top.Subtype == Unknown
+
+	switch t {
+	case Data, Empty, Null:
+		// These are single byte fields that don't add to the symmetric
+		// control block count and are fully read.
+		d.finished = true
+	case ContainerEnd:
+		// In reverse the end block begins the unbounded container.
+		t = ContainerUnbounded
+
+		d.stack.Push(&Frame{
+			Type: t,
+		})
+	case ContainerUnbounded:
+		// In reverse the unbounded block ends the container.
+		t = ContainerEnd
+
+		if top == nil {
+			d.err = oops.New("%w (not in a container)", ErrUnpairedEnd)
+
+			return false
+		}
+
+		if top.Type != ContainerUnbounded {
+			d.err = oops.New(
+				"%w (container not unbounded): %s",
+				ErrUnpairedEnd,
+				top.Type.Abbr,
+			)
+
+			return false
+		}
+
+		d.err = d.stack.Pop()
+		if d.err != nil {
+			return false
+		}
+
+		d.finished = true
+	case ContainerSymmetric:
+		d.stack.Push(&Frame{
+			Type:  t,
+			Count: 1,
+		})
+	default:
+		// The remaining blocks are only readable in reverse when
+		// embedded in a symmetric container. The symmetric encoder
+		// writes single byte data and the field of an unbounded
+		// container without one, so those are read as if they were.
+		if !embedded {
+			top = &Frame{
+				Type: ContainerSymmetric,
+			}
+			embedded = true
+
+			d.stack.Push(top)
+		}
+
+		d.stack.Count(1)
+
+		if t == ContainerBounded {
+			d.stack.Push(&Frame{
+				Type: t,
+			})
+		}
+	}
+
+	if embedded {
+		top.Subtype = t
+	}
+
+	d.t = t
+
+	return true
+}
+
+func (d *reverseDecoder) Err() error {
+	return d.err
+}
+
+func (d *reverseDecoder) Type() Type {
+	return d.t
+}
+
+func (d *reverseDecoder) Depth() int {
+	return len(*d.stack)
+}
+
+func (d *reverseDecoder) Stack() Stack {
+	return *d.stack
+}
+
+func (d *reverseDecoder) Consumed() uint64 {
+	return d.consumed
+}
+
+func (d *reverseDecoder) Size() (_ uint64, err error) {
+	defer func() {
+		if err != nil {
+			d.size = 0
+			err = d.locate(err)
+			d.err = err
+		}
+	}()
+
+	if d.size != 0 {
+		return d.size, nil
+	}
+
+	switch d.t {
+	case Data:
+		d.size = 1
+	case DataSize:
+		d.size = uint64(d.value[0]&d.t.Mask) + 1
+	case Data1:
+		d.size = 2
+	case Data2:
+		d.size = 3
+	case DataSizeSize:
+		sizeSize := uint64(d.value[0]&d.t.Mask) + 1
+
+		sizeBytes, err := d.read(sizeSize)
+		if err != nil {
+			return 0, err
+		}
+
+		d.stack.Count(sizeSize)
+
+		size := new(big.Int).SetBytes(sizeBytes)
+		size.Add(size, big.NewInt(1))
+		if !size.IsUint64() {
+			return 0, Error.New("unimplemented: size >= 2^64")
+		}
+
+		d.size = size.Uint64()
+	case ContainerBounded:
+		zd := &reverseDecoder{
+			r:      d.r,
+			pos:    d.pos,
+			offset: d.offset + d.consumed,
+			parent: &scope{d.parent, d.stack},
+			stack:  &Stack{},
+		}
+
+		ok := zd.Next()
+		if zd.Err() != nil {
+			return 0, zd.Err()
+		}
+		if !ok {
+			return 0, Error.New("unabled to read container bounded size: %w", io.ErrUnexpectedEOF)
+		}
+
+		if zd.Type() == ContainerSymmetric {
+			err = zd.Enter()
+			if err != nil {
+				return 0, err
+			}
+
+			ok := zd.Next()
+			if zd.Err() != nil {
+				return 0, zd.Err()
+			}
+			if !ok {
+				return 0, Error.New("unabled to read container bounded size: %w", io.ErrUnexpectedEOF)
+			}
+		}
+
+		sizeBytes, err := zd.Data()
+		if err != nil {
+			return 0, err
+		}
+
+		err = d.seek(zd.Consumed())
+		if err != nil {
+			return 0, err
+		}
+		d.stack.Count(zd.Consumed())
+
+		size := new(big.Int).SetBytes(sizeBytes)
+		size.Add(size, big.NewInt(1))
+		if !size.IsUint64() {
+			return 0, Error.New("unimplemented: size >= 2^64")
+		}
+
+		d.size = size.Uint64()
+
+		top := d.stack.Top()
+		top.Size = d.size
+		top.Remaining = d.size
+
+		d.finished = true
+	case SkipSize:
+		d.size = uint64(d.value[0]&d.t.Mask) + 1
+	default:
+		return 0, oops.Trace(ErrInvalidOperation)
+	}
+
+	return d.size, nil
+}
+
+// Data reads data bits and bytes from the field. If the field does not contain
+// data it returns nil and ErrInvalidOperation.
+func (d *reverseDecoder) Data() (data []byte, err error) {
+	defer func() {
+		if err != nil {
+			d.data = d.data[:0]
+			err = d.locate(err)
+			d.err = err
+		}
+	}()
+
+	if d.t != Data && d.t != Data1 && d.t != Data2 && d.t != DataSize && d.t != DataSizeSize {
+		return nil, oops.Trace(ErrInvalidOperation)
+	}
+
+	if len(d.data) != 0 {
+		return d.data, nil
+	}
+
+	// The data was already read by DataReader.
+	if d.streamed {
+		return nil, oops.Trace(ErrInvalidOperation)
+	}
+
+	_, err = d.Size()
+	if err != nil {
+		return d.data[:0], err
+	}
+
+	switch d.t {
+	case Data:
+		d.data = []byte{d.value[0] & d.t.Mask}
+	case DataSize, DataSizeSize:
+		d.data, err = d.read(d.size)
+		if err != nil {
+			return nil, err
+		}
+
+		d.finished = true
+	case Data1, Data2:
+		// The data bits in the control block are the most significant
+		// and the data bytes precede the control block.
+		tail, err := d.read(d.size - 1)
+		if err != nil {
+			return nil, err
+		}
+
+		d.data = append([]byte{d.value[0] & d.t.Mask}, tail...)
+
+		d.finished = true
+	}
+
+	// Skip over leading symmetric control blocks.
+	err = d.leading()
+	if err != nil {
+		return nil, err
+	}
+
+	return d.data, nil
+}
+
+// DataReader returns a reader over the field's data and the data size. The
+// position is moved before the data immediately and the returned reader reads
+// the data in forward order. If the field does not contain data it returns nil
+// and ErrInvalidOperation.
+func (d *reverseDecoder) DataReader() (r io.Reader, size uint64, err error) {
+	defer func() {
+		if err != nil {
+			err = d.locate(err)
+			d.err = err
+		}
+	}()
+
+	switch d.t {
+	case Data, Data1, Data2:
+		data, err := d.Data()
+		if err != nil {
+			return nil, 0, err
+		}
+
+		return bytes.NewReader(data), uint64(len(data)), nil
+	case DataSize, DataSizeSize:
+	default:
+		return nil, 0, oops.Trace(ErrInvalidOperation)
+	}
+
+	if len(d.data) != 0 || d.streamed {
+		return nil, 0, oops.Trace(ErrInvalidOperation)
+	}
+
+	size, err = d.Size()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = d.seek(size)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	d.streamed = true
+
+	return io.NewSectionReader(d.r, d.pos, int64(size)), size, nil
+}
+
+// Enter informs decoder that the ContainerSymmetric, ContainerBounded or
+// ContainerUnbounded field should be entered. If the current field type is not
+// a container, then it returns ErrInvalidOperation.
+func (d *reverseDecoder) Enter() (err error) {
+	defer func() {
+		if err != nil {
+			err = d.locate(err)
+			d.err = err
+		}
+	}()
+
+	switch d.t {
+	case ContainerSymmetric:
+		d.finished = true
+	case ContainerBounded:
+		_, err := d.Size()
+		if err != nil {
+			return err
+		}
+	case ContainerUnbounded:
+		d.finished = true
+	default:
+		return oops.Trace(ErrInvalidOperation)
+	}
+
+	return nil
+}
+
+// BSV returns the embedded BSV in forward order. If the current field type is
+// not ContainerBounded, then it returns nil and ErrInvalidOperation.
+func (d *reverseDecoder) BSV() (bsv []byte, err error) {
+	defer func() {
+		if err != nil {
+			d.data = d.data[:0]
+			err = d.locate(err)
+			d.err = err
+		}
+	}()
+
+	if d.t != ContainerBounded {
+		return nil, oops.Trace(ErrInvalidOperation)
+	}
+
+	err = d.Enter()
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := d.Size()
+	if err != nil {
+		return nil, err
+	}
+
+	d.data, err = d.read(size)
+	if err != nil {
+		return nil, err
+	}
+
+	// Skip over leading symmetric control blocks.
+	err = d.leading()
+	if err != nil {
+		return nil, err
+	}
+
+	return d.data, nil
+}
+
+// Amount returns the skip amount. If the current field type is not SkipSize,
+// then it returns 0 and ErrInvalidOperation.
+func (d *reverseDecoder) Amount() (_ uint64, err error) {
+	defer func() {
+		if err != nil {
+			d.amount = 0
+			err = d.locate(err)
+			d.err = err
+		}
+	}()
+
+	if d.t != SkipSize {
+		return 0, oops.Trace(ErrInvalidOperation)
+	}
+
+	if d.amount != 0 {
+		return d.amount, nil
+	}
+
+	_, err = d.Size()
+	if err != nil {
+		return 0, err
+	}
+
+	amountBytes, err := d.read(d.size)
+	if err != nil {
+		return 0, err
+	}
+
+	padSize := 8 - len(amountBytes)
+	padBytes := make([]byte, padSize)
+
+	amountBytes = append(padBytes, amountBytes...)
+
+	d.amount = binary.BigEndian.Uint64(amountBytes) + 1
+
+	d.finished = true
+
+	// Skip over leading symmetric control blocks.
+	err = d.leading()
+	if err != nil {
+		return 0, err
+	}
+
+	return d.amount, nil
+}
+
+// SeekToField moves the reading position to the end of field i in the index
+// so the next call to Next reads it (in reverse). The stack is reset so the
+// field is read as if it were at the top level.
+func (d *reverseDecoder) SeekToField(idx Index, i int) (err error) {
+	defer func() {
+		if err != nil {
+			err = d.locate(err)
+			d.err = err
+		}
+	}()
+
+	entry, err := checkField(idx, i)
+	if err != nil {
+		return err
+	}
+
+	end := int64(entry.Offset + entry.Length)
+
+	d.consumed = uint64(int64(d.consumed) + d.pos - end)
+	d.pos = end
+	d.stack = &Stack{}
+
+	d.value[0] = 0
+	d.t = Unknown
+	d.finished = true
+
+	d.size = 0
+	d.data = d.data[:0]
+	d.streamed = false
+	d.amount = 0
+
+	d.err = nil
+
+	return nil
+}
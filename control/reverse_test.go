@@ -0,0 +1,332 @@
+package control_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calebcase/bsv/control"
+	"github.com/calebcase/oops"
+)
+
+type field struct {
+	Type   control.Type
+	Data   []byte
+	BSV    []byte
+	Amount uint64
+}
+
+// walk reads all the fields from the decoder entering any symmetric and
+// unbounded containers.
+func walk(t *testing.T, d control.Decoder, mark error) (fields []field) {
+	for d.Next() {
+		f := field{
+			Type: d.Type(),
+		}
+
+		switch d.Type() {
+		case control.Data,
+			control.Data1,
+			control.Data2,
+			control.DataSize,
+			control.DataSizeSize:
+
+			data, err := d.Data()
+			require.NoError(t, err, mark)
+
+			f.Data = append([]byte{}, data...)
+		case control.ContainerBounded:
+			bsv, err := d.BSV()
+			require.NoError(t, err, mark)
+
+			f.BSV = append([]byte{}, bsv...)
+		case control.ContainerUnbounded,
+			control.ContainerSymmetric:
+
+			err := d.Enter()
+			require.NoError(t, err, mark)
+		case control.SkipSize:
+			amount, err := d.Amount()
+			require.NoError(t, err, mark)
+
+			f.Amount = amount
+		}
+
+		fields = append(fields, f)
+	}
+	require.NoError(t, d.Err(), mark)
+	require.Equal(t, 0, d.Depth(), mark)
+
+	return fields
+}
+
+func TestReverseDecoder(t *testing.T) {
+	type TC struct {
+		Fn     func(control.Encoder) error
+		Fields []field
+
+		// Bare is true if the symmetric encoder writes the field's
+		// trailing control blocks without a Container Symmetric block.
+		// These are only readable in reverse.
+		Bare bool
+
+		Mark error
+	}
+
+	tcs := []TC{
+		{
+			Fn: func(e control.Encoder) (err error) {
+				return e.Data([]byte{0b_0000_0001})
+			},
+			Fields: []field{
+				{Type: control.Data, Data: []byte{0b_0000_0001}},
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Fn: func(e control.Encoder) (err error) {
+				return e.Data([]byte{0b_1000_0001})
+			},
+			Fields: []field{
+				{Type: control.DataSize, Data: []byte{0b_1000_0001}},
+			},
+			Bare: true,
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Fn: func(e control.Encoder) (err error) {
+				return e.Data([]byte{0b_0000_0001, 0b_0000_0010})
+			},
+			Fields: []field{
+				{Type: control.ContainerSymmetric},
+				{Type: control.Data1, Data: []byte{0b_0000_0001, 0b_0000_0010}},
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Fn: func(e control.Encoder) (err error) {
+				return e.Data([]byte{0b_0000_0001, 0b_0000_0010, 0b_0000_0011})
+			},
+			Fields: []field{
+				{Type: control.ContainerSymmetric},
+				{Type: control.Data2, Data: []byte{0b_0000_0001, 0b_0000_0010, 0b_0000_0011}},
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Fn: func(e control.Encoder) (err error) {
+				return e.Data([]byte{1, 2, 3, 4})
+			},
+			Fields: []field{
+				{Type: control.ContainerSymmetric},
+				{Type: control.DataSize, Data: []byte{1, 2, 3, 4}},
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Fn: func(e control.Encoder) (err error) {
+				return e.Data(bytes.Repeat([]byte{1}, 1024))
+			},
+			Fields: []field{
+				{Type: control.ContainerSymmetric},
+				{Type: control.DataSizeSize, Data: bytes.Repeat([]byte{1}, 1024)},
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Fn: func(e control.Encoder) (err error) {
+				return e.Bound([]byte{0b_1000_0001})
+			},
+			Fields: []field{
+				{Type: control.ContainerSymmetric},
+				{Type: control.ContainerBounded, BSV: []byte{0b_1000_0001}},
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Fn: func(e control.Encoder) (err error) {
+				return e.Bound(bytes.Repeat([]byte{0b_1000_0001}, 200))
+			},
+			Fields: []field{
+				{Type: control.ContainerSymmetric},
+				{Type: control.ContainerBounded, BSV: bytes.Repeat([]byte{0b_1000_0001}, 200)},
+			},
+			Bare: true,
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Fn: func(e control.Encoder) (err error) {
+				return e.Bound(bytes.Repeat([]byte{0b_1000_0001}, 1024))
+			},
+			Fields: []field{
+				{Type: control.ContainerSymmetric},
+				{Type: control.ContainerBounded, BSV: bytes.Repeat([]byte{0b_1000_0001}, 1024)},
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Fn: func(e control.Encoder) (err error) {
+				return e.Skip(16)
+			},
+			Fields: []field{
+				{Type: control.ContainerSymmetric},
+				{Type: control.SkipSize, Amount: 16},
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Fn: func(e control.Encoder) (err error) {
+				return e.Skip(512)
+			},
+			Fields: []field{
+				{Type: control.ContainerSymmetric},
+				{Type: control.SkipSize, Amount: 512},
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Fn: func(e control.Encoder) (err error) {
+				return e.Empty()
+			},
+			Fields: []field{
+				{Type: control.Empty},
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Fn: func(e control.Encoder) (err error) {
+				return e.Null()
+			},
+			Fields: []field{
+				{Type: control.Null},
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Fn: func(e control.Encoder) (err error) {
+				return e.Symmetric(func(e control.Encoder) (err error) {
+					return e.Data([]byte{1, 2, 3, 4})
+				})
+			},
+			Fields: []field{
+				{Type: control.ContainerSymmetric},
+				{Type: control.ContainerSymmetric},
+				{Type: control.DataSize, Data: []byte{1, 2, 3, 4}},
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Fn: func(e control.Encoder) (err error) {
+				return e.Unbound(func(e control.Encoder) (err error) {
+					return e.Unbound(func(e control.Encoder) (err error) {
+						return e.Empty()
+					})
+				})
+			},
+			Fields: []field{
+				{Type: control.ContainerUnbounded},
+				{Type: control.ContainerUnbounded},
+				{Type: control.Empty},
+				{Type: control.ContainerEnd},
+				{Type: control.ContainerEnd},
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Fn: func(e control.Encoder) (err error) {
+				return e.Unbound(func(e control.Encoder) (err error) {
+					return e.Data([]byte{0b_0000_0001, 0b_0000_0010})
+				})
+			},
+			Fields: []field{
+				{Type: control.ContainerUnbounded},
+				{Type: control.Data1, Data: []byte{0b_0000_0001, 0b_0000_0010}},
+				{Type: control.ContainerEnd},
+			},
+			Bare: true,
+			Mark: oops.New("unexpected"),
+		},
+	}
+
+	for i, tc := range tcs {
+		output := &bytes.Buffer{}
+		e := control.NewEncoder(output)
+
+		err := e.Symmetric(tc.Fn)
+		require.NoError(t, err, tc.Mark)
+
+		t.Run(shortName(i, output.Bytes()), func(t *testing.T) {
+			input := bytes.NewReader(output.Bytes())
+			d := control.NewReverseDecoder(input, input.Size())
+
+			fields := walk(t, d, tc.Mark)
+			require.Equal(t, tc.Fields, fields, tc.Mark)
+			require.Equal(t, uint64(output.Len()), d.Consumed(), tc.Mark)
+
+			if tc.Bare {
+				return
+			}
+
+			// The same input must also be readable forwards.
+			d = control.NewDecoder(bytes.NewReader(output.Bytes()))
+
+			fields = walk(t, d, tc.Mark)
+			require.Equal(t, len(tc.Fields), len(fields), tc.Mark)
+			require.Equal(t, uint64(output.Len()), d.Consumed(), tc.Mark)
+		})
+	}
+
+	t.Run("sequence", func(t *testing.T) {
+		output := &bytes.Buffer{}
+		e := control.NewEncoder(output)
+
+		for _, tc := range tcs {
+			if tc.Bare {
+				continue
+			}
+
+			err := e.Symmetric(tc.Fn)
+			require.NoError(t, err, tc.Mark)
+		}
+
+		forward := walk(t, control.NewDecoder(bytes.NewReader(output.Bytes())), nil)
+
+		input := bytes.NewReader(output.Bytes())
+		reverse := walk(t, control.NewReverseDecoder(input, input.Size()), nil)
+
+		// Reading in reverse should produce the same fields with symmetric
+		// containers leading their embedded field.
+		expected := []field{}
+		for _, tc := range tcs {
+			if tc.Bare {
+				continue
+			}
+
+			expected = append(tc.Fields, expected...)
+		}
+		require.Equal(t, expected, reverse)
+		require.Equal(t, len(forward), len(reverse))
+	})
+
+	t.Run("not symmetric", func(t *testing.T) {
+		output := &bytes.Buffer{}
+		e := control.NewEncoder(output)
+
+		err := e.Data([]byte{0b_0100_0001, 0b_0100_0001})
+		require.NoError(t, err)
+
+		input := bytes.NewReader(output.Bytes())
+		d := control.NewReverseDecoder(input, input.Size())
+
+		// The trailing block is taken as a symmetric field without a
+		// Container Symmetric block, but its leading block is missing.
+		for d.Next() {
+			_, err = d.Data()
+			if err != nil {
+				break
+			}
+		}
+		require.Error(t, d.Err())
+	})
+}
@@ -2,6 +2,9 @@ package control_test
 
 import (
 	"bytes"
+	"fmt"
+	"io"
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -170,6 +173,284 @@ func TestRoundtrip(t *testing.T) {
 		}
 	})
 
+	t.Run("bound func", func(t *testing.T) {
+		type TC struct {
+			Fn        func(control.Encoder) error
+			Symmetric bool
+			Fields    []field
+			Mark      error
+		}
+
+		tcs := []TC{
+			{
+				Fn: func(e control.Encoder) (err error) {
+					err = e.Data([]byte{1, 2, 3, 4})
+					if err != nil {
+						return err
+					}
+
+					return e.BoundFunc(func(e control.Encoder) (err error) {
+						return e.Data([]byte{0b_0000_0001})
+					})
+				},
+				Fields: []field{
+					{Type: control.ContainerBounded},
+					{Type: control.DataSize, Data: []byte{1, 2, 3, 4}},
+					{Type: control.ContainerBounded},
+					{Type: control.Data, Data: []byte{0b_0000_0001}},
+				},
+				Mark: oops.New("unexpected"),
+			},
+			{
+				Fn: func(e control.Encoder) (err error) {
+					return e.Data([]byte{1, 2, 3, 4})
+				},
+				Symmetric: true,
+				Fields: []field{
+					{Type: control.ContainerSymmetric},
+					{Type: control.ContainerBounded},
+					{Type: control.DataSize, Data: []byte{1, 2, 3, 4}},
+				},
+				Mark: oops.New("unexpected"),
+			},
+		}
+
+		// enter reads all fields entering bounded containers.
+		enter := func(t *testing.T, d control.Decoder, mark error) (fields []field) {
+			for d.Next() {
+				f := field{
+					Type: d.Type(),
+				}
+
+				switch d.Type() {
+				case control.Data, control.DataSize:
+					data, err := d.Data()
+					require.NoError(t, err, mark)
+
+					f.Data = append([]byte{}, data...)
+				case control.ContainerBounded, control.ContainerSymmetric:
+					err := d.Enter()
+					require.NoError(t, err, mark)
+				}
+
+				fields = append(fields, f)
+			}
+			require.NoError(t, d.Err(), mark)
+			require.Equal(t, 0, d.Depth(), mark)
+
+			return fields
+		}
+
+		for i, tc := range tcs {
+			t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+				var err error
+
+				buffered := &bytes.Buffer{}
+				seeker := &seekBuffer{}
+
+				for _, w := range []io.Writer{buffered, seeker} {
+					e := control.NewEncoder(w)
+
+					if tc.Symmetric {
+						err = e.Symmetric(func(e control.Encoder) (err error) {
+							return e.BoundFunc(tc.Fn)
+						})
+					} else {
+						err = e.BoundFunc(tc.Fn)
+					}
+					require.NoError(t, err, tc.Mark)
+				}
+
+				for _, output := range [][]byte{buffered.Bytes(), seeker.Bytes()} {
+					t.Logf("output=%x", output)
+
+					d := control.NewDecoder(bytes.NewReader(output))
+					require.Equal(t, tc.Fields, enter(t, d, tc.Mark), tc.Mark)
+
+					if tc.Symmetric {
+						forward := walk(t, control.NewDecoder(bytes.NewReader(output)), tc.Mark)
+
+						input := bytes.NewReader(output)
+						reverse := walk(t, control.NewReverseDecoder(input, input.Size()), tc.Mark)
+						require.Equal(t, forward, reverse, tc.Mark)
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("data from", func(t *testing.T) {
+		payload := make([]byte, 100_000)
+		for i := range payload {
+			payload[i] = byte(i)
+		}
+
+		output := &bytes.Buffer{}
+		e := control.NewEncoder(output)
+
+		err := e.DataFrom(bytes.NewReader(payload), uint64(len(payload)))
+		require.NoError(t, err)
+
+		err = e.Symmetric(func(e control.Encoder) (err error) {
+			return e.DataFrom(bytes.NewReader(payload), uint64(len(payload)))
+		})
+		require.NoError(t, err)
+
+		err = e.Data([]byte{0b_0000_0001})
+		require.NoError(t, err)
+
+		decoders := map[string]func() control.Decoder{
+			"reader": func() control.Decoder {
+				return control.NewDecoder(bytes.NewBuffer(output.Bytes()))
+			},
+			"seeker": func() control.Decoder {
+				return control.NewDecoder(bytes.NewReader(output.Bytes()))
+			},
+			"bytes": func() control.Decoder {
+				return control.NewBytesDecoder(output.Bytes())
+			},
+		}
+
+		for name, fn := range decoders {
+			t.Run(name, func(t *testing.T) {
+				d := fn()
+
+				// Read the full payload.
+				require.True(t, d.Next())
+				require.Equal(t, control.DataSizeSize, d.Type())
+
+				r, size, err := d.DataReader()
+				require.NoError(t, err)
+				require.Equal(t, uint64(len(payload)), size)
+
+				data, err := io.ReadAll(r)
+				require.NoError(t, err)
+				require.Equal(t, payload, data)
+
+				// Read part of the symmetric payload.
+				require.True(t, d.Next())
+				require.Equal(t, control.ContainerSymmetric, d.Type())
+				require.NoError(t, d.Enter())
+
+				require.True(t, d.Next())
+				require.Equal(t, control.DataSizeSize, d.Type())
+
+				r, _, err = d.DataReader()
+				require.NoError(t, err)
+
+				part := make([]byte, 10)
+				_, err = io.ReadFull(r, part)
+				require.NoError(t, err)
+				require.Equal(t, payload[:10], part)
+
+				// The rest is skipped.
+				require.True(t, d.Next())
+				require.Equal(t, control.Data, d.Type())
+				require.Equal(t, 0, d.Depth())
+
+				require.False(t, d.Next())
+				require.NoError(t, d.Err())
+				require.Equal(t, uint64(output.Len()), d.Consumed())
+			})
+		}
+
+		t.Run("reverse", func(t *testing.T) {
+			input := bytes.NewReader(output.Bytes())
+			d := control.NewReverseDecoder(input, input.Size())
+
+			require.True(t, d.Next())
+			require.Equal(t, control.Data, d.Type())
+
+			require.True(t, d.Next())
+			require.Equal(t, control.ContainerSymmetric, d.Type())
+			require.NoError(t, d.Enter())
+
+			require.True(t, d.Next())
+			require.Equal(t, control.DataSizeSize, d.Type())
+
+			r, size, err := d.DataReader()
+			require.NoError(t, err)
+			require.Equal(t, uint64(len(payload)), size)
+
+			data, err := io.ReadAll(r)
+			require.NoError(t, err)
+			require.Equal(t, payload, data)
+			require.Equal(t, uint64(1+1+1+3+len(payload)), d.Consumed())
+		})
+
+		t.Run("short", func(t *testing.T) {
+			e := control.NewEncoder(&bytes.Buffer{})
+
+			err := e.DataFrom(bytes.NewReader(payload[:10]), 11)
+			require.Error(t, err)
+		})
+
+		t.Run("too large", func(t *testing.T) {
+			output := &bytes.Buffer{}
+			e := control.NewEncoder(output)
+
+			err := e.DataFrom(bytes.NewReader(payload), math.MaxUint64)
+			require.Error(t, err)
+			require.Equal(t, 0, output.Len())
+		})
+
+		t.Run("truncated", func(t *testing.T) {
+			d := control.NewDecoder(bytes.NewBuffer(output.Bytes()[:1000]))
+
+			require.True(t, d.Next())
+
+			r, _, err := d.DataReader()
+			require.NoError(t, err)
+
+			_, err = io.ReadAll(r)
+			require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+		})
+
+		t.Run("exceeds bounded", func(t *testing.T) {
+			// A 4 byte field embedded in a 2 byte bounded container.
+			input := []byte{
+				0b_0000_0101, 0b_1000_0001,
+				0b_0100_0011, 0x01, 0x02, 0x03, 0x04,
+				0b_1000_0001,
+			}
+
+			decoders := map[string]func() control.Decoder{
+				"reader": func() control.Decoder {
+					return control.NewDecoder(bytes.NewBuffer(input))
+				},
+				"seeker": func() control.Decoder {
+					return control.NewDecoder(bytes.NewReader(input))
+				},
+				"bytes": func() control.Decoder {
+					return control.NewBytesDecoder(input)
+				},
+			}
+
+			for name, fn := range decoders {
+				t.Run(name, func(t *testing.T) {
+					d := fn()
+
+					require.True(t, d.Next())
+					require.NoError(t, d.Enter())
+
+					require.True(t, d.Next())
+					require.Equal(t, control.DataSize, d.Type())
+
+					// None of the data past the container is read.
+					r, _, err := d.DataReader()
+					if err == nil {
+						_, err = io.ReadFull(r, make([]byte, 4))
+					}
+					require.Error(t, err)
+					require.Error(t, d.Err())
+
+					require.False(t, d.Next())
+					require.Error(t, d.Err())
+				})
+			}
+		})
+	})
+
 	t.Run("unbound", func(t *testing.T) {
 		type TC struct {
 			Input  []byte
@@ -467,6 +748,30 @@ func TestRoundtrip(t *testing.T) {
 				},
 				Mark: oops.New("unexpected"),
 			},
+			{
+				Fn: func(e control.Encoder) (err error) {
+					return e.Bound(make([]byte, 129))
+				},
+				Input: make([]byte, 129),
+				Output: append(
+					append(
+						[]byte{
+							0b_0000_0111,
+							0b_0000_0101,
+							0b_0100_0000,
+							0b_1000_0000,
+							0b_0100_0000,
+						},
+						make([]byte, 129)...,
+					),
+					0b_0100_0000,
+					0b_1000_0000,
+					0b_0100_0000,
+					0b_0000_0101,
+					0b_0000_0111,
+				),
+				Mark: oops.New("unexpected"),
+			},
 			// Unbound
 			{
 				Fn: func(e control.Encoder) (err error) {
@@ -0,0 +1,62 @@
+package control
+
+import (
+	"errors"
+	"io"
+)
+
+// ScanFields is a split function for a bufio.Scanner that returns each top
+// level field as a token. The token includes everything embedded in the field,
+// so containers are returned whole. Sizes and container pairing are decoded
+// the same way as by the decoders.
+func ScanFields(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	n, err := fieldLen(data)
+	if err == nil {
+		return n, data[:n], nil
+	}
+
+	if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return 0, nil, err
+	}
+
+	if atEOF {
+		return 0, nil, Error.Trace(io.ErrUnexpectedEOF)
+	}
+
+	// Request more data.
+	return 0, nil, nil
+}
+
+// fieldLen returns the length of the first field in data. If the field isn't
+// complete the error wraps io.EOF or io.ErrUnexpectedEOF.
+func fieldLen(data []byte) (n int, err error) {
+	d := NewBytesDecoder(data)
+
+	if !d.Next() {
+		if d.Err() != nil {
+			return 0, d.Err()
+		}
+
+		return 0, Error.Trace(io.EOF)
+	}
+
+	if d.Type() == ContainerEnd {
+		return 0, Error.New("unexpected container end")
+	}
+
+	err = d.Seek()
+	if err != nil {
+		return 0, err
+	}
+
+	// An unbounded container without its end leaves its frame behind.
+	if d.Depth() != 0 {
+		return 0, Error.Trace(io.ErrUnexpectedEOF)
+	}
+
+	return int(d.Consumed()), nil
+}
@@ -0,0 +1,91 @@
+package control_test
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calebcase/bsv/control"
+	"github.com/calebcase/oops"
+)
+
+func TestScanFields(t *testing.T) {
+	mark := oops.New("unexpected")
+
+	bsv := indexFixture(t)
+
+	buf := bytes.NewBuffer(append([]byte{}, bsv...))
+	e := control.NewEncoder(buf)
+
+	require.NoError(t, e.Skip(300), mark)
+	require.NoError(t, e.Symmetric(func(se control.Encoder) error {
+		return se.Bound(bytes.Repeat([]byte{0b_1000_0001}, 200))
+	}), mark)
+	require.NoError(t, e.Symmetric(func(se control.Encoder) error {
+		return se.Data(bytes.Repeat([]byte{0xff}, 70))
+	}), mark)
+
+	bsv = buf.Bytes()
+
+	idx, err := control.BuildIndex(bytes.NewReader(bsv))
+	require.NoError(t, err, mark)
+
+	var expected [][]byte
+	for _, entry := range idx {
+		expected = append(expected, bsv[entry.Offset:entry.Offset+entry.Length])
+	}
+
+	// Reading one byte at a time requests more data for every partial
+	// field.
+	s := bufio.NewScanner(iotest.OneByteReader(bytes.NewReader(bsv)))
+	s.Split(control.ScanFields)
+
+	var tokens [][]byte
+	for s.Scan() {
+		tokens = append(tokens, append([]byte{}, s.Bytes()...))
+	}
+	require.NoError(t, s.Err(), mark)
+	require.Equal(t, expected, tokens, mark)
+}
+
+func TestScanFieldsErrors(t *testing.T) {
+	type TC struct {
+		Input []byte
+		Mark  error
+	}
+
+	tcs := []TC{
+		{
+			// Truncated data size field.
+			Input: []byte{0b_0100_0011, 0x01, 0x02},
+			Mark:  oops.New("unexpected"),
+		},
+		{
+			// Unbounded container without an end.
+			Input: []byte{0b_0000_0110, 0b_1000_0001},
+			Mark:  oops.New("unexpected"),
+		},
+		{
+			// Truncated symmetric container.
+			Input: []byte{0b_0000_0111, 0b_0100_0000, 0x01, 0b_0100_0000},
+			Mark:  oops.New("unexpected"),
+		},
+		{
+			// Container end without a container.
+			Input: []byte{0b_1000_0001, 0b_0000_0100},
+			Mark:  oops.New("unexpected"),
+		},
+	}
+
+	for _, tc := range tcs {
+		s := bufio.NewScanner(bytes.NewReader(tc.Input))
+		s.Split(control.ScanFields)
+
+		for s.Scan() {
+		}
+		require.Error(t, s.Err(), tc.Mark)
+	}
+}
@@ -0,0 +1,179 @@
+package control
+
+import (
+	"io"
+
+	"github.com/calebcase/bsv/internal/wire"
+)
+
+// sizeBytes returns the number of bytes in the minimal big-endian encoding of
+// size-1.
+func sizeBytes(size uint64) uint64 {
+	return uint64(len(minimalSize(size)))
+}
+
+// fitsData reports if the data is written in a Data, Data1 or Data2 block.
+func fitsData(data []byte) bool {
+	switch len(data) {
+	case 1:
+		return data[0]&Data.Mask == data[0]
+	case 2:
+		return data[0]&Data1.Mask == data[0]
+	case 3:
+		return data[0]&Data2.Mask == data[0]
+	}
+
+	return false
+}
+
+// SizeOfData returns the number of bytes Encoder.Data writes for n bytes of
+// data. Data of 1 to 3 bytes is written with a byte less if its leading bits
+// fit in the control block (see SizeOfDataBytes), so for those sizes this is
+// the largest size. Zero is returned if n is zero.
+func SizeOfData(n int) uint64 {
+	switch {
+	case n <= 0:
+		return 0
+	case n <= 64:
+		return 1 + uint64(n)
+	}
+
+	return 1 + sizeBytes(uint64(n)) + uint64(n)
+}
+
+// SizeOfDataBytes returns the number of bytes Encoder.Data writes for the data.
+func SizeOfDataBytes(data []byte) uint64 {
+	if fitsData(data) {
+		return uint64(len(data))
+	}
+
+	return SizeOfData(len(data))
+}
+
+// SizeOfSymmetricData is like SizeOfData, but for data fields written in
+// symmetric mode.
+func SizeOfSymmetricData(n int) uint64 {
+	switch {
+	case n <= 0:
+		return 0
+	case n == 1:
+		// dz data dz
+		return 3
+	case n <= 64:
+		// cs dz data dz cs
+		return 4 + uint64(n)
+	}
+
+	// cs dzz size data size dzz cs
+	return 4 + 2*sizeBytes(uint64(n)) + uint64(n)
+}
+
+// SizeOfSymmetricDataBytes is like SizeOfDataBytes, but for data fields written
+// in symmetric mode.
+func SizeOfSymmetricDataBytes(data []byte) uint64 {
+	if !fitsData(data) {
+		return SizeOfSymmetricData(len(data))
+	}
+
+	// Data blocks are already symmetric.
+	if len(data) == 1 {
+		return 1
+	}
+
+	// cs d1/d2 data d1/d2 cs
+	return 3 + uint64(len(data))
+}
+
+// skipBytes returns the number of amount bytes in a Skip Size field or zero if
+// the amount can't be encoded.
+func skipBytes(amount uint64) uint64 {
+	switch {
+	case amount == 0:
+		return 0
+	case amount <= 1<<8:
+		return 1
+	case amount <= 1<<16:
+		return 2
+	}
+
+	return 0
+}
+
+// SizeOfSkip returns the number of bytes Encoder.Skip writes. Zero is returned
+// if the amount can't be encoded.
+func SizeOfSkip(amount uint64) uint64 {
+	n := skipBytes(amount)
+	if n == 0 {
+		return 0
+	}
+
+	return 1 + n
+}
+
+// SizeOfSymmetricSkip is like SizeOfSkip, but in symmetric mode.
+func SizeOfSymmetricSkip(amount uint64) uint64 {
+	n := skipBytes(amount)
+	if n == 0 {
+		return 0
+	}
+
+	// cs sz amount sz cs
+	return 4 + n
+}
+
+// SizeOfBound returns the number of bytes Encoder.Bound writes for inner bytes
+// of embedded BSV. Zero is returned if inner is zero. BoundFunc writes the same
+// unless the writer is an io.WriteSeeker, in which case it reserves a fixed 9
+// byte size field.
+func SizeOfBound(inner uint64) uint64 {
+	if inner == 0 {
+		return 0
+	}
+
+	// cb size bsv
+	return 1 + SizeOfDataBytes(minimalSize(inner)) + inner
+}
+
+// SizeOfSymmetricBound is like SizeOfBound, but in symmetric mode.
+func SizeOfSymmetricBound(inner uint64) uint64 {
+	if inner == 0 {
+		return 0
+	}
+
+	// cs cb size bsv size cb cs
+	return 4 + 2*SizeOfSymmetricDataBytes(minimalSize(inner)) + inner
+}
+
+// CountingEncoder is an Encoder that counts the bytes it would write instead
+// of writing them.
+type CountingEncoder struct {
+	Encoder
+
+	cw *wire.CountingWriter
+}
+
+// NewCountingEncoder returns a counting encoder.
+func NewCountingEncoder() *CountingEncoder {
+	return NewCountingEncoderWithOptions(EncoderOptions{})
+}
+
+// NewCountingEncoderWithOptions returns a counting encoder that counts the
+// bytes an encoder with opts would write.
+func NewCountingEncoderWithOptions(opts EncoderOptions) *CountingEncoder {
+	cw := &wire.CountingWriter{W: io.Discard}
+
+	return &CountingEncoder{
+		Encoder: NewEncoderWithOptions(cw, opts),
+		cw:      cw,
+	}
+}
+
+// Count returns the number of bytes written so far.
+func (ce *CountingEncoder) Count() uint64 {
+	return uint64(ce.cw.N)
+}
+
+// Reset sets the count to zero.
+func (ce *CountingEncoder) Reset() {
+	ce.cw.N = 0
+}
@@ -0,0 +1,166 @@
+package control_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calebcase/bsv/control"
+	"github.com/calebcase/oops"
+)
+
+// sizes covers the boundaries between the encoder's block types.
+var sizes = []int{1, 2, 3, 4, 63, 64, 65, 66, 255, 256, 257, 258, 1000, 65536, 65537, 65538}
+
+// encodedLen returns the number of bytes fn writes.
+func encodedLen(t *testing.T, symmetric bool, fn func(e control.Encoder) error, mark error) uint64 {
+	buf := &bytes.Buffer{}
+	e := control.NewEncoder(buf)
+
+	var err error
+	if symmetric {
+		err = e.Symmetric(fn)
+	} else {
+		err = fn(e)
+	}
+	require.NoError(t, err, mark)
+
+	return uint64(buf.Len())
+}
+
+func TestSizeOfData(t *testing.T) {
+	for _, n := range sizes {
+		for _, first := range []byte{0x00, 0x0f, 0x10, 0x1f, 0x20, 0x7f, 0x80, 0xff} {
+			mark := oops.New("unexpected: n=%d first=%#x", n, first)
+
+			data := make([]byte, n)
+			data[0] = first
+
+			fn := func(e control.Encoder) error {
+				return e.Data(data)
+			}
+
+			require.Equal(t, encodedLen(t, false, fn, mark), control.SizeOfDataBytes(data), mark)
+			require.Equal(t, encodedLen(t, true, fn, mark), control.SizeOfSymmetricDataBytes(data), mark)
+
+			require.LessOrEqual(t, control.SizeOfDataBytes(data), control.SizeOfData(n), mark)
+			require.LessOrEqual(t, control.SizeOfSymmetricDataBytes(data), control.SizeOfSymmetricData(n), mark)
+		}
+
+		mark := oops.New("unexpected: n=%d", n)
+
+		data := bytes.Repeat([]byte{0xff}, n)
+
+		fn := func(e control.Encoder) error {
+			return e.Data(data)
+		}
+
+		require.Equal(t, encodedLen(t, false, fn, mark), control.SizeOfData(n), mark)
+		require.Equal(t, encodedLen(t, true, fn, mark), control.SizeOfSymmetricData(n), mark)
+	}
+
+	require.Equal(t, uint64(0), control.SizeOfData(0))
+	require.Equal(t, uint64(0), control.SizeOfSymmetricData(0))
+}
+
+func TestSizeOfSkip(t *testing.T) {
+	for _, amount := range []uint64{1, 2, 255, 256, 257, 65535, 65536} {
+		mark := oops.New("unexpected: amount=%d", amount)
+
+		fn := func(e control.Encoder) error {
+			return e.Skip(amount)
+		}
+
+		require.Equal(t, encodedLen(t, false, fn, mark), control.SizeOfSkip(amount), mark)
+		require.Equal(t, encodedLen(t, true, fn, mark), control.SizeOfSymmetricSkip(amount), mark)
+	}
+
+	require.Equal(t, uint64(0), control.SizeOfSkip(0))
+	require.Equal(t, uint64(0), control.SizeOfSkip(65537))
+	require.Equal(t, uint64(0), control.SizeOfSymmetricSkip(65537))
+}
+
+func TestSizeOfBound(t *testing.T) {
+	for _, n := range append(sizes, 1<<24, 1<<24+1) {
+		mark := oops.New("unexpected: n=%d", n)
+
+		inner := make([]byte, n)
+
+		fn := func(e control.Encoder) error {
+			return e.Bound(inner)
+		}
+
+		require.Equal(t, encodedLen(t, false, fn, mark), control.SizeOfBound(uint64(n)), mark)
+		require.Equal(t, encodedLen(t, true, fn, mark), control.SizeOfSymmetricBound(uint64(n)), mark)
+	}
+
+	require.Equal(t, uint64(0), control.SizeOfBound(0))
+	require.Equal(t, uint64(0), control.SizeOfSymmetricBound(0))
+}
+
+func TestCountingEncoder(t *testing.T) {
+	type TC struct {
+		Options control.EncoderOptions
+		Mark    error
+	}
+
+	tcs := []TC{
+		{
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Options: control.EncoderOptions{Policy: control.FixedDataSizeSize},
+			Mark:    oops.New("unexpected"),
+		},
+	}
+
+	fn := func(e control.Encoder) (err error) {
+		err = e.Data([]byte{0x01})
+		if err != nil {
+			return err
+		}
+
+		err = e.Data(make([]byte, 300))
+		if err != nil {
+			return err
+		}
+
+		err = e.Null()
+		if err != nil {
+			return err
+		}
+
+		err = e.BoundFunc(func(be control.Encoder) error {
+			return be.Data([]byte{0xff, 0xff})
+		})
+		if err != nil {
+			return err
+		}
+
+		err = e.Symmetric(func(se control.Encoder) error {
+			return se.Data([]byte{0x10, 0x00})
+		})
+		if err != nil {
+			return err
+		}
+
+		return e.Unbound(func(ue control.Encoder) error {
+			return ue.Skip(1000)
+		})
+	}
+
+	for _, tc := range tcs {
+		buf := &bytes.Buffer{}
+		require.NoError(t, fn(control.NewEncoderWithOptions(buf, tc.Options)), tc.Mark)
+
+		ce := control.NewCountingEncoderWithOptions(tc.Options)
+		require.Equal(t, uint64(0), ce.Count(), tc.Mark)
+
+		require.NoError(t, fn(ce), tc.Mark)
+		require.Equal(t, uint64(buf.Len()), ce.Count(), tc.Mark)
+
+		ce.Reset()
+		require.Equal(t, uint64(0), ce.Count(), tc.Mark)
+	}
+}
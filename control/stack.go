@@ -1,5 +1,7 @@
 package control
 
+import "github.com/calebcase/oops"
+
 type Frame struct {
 	Type Type
 
@@ -14,6 +16,10 @@ type Frame struct {
 	// control blocks encountered.
 	Subtype Type
 	Count   uint64
+
+	// If the type is ContainerSymmetric and the decoder is verifying
+	// symmetric fields then blocks are the control blocks counted so far.
+	blocks [][]byte
 }
 
 type Stack []*Frame
@@ -33,7 +39,7 @@ func (s *Stack) Top() *Frame {
 func (s *Stack) Pop() (err error) {
 	top := s.Top()
 	if top == nil {
-		Error.New("no frame on stack")
+		return Error.New("no frame on stack")
 	}
 
 	switch top.Type {
@@ -52,24 +58,68 @@ func (s *Stack) Pop() (err error) {
 	return nil
 }
 
-func (s *Stack) Count(blocks uint64) {
+// counter returns the frame of the symmetric container currently being read.
+// Blocks read while a bounded container's size is being read count towards
+// its parent. If there is no such frame it returns nil.
+func (s *Stack) counter() *Frame {
 	top := s.Top()
 	if top == nil {
-		return
+		return nil
+	}
+
+	if top.Type == ContainerSymmetric {
+		return top
 	}
 
-	if top.Type != ContainerSymmetric && len(*s) >= 2 {
+	if top.Type == ContainerBounded && top.Size == 0 && len(*s) >= 2 {
 		parent := (*s)[len(*s)-2]
-		if parent.Type != ContainerSymmetric {
-			panic("invalid attempt to count")
+		if parent.Type == ContainerSymmetric {
+			return parent
 		}
+	}
+
+	return nil
+}
+
+// Count adds blocks to the control block count of the symmetric container
+// currently being read (see counter). All other blocks are not part of a
+// symmetric field's control blocks and are ignored.
+func (s *Stack) Count(blocks uint64) {
+	f := s.counter()
+	if f == nil {
+		return
+	}
 
-		parent.Count += blocks
+	f.Count += blocks
+}
 
+// record adds a copy of the block to the leading control blocks of the
+// symmetric container currently being read (see counter).
+func (s *Stack) record(block []byte) {
+	f := s.counter()
+	if f == nil {
 		return
 	}
 
-	top.Count += blocks
+	f.blocks = append(f.blocks, append([]byte{}, block...))
+}
+
+// Available returns the number of bytes that can be consumed before exceeding
+// one of the bounded containers. It returns false if there are no bounded
+// containers with a known size.
+func (s Stack) Available() (n uint64, ok bool) {
+	for _, f := range s {
+		if f.Type != ContainerBounded || f.Size == 0 {
+			continue
+		}
+
+		if !ok || f.Remaining < n {
+			n = f.Remaining
+			ok = true
+		}
+	}
+
+	return n, ok
 }
 
 func (s *Stack) Consume(size uint64) (err error) {
@@ -79,13 +129,16 @@ func (s *Stack) Consume(size uint64) (err error) {
 	}
 
 	for i, f := range *s {
-		if f.Type != ContainerBounded {
+		// Bounded containers without a size are still reading their
+		// size and the bytes do not count against them.
+		if f.Type != ContainerBounded || f.Size == 0 {
 			continue
 		}
 
 		if size > f.Remaining {
-			return Error.New(
-				"exceeded bounded: depth=%d/%d size=%d remaining=%d consuming=%d",
+			return oops.New(
+				"%w: depth=%d/%d size=%d remaining=%d consuming=%d",
+				ErrBoundedOverflow,
 				i,
 				len(*s),
 				f.Size,
@@ -103,7 +156,10 @@ func (s *Stack) Consume(size uint64) (err error) {
 			break
 		}
 
-		if top.Type == ContainerBounded && top.Remaining == 0 {
+		if top.Type == ContainerBounded &&
+			top.Size != 0 &&
+			top.Remaining == 0 {
+
 			err = s.Pop()
 			if err != nil {
 				return err
@@ -0,0 +1,76 @@
+package control
+
+import (
+	"errors"
+	"io"
+
+	"github.com/calebcase/oops"
+)
+
+// dataReader is a length limited reader over a field's data. Bytes are
+// accounted for by consume as they are read.
+type dataReader struct {
+	r io.Reader
+
+	// remaining is the size of the data not yet read and available is how
+	// much of it can be read before exceeding a bounded container.
+	remaining uint64
+	available uint64
+
+	consume func(n uint64) error
+
+	// fail records the error that stopped reading with the decoder. Once
+	// set err is returned by every read.
+	fail func(err error) error
+	err  error
+}
+
+func (dr *dataReader) Read(p []byte) (n int, err error) {
+	if dr.err != nil {
+		return 0, dr.err
+	}
+
+	if dr.remaining == 0 {
+		return 0, io.EOF
+	}
+
+	if dr.available == 0 {
+		return 0, dr.stop(oops.New(
+			"%w: data remaining=%d",
+			ErrBoundedOverflow,
+			dr.remaining,
+		))
+	}
+
+	if uint64(len(p)) > dr.available {
+		p = p[:dr.available]
+	}
+
+	n, err = dr.r.Read(p)
+	if n > 0 {
+		dr.remaining -= uint64(n)
+		dr.available -= uint64(n)
+
+		cerr := dr.consume(uint64(n))
+		if cerr != nil {
+			return n, dr.stop(cerr)
+		}
+	}
+
+	if errors.Is(err, io.EOF) && dr.remaining > 0 {
+		return n, dr.stop(Error.Trace(io.ErrUnexpectedEOF))
+	}
+
+	if err != nil && !errors.Is(err, io.EOF) {
+		return n, dr.stop(Error.Trace(err))
+	}
+
+	return n, err
+}
+
+// stop ends reading with err.
+func (dr *dataReader) stop(err error) error {
+	dr.err = dr.fail(err)
+
+	return dr.err
+}
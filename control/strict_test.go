@@ -0,0 +1,131 @@
+package control_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calebcase/bsv/control"
+	"github.com/calebcase/oops"
+)
+
+func TestStrictSymmetric(t *testing.T) {
+	type TC struct {
+		Field func(e control.Encoder) error
+
+		// Trailing is the number of trailing control blocks bytes.
+		Trailing int
+
+		Mark error
+	}
+
+	tcs := []TC{
+		{
+			Field: func(e control.Encoder) error {
+				return e.Data([]byte{0x10, 0x00})
+			},
+			Trailing: 2,
+			Mark:     oops.New("unexpected"),
+		},
+		{
+			Field: func(e control.Encoder) error {
+				return e.Data([]byte{0x0f, 0x01, 0x02})
+			},
+			Trailing: 2,
+			Mark:     oops.New("unexpected"),
+		},
+		{
+			Field: func(e control.Encoder) error {
+				return e.Data(bytes.Repeat([]byte{0xff}, 10))
+			},
+			Trailing: 2,
+			Mark:     oops.New("unexpected"),
+		},
+		{
+			Field: func(e control.Encoder) error {
+				return e.Data(bytes.Repeat([]byte{0xff}, 300))
+			},
+			Trailing: 4,
+			Mark:     oops.New("unexpected"),
+		},
+		{
+			Field: func(e control.Encoder) error {
+				return e.Bound([]byte{0x81, 0x82, 0x83})
+			},
+			Trailing: 3,
+			Mark:     oops.New("unexpected"),
+		},
+		{
+			Field: func(e control.Encoder) error {
+				return e.Bound(bytes.Repeat([]byte{0x81}, 300))
+			},
+			Trailing: 7,
+			Mark:     oops.New("unexpected"),
+		},
+		{
+			Field: func(e control.Encoder) error {
+				return e.Skip(300)
+			},
+			Trailing: 2,
+			Mark:     oops.New("unexpected"),
+		},
+		{
+			Field: func(e control.Encoder) error {
+				return e.Bound(bytes.Repeat([]byte{0x81}, 200))
+			},
+			Trailing: 5,
+			Mark:     oops.New("unexpected"),
+		},
+	}
+
+	strict := control.DecoderOptions{StrictSymmetric: true}
+
+	for _, tc := range tcs {
+		buf := &bytes.Buffer{}
+		e := control.NewEncoder(buf)
+
+		require.NoError(t, e.Symmetric(tc.Field), tc.Mark)
+		require.NoError(t, e.Null(), tc.Mark)
+
+		input := buf.Bytes()
+
+		decoders := func(input []byte) []control.Decoder {
+			return []control.Decoder{
+				control.NewDecoderWithOptions(bytes.NewReader(input), strict),
+				control.NewBytesDecoderWithOptions(input, strict),
+			}
+		}
+
+		for _, d := range decoders(input) {
+			require.NoError(t, decodeAll(d), tc.Mark)
+		}
+
+		// The trailing control blocks end before the null.
+		end := len(input) - 1
+
+		// Changing any of the trailing control blocks is detected.
+		for i := end - tc.Trailing; i < end; i++ {
+			mark := oops.New("unexpected: %x: offset=%d", input, i)
+
+			corrupt := append([]byte{}, input...)
+			corrupt[i] ^= 0b_0000_0001
+
+			for _, d := range decoders(corrupt) {
+				err := decodeAll(d)
+				require.Error(t, err, mark)
+				require.ErrorIs(t, err, control.ErrAsymmetric, mark)
+
+				de := &control.DecodeError{}
+				require.True(t, errors.As(err, &de), mark)
+				require.Equal(t, uint64(end), de.Offset, mark)
+				require.Contains(t, err.Error(), "differ at offset", mark)
+			}
+
+			// Without verification the change goes unnoticed.
+			err := decodeAll(control.NewDecoder(bytes.NewReader(corrupt)))
+			require.NoError(t, err, mark)
+		}
+	}
+}
@@ -0,0 +1,461 @@
+package control
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+)
+
+// SyncMarkerSize is the size of a sync marker.
+const SyncMarkerSize = 16
+
+// NewSyncMarker returns a new random sync marker.
+func NewSyncMarker() (marker []byte, err error) {
+	marker = make([]byte, SyncMarkerSize)
+
+	_, err = rand.Read(marker)
+	if err != nil {
+		return nil, Error.Trace(err)
+	}
+
+	return marker, nil
+}
+
+// checkSyncMarker verifies the marker is SyncMarkerSize bytes.
+func checkSyncMarker(marker []byte) (err error) {
+	if len(marker) != SyncMarkerSize {
+		return Error.New("invalid sync marker size: %d", len(marker))
+	}
+
+	return nil
+}
+
+// syncField returns the encoded sync marker field. It is always a Data Size
+// field so it can be found in the raw bytes of the stream.
+func syncField(marker []byte) (field []byte, err error) {
+	err = checkSyncMarker(marker)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte{DataSize.Prefix | byte(len(marker)-1)}, marker...), nil
+}
+
+// syncEncoder writes a sync marker after every n top level fields.
+type syncEncoder struct {
+	e      Encoder
+	marker []byte
+	every  int
+	count  int
+}
+
+// NewSyncEncoder returns an encoder that writes the sync marker to e as a
+// data field after every n fields. Decoders can use the markers to recover
+// from corruption (see Recover) and skip them with NewSyncDecoder.
+func NewSyncEncoder(e Encoder, marker []byte, n int) Encoder {
+	return &syncEncoder{
+		e:      e,
+		marker: marker,
+		every:  n,
+	}
+}
+
+// field counts a field written with err and writes a sync marker if needed.
+func (se *syncEncoder) field(err error) error {
+	if err != nil {
+		return err
+	}
+
+	se.count++
+	if se.every <= 0 || se.count%se.every != 0 {
+		return nil
+	}
+
+	err = checkSyncMarker(se.marker)
+	if err != nil {
+		return err
+	}
+
+	return se.e.DataPolicy(se.marker, FixedDataSize)
+}
+
+func (se *syncEncoder) Data(data []byte) (err error) {
+	return se.field(se.e.Data(data))
+}
+
+func (se *syncEncoder) DataPolicy(data []byte, p Policy) (err error) {
+	return se.field(se.e.DataPolicy(data, p))
+}
+
+func (se *syncEncoder) DataFrom(r io.Reader, size uint64) (err error) {
+	return se.field(se.e.DataFrom(r, size))
+}
+
+func (se *syncEncoder) Bound(bsv []byte) (err error) {
+	return se.field(se.e.Bound(bsv))
+}
+
+func (se *syncEncoder) BoundFunc(fn func(Encoder) error) (err error) {
+	return se.field(se.e.BoundFunc(fn))
+}
+
+func (se *syncEncoder) Unbound(fn func(Encoder) error) (err error) {
+	return se.field(se.e.Unbound(fn))
+}
+
+func (se *syncEncoder) Symmetric(fn func(Encoder) error) (err error) {
+	return se.field(se.e.Symmetric(fn))
+}
+
+func (se *syncEncoder) Skip(amount uint64) (err error) {
+	return se.field(se.e.Skip(amount))
+}
+
+func (se *syncEncoder) Empty() (err error) {
+	return se.field(se.e.Empty())
+}
+
+func (se *syncEncoder) Null() (err error) {
+	return se.field(se.e.Null())
+}
+
+// syncDecoder skips top level sync marker fields.
+type syncDecoder struct {
+	Decoder
+
+	marker []byte
+}
+
+// NewSyncDecoder returns a decoder that skips the top level sync markers
+// written by a sync encoder.
+func NewSyncDecoder(d Decoder, marker []byte) Decoder {
+	return &syncDecoder{
+		Decoder: d,
+		marker:  marker,
+	}
+}
+
+func (sd *syncDecoder) Next() bool {
+	for sd.Decoder.Next() {
+		if sd.Depth() != 0 || sd.Type() != DataSize {
+			return true
+		}
+
+		size, err := sd.Size()
+		if err != nil || size != uint64(len(sd.marker)) {
+			return true
+		}
+
+		data, err := sd.Data()
+		if err != nil || !bytes.Equal(data, sd.marker) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Range is a range of bytes skipped during recovery and the error that caused
+// it to be skipped.
+type Range struct {
+	Start uint64
+	End   uint64
+
+	Err error
+}
+
+// recoverReader reads from the pending bytes and then r and records the bytes
+// read.
+type recoverReader struct {
+	r       io.Reader
+	pending []byte
+	record  []byte
+
+	// err is the first error from r other than io.EOF.
+	err error
+}
+
+func (rr *recoverReader) Read(p []byte) (n int, err error) {
+	if len(rr.pending) > 0 {
+		n = copy(p, rr.pending)
+		rr.pending = rr.pending[n:]
+	} else {
+		n, err = rr.r.Read(p)
+		if err != nil && !errors.Is(err, io.EOF) && rr.err == nil {
+			rr.err = err
+		}
+	}
+
+	rr.record = append(rr.record, p[:n]...)
+
+	return n, err
+}
+
+// Recovery reads the top level fields of a stream written with sync markers.
+// When a field can't be decoded the bytes up to the next sync marker are
+// skipped and decoding resumes after it.
+type Recovery struct {
+	rr     *recoverReader
+	opts   DecoderOptions
+	field  []byte
+	marker []byte
+
+	offset  uint64
+	current []byte
+	start   uint64
+	skipped []Range
+
+	err error
+}
+
+// Recover returns a Recovery reading r. The marker is the sync marker used
+// when writing the stream. The fields are decoded with the
+// HardenedDecoderOptions limits, except for the total size.
+func Recover(r io.Reader, marker []byte) *Recovery {
+	opts := HardenedDecoderOptions
+	opts.MaxTotal = 0
+
+	return RecoverWithOptions(r, marker, opts)
+}
+
+// RecoverWithOptions is like Recover, but each field is decoded with the
+// limits in opts.
+func RecoverWithOptions(r io.Reader, marker []byte, opts DecoderOptions) *Recovery {
+	field, err := syncField(marker)
+
+	return &Recovery{
+		rr:     &recoverReader{r: r},
+		opts:   opts,
+		field:  field,
+		marker: marker,
+		err:    err,
+	}
+}
+
+// readField reads the current field of d including everything embedded in it.
+func readField(d Decoder) (err error) {
+	switch d.Type() {
+	case Data, Data1, Data2, DataSize, DataSizeSize:
+		_, err = d.Data()
+
+		return err
+	case SkipSize:
+		_, err = d.Amount()
+
+		return err
+	case Empty, Null:
+		return nil
+	case ContainerSymmetric:
+		err = d.Enter()
+		if err != nil {
+			return err
+		}
+
+		if !d.Next() {
+			if d.Err() != nil {
+				return d.Err()
+			}
+
+			return Error.New("unexpected end of input (symmetric container empty)")
+		}
+
+		return readField(d)
+	case ContainerBounded:
+		bsv, err := d.BSV()
+		if err != nil {
+			return err
+		}
+
+		bd := NewBytesDecoder(bsv)
+		for bd.Next() {
+			err = readField(bd)
+			if err != nil {
+				return err
+			}
+		}
+
+		return bd.Err()
+	case ContainerUnbounded:
+		err = d.Enter()
+		if err != nil {
+			return err
+		}
+
+		depth := d.Depth()
+
+		for d.Next() {
+			if d.Type() == ContainerEnd && d.Depth() == depth-1 {
+				return nil
+			}
+
+			err = readField(d)
+			if err != nil {
+				return err
+			}
+		}
+
+		if d.Err() != nil {
+			return d.Err()
+		}
+
+		return Error.New("unexpected end of input (unbounded container not ended)")
+	}
+
+	return Error.New("unexpected field: %s", d.Type().Abbr)
+}
+
+// next reads the next field into rr.record.
+func (rc *Recovery) next() (ok bool, err error) {
+	rc.rr.record = rc.rr.record[:0]
+
+	d := NewDecoderWithOptions(rc.rr, rc.opts)
+	if !d.Next() {
+		return false, d.Err()
+	}
+
+	err = readField(d)
+	if err != nil {
+		return false, err
+	}
+
+	// Move past the trailing blocks of symmetric fields.
+	err = d.Seek()
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// resync skips to the end of the next sync marker after the start of the
+// field that couldn't be decoded.
+func (rc *Recovery) resync(cause error) (ok bool) {
+	start := rc.offset
+
+	// buf holds the bytes read from base onwards that haven't been
+	// searched yet (except for a possible partial marker).
+	buf := append([]byte{}, rc.rr.record...)
+	base := start
+
+	chunk := make([]byte, 4096)
+
+	for {
+		// The marker can't start where decoding failed.
+		from := uint64(0)
+		if base == start {
+			from = 1
+		}
+
+		if from <= uint64(len(buf)) {
+			i := bytes.Index(buf[from:], rc.field)
+			if i >= 0 {
+				end := base + from + uint64(i)
+
+				rc.skipped = append(rc.skipped, Range{start, end, cause})
+				rc.rr.pending = append(buf[from+uint64(i)+uint64(len(rc.field)):], rc.rr.pending...)
+				rc.offset = end + uint64(len(rc.field))
+
+				return true
+			}
+		}
+
+		// Keep enough bytes to match a marker split across reads.
+		keep := len(rc.field) - 1
+		if len(buf) > keep && base+uint64(len(buf)-keep) > start {
+			drop := len(buf) - keep
+			buf = buf[drop:]
+			base += uint64(drop)
+		}
+
+		rc.rr.record = rc.rr.record[:0]
+
+		n, err := rc.rr.Read(chunk)
+		buf = append(buf, chunk[:n]...)
+
+		if rc.rr.err != nil {
+			rc.err = Error.Trace(rc.rr.err)
+
+			return false
+		}
+
+		if err != nil && n == 0 {
+			end := base + uint64(len(buf))
+
+			rc.skipped = append(rc.skipped, Range{start, end, cause})
+			rc.offset = end
+
+			return false
+		}
+	}
+}
+
+// Next reads the next field. It returns false at the end of the input or if
+// there is an error reading the input. Corrupt fields are skipped and
+// reported by Skipped.
+func (rc *Recovery) Next() bool {
+	if rc.err != nil {
+		return false
+	}
+
+	for {
+		ok, err := rc.next()
+
+		if rc.rr.err != nil {
+			rc.err = Error.Trace(rc.rr.err)
+
+			return false
+		}
+
+		if err != nil {
+			if !rc.resync(err) {
+				return false
+			}
+
+			continue
+		}
+
+		if !ok {
+			return false
+		}
+
+		field := rc.rr.record
+
+		rc.start = rc.offset
+		rc.offset += uint64(len(field))
+
+		if bytes.Equal(field, rc.field) {
+			continue
+		}
+
+		rc.current = append(rc.current[:0], field...)
+
+		return true
+	}
+}
+
+// Field returns the encoded bytes of the current field.
+func (rc *Recovery) Field() []byte {
+	return rc.current
+}
+
+// Offset returns the position of the current field in the input.
+func (rc *Recovery) Offset() uint64 {
+	return rc.start
+}
+
+// Decoder returns a decoder for the current field.
+func (rc *Recovery) Decoder() Decoder {
+	return NewBytesDecoder(rc.current)
+}
+
+// Skipped returns the ranges of the input that were skipped.
+func (rc *Recovery) Skipped() []Range {
+	return rc.skipped
+}
+
+// Err returns the error that stopped the recovery, if any. Errors decoding
+// fields are not returned; they are reported with the ranges in Skipped.
+func (rc *Recovery) Err() error {
+	return rc.err
+}
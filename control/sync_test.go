@@ -0,0 +1,187 @@
+package control_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calebcase/bsv/control"
+	"github.com/calebcase/oops"
+)
+
+// syncFixture writes records 0 to count-1 with a sync marker after every 2
+// records.
+func syncFixture(t *testing.T, marker []byte, count int) []byte {
+	return encode(t, func(e control.Encoder) error {
+		return records(control.NewSyncEncoder(e, marker, 2), count, func(int) int {
+			return 20
+		})
+	})
+}
+
+func TestSyncEncoder(t *testing.T) {
+	mark := oops.New("unexpected")
+
+	marker, err := control.NewSyncMarker()
+	require.NoError(t, err, mark)
+	require.Len(t, marker, control.SyncMarkerSize, mark)
+
+	bsv := syncFixture(t, marker, 5)
+
+	idx, err := control.BuildIndex(bytes.NewReader(bsv))
+	require.NoError(t, err, mark)
+
+	// r r m r r m r
+	require.Len(t, idx, 7, mark)
+
+	field := append([]byte{0b_0100_1111}, marker...)
+	for _, i := range []int{2, 5} {
+		require.Equal(t, field, bsv[idx[i].Offset:idx[i].Offset+idx[i].Length], mark)
+	}
+
+	// The sync decoder skips the markers.
+	fields := walk(t, control.NewSyncDecoder(control.NewBytesDecoder(bsv), marker), mark)
+	require.Len(t, fields, 5, mark)
+
+	for i, f := range fields {
+		require.Equal(t, control.ContainerBounded, f.Type, mark)
+		require.Equal(t, byte(i), f.BSV[0]&0b_0111_1111, mark)
+	}
+
+	// Invalid markers are rejected when writing.
+	e := control.NewSyncEncoder(control.NewEncoder(&bytes.Buffer{}), []byte{1}, 1)
+	require.Error(t, e.Null(), mark)
+}
+
+// recovered returns the first data byte of each recovered record.
+func recovered(t *testing.T, rc *control.Recovery, mark error) (records []byte, offsets []uint64) {
+	for rc.Next() {
+		d := rc.Decoder()
+		require.True(t, d.Next(), mark)
+
+		bsv, err := d.BSV()
+		require.NoError(t, err, mark)
+
+		records = append(records, bsv[0]&0b_0111_1111)
+		offsets = append(offsets, rc.Offset())
+	}
+	require.NoError(t, rc.Err(), mark)
+
+	return records, offsets
+}
+
+func TestRecover(t *testing.T) {
+	marker, err := control.NewSyncMarker()
+	require.NoError(t, err)
+
+	bsv := syncFixture(t, marker, 7)
+
+	// r0 r1 m r2 r3 m r4 r5 m r6
+	idx, err := control.BuildIndex(bytes.NewReader(bsv))
+	require.NoError(t, err)
+	require.Len(t, idx, 10)
+
+	type TC struct {
+		Input   func() []byte
+		Records []byte
+		Offsets []uint64
+		Skipped [][2]uint64
+		Mark    error
+	}
+
+	tcs := []TC{
+		{
+			Input: func() []byte {
+				return bsv
+			},
+			Records: []byte{0, 1, 2, 3, 4, 5, 6},
+			Offsets: []uint64{
+				idx[0].Offset, idx[1].Offset,
+				idx[3].Offset, idx[4].Offset,
+				idx[6].Offset, idx[7].Offset,
+				idx[9].Offset,
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			// An unpaired container end in place of record 1.
+			Input: func() []byte {
+				input := append([]byte{}, bsv...)
+				input[idx[1].Offset] = 0b_0000_0100
+
+				return input
+			},
+			Records: []byte{0, 2, 3, 4, 5, 6},
+			Offsets: []uint64{
+				idx[0].Offset,
+				idx[3].Offset, idx[4].Offset,
+				idx[6].Offset, idx[7].Offset,
+				idx[9].Offset,
+			},
+			Skipped: [][2]uint64{
+				{idx[1].Offset, idx[2].Offset},
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			// Corrupt data in record 3 and 4 making them unreadable.
+			Input: func() []byte {
+				input := append([]byte{}, bsv...)
+				input[idx[4].Offset] = 0b_0000_0100
+				input[idx[6].Offset+2] = 0b_0000_0110
+
+				return input
+			},
+			Records: []byte{0, 1, 2, 6},
+			Offsets: []uint64{
+				idx[0].Offset, idx[1].Offset,
+				idx[3].Offset,
+				idx[9].Offset,
+			},
+			Skipped: [][2]uint64{
+				{idx[4].Offset, idx[5].Offset},
+				{idx[6].Offset, idx[8].Offset},
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			// Truncated in the middle of the last record.
+			Input: func() []byte {
+				return bsv[:len(bsv)-3]
+			},
+			Records: []byte{0, 1, 2, 3, 4, 5},
+			Offsets: []uint64{
+				idx[0].Offset, idx[1].Offset,
+				idx[3].Offset, idx[4].Offset,
+				idx[6].Offset, idx[7].Offset,
+			},
+			Skipped: [][2]uint64{
+				{idx[9].Offset, uint64(len(bsv) - 3)},
+			},
+			Mark: oops.New("unexpected"),
+		},
+	}
+
+	for _, tc := range tcs {
+		rc := control.Recover(bytes.NewReader(tc.Input()), marker)
+
+		records, offsets := recovered(t, rc, tc.Mark)
+		require.Equal(t, tc.Records, records, tc.Mark)
+		require.Equal(t, tc.Offsets, offsets, tc.Mark)
+
+		var skipped [][2]uint64
+		for _, r := range rc.Skipped() {
+			require.Error(t, r.Err, tc.Mark)
+
+			skipped = append(skipped, [2]uint64{r.Start, r.End})
+		}
+		require.Equal(t, tc.Skipped, skipped, tc.Mark)
+	}
+}
+
+func TestRecoverInvalidMarker(t *testing.T) {
+	rc := control.Recover(bytes.NewReader([]byte{0b_0000_0000}), []byte{1, 2, 3})
+	require.False(t, rc.Next())
+	require.Error(t, rc.Err())
+}
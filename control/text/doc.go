@@ -0,0 +1,27 @@
+// Package text implements a human readable notation for BSV fields.
+//
+// Each field is written as its block abbreviation followed by its value:
+//
+//	d 1                      Data
+//	d1 0x1234                Data + 1
+//	d2 0x012345              Data + 2
+//	dz 0x01                  Data Size
+//	dzz 0x0102               Data Size Size
+//	dzz:8 0x0102             Data Size Size with 8 bytes of size
+//	sz 16                    Skip Size
+//	sz:2 16                  Skip Size with 2 bytes of amount
+//	e                        Empty
+//	n                        Null
+//	cb[ d 1, e, n ]          Container Bounded
+//	cb(dz 0x02)[ d 1, e, n ] Container Bounded with an explicit size field
+//	cu[ d 1, e, n ]          Container Unbounded
+//	cs(d1 0x1234)            Container Symmetric
+//
+// Values of d, d1 and d2 fields are numbers (decimal or hex). Values of dz and
+// dzz fields are hex with one byte per two digits, decimal numbers (encoded
+// with the fewest bytes) or Go quoted strings. Fields are separated by commas
+// or white space and a # starts a comment that runs to the end of the line.
+//
+// The notation records the exact blocks used so Parse(Format(bsv)) returns
+// bsv unchanged, even when the blocks are not the most compact choice.
+package text
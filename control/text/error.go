@@ -0,0 +1,6 @@
+package text
+
+import "github.com/calebcase/oops"
+
+// Error is the namespace for this package's errors.
+var Error = oops.Namespace("text")
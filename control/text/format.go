@@ -0,0 +1,234 @@
+package text
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/calebcase/bsv/control"
+)
+
+// Format returns the text notation of the BSV. Top level fields are separated
+// by new lines.
+func Format(bsv []byte) (s string, err error) {
+	fields, err := formatFields(bsv)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join(fields, "\n"), nil
+}
+
+func formatFields(bsv []byte) (fields []string, err error) {
+	d := control.NewBytesDecoder(bsv)
+
+	for d.Next() {
+		field, err := formatField(d, bsv, false)
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, field)
+	}
+
+	err = d.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
+
+func brackets(fields []string) string {
+	if len(fields) == 0 {
+		return "[]"
+	}
+
+	return "[ " + strings.Join(fields, ", ") + " ]"
+}
+
+// canonicalSize returns the size field the encoder writes for a bounded
+// container with the embedded bsv.
+func canonicalSize(bsv []byte, symmetric bool) (field []byte, err error) {
+	buf := &bytes.Buffer{}
+	e := control.NewEncoder(buf)
+
+	if !symmetric {
+		err = e.Bound(bsv)
+		if err != nil {
+			return nil, err
+		}
+
+		return buf.Bytes()[1 : buf.Len()-len(bsv)], nil
+	}
+
+	err = e.Symmetric(func(se control.Encoder) error {
+		return se.Bound(bsv)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// cs cb <size> bsv <size> cb cs
+	size := (buf.Len() - 4 - len(bsv)) / 2
+
+	return buf.Bytes()[2 : 2+size], nil
+}
+
+// formatField formats the current field of d. The decoder's offsets must be
+// relative to bsv. If symmetric is true the field is embedded in a symmetric
+// container.
+func formatField(d control.Decoder, bsv []byte, symmetric bool) (field string, err error) {
+	start := d.Consumed()
+
+	switch d.Type() {
+	case control.Data:
+		data, err := d.Data()
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("d %d", data[0]), nil
+	case control.Data1, control.Data2, control.DataSize:
+		data, err := d.Data()
+		if err != nil {
+			return "", err
+		}
+
+		return d.Type().Abbr + " 0x" + hex.EncodeToString(data), nil
+	case control.DataSizeSize:
+		size, err := d.Size()
+		if err != nil {
+			return "", err
+		}
+
+		sizeSize := d.Consumed() - start
+
+		data, err := d.Data()
+		if err != nil {
+			return "", err
+		}
+
+		abbr := d.Type().Abbr
+
+		minimal := len(new(big.Int).SetUint64(size - 1).Bytes())
+		if minimal == 0 {
+			minimal = 1
+		}
+
+		if sizeSize != uint64(minimal) {
+			abbr = fmt.Sprintf("%s:%d", abbr, sizeSize)
+		}
+
+		return abbr + " 0x" + hex.EncodeToString(data), nil
+	case control.SkipSize:
+		width, err := d.Size()
+		if err != nil {
+			return "", err
+		}
+
+		amount, err := d.Amount()
+		if err != nil {
+			return "", err
+		}
+
+		if width != uint64(minimalAmount(amount)) {
+			return fmt.Sprintf("sz:%d %d", width, amount), nil
+		}
+
+		return fmt.Sprintf("sz %d", amount), nil
+	case control.Empty:
+		return "e", nil
+	case control.Null:
+		return "n", nil
+	case control.ContainerSymmetric:
+		err = d.Enter()
+		if err != nil {
+			return "", err
+		}
+
+		if !d.Next() {
+			if d.Err() != nil {
+				return "", d.Err()
+			}
+
+			return "", Error.New("unexpected end of input (symmetric container empty)")
+		}
+
+		child, err := formatField(d, bsv, true)
+		if err != nil {
+			return "", err
+		}
+
+		return "cs(" + child + ")", nil
+	case control.ContainerBounded:
+		_, err = d.Size()
+		if err != nil {
+			return "", err
+		}
+
+		size := bsv[start:d.Consumed()]
+
+		inner, err := d.BSV()
+		if err != nil {
+			return "", err
+		}
+
+		children, err := formatFields(inner)
+		if err != nil {
+			return "", err
+		}
+
+		canonical, err := canonicalSize(inner, symmetric)
+		if err != nil {
+			return "", err
+		}
+
+		if bytes.Equal(size, canonical) {
+			return "cb" + brackets(children), nil
+		}
+
+		sizeFields, err := formatFields(size)
+		if err != nil {
+			return "", err
+		}
+
+		if len(sizeFields) != 1 {
+			return "", Error.New("invalid cb size field: %x", size)
+		}
+
+		return "cb(" + sizeFields[0] + ")" + brackets(children), nil
+	case control.ContainerUnbounded:
+		err = d.Enter()
+		if err != nil {
+			return "", err
+		}
+
+		depth := d.Depth()
+
+		var children []string
+
+		for d.Next() {
+			if d.Type() == control.ContainerEnd && d.Depth() == depth-1 {
+				return "cu" + brackets(children), nil
+			}
+
+			child, err := formatField(d, bsv, false)
+			if err != nil {
+				return "", err
+			}
+
+			children = append(children, child)
+		}
+
+		if d.Err() != nil {
+			return "", d.Err()
+		}
+
+		return "", Error.New("unexpected end of input (unbounded container not ended)")
+	}
+
+	return "", Error.New("unexpected field: %s", d.Type().Abbr)
+}
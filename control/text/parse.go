@@ -0,0 +1,600 @@
+package text
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/calebcase/bsv/control"
+)
+
+// node is a parsed field.
+type node struct {
+	t control.Type
+
+	// width is the number of size bytes of a Data Size Size field or the
+	// number of amount bytes of a Skip Size field. Zero uses the fewest
+	// bytes needed.
+	width int
+
+	data   []byte
+	amount uint64
+
+	// size is the explicit size field of a Container Bounded field.
+	size *node
+
+	children []*node
+}
+
+type parser struct {
+	s   string
+	pos int
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return Error.New("offset %d: "+format, append([]interface{}{p.pos}, args...)...)
+}
+
+// skip moves past white space, commas and comments.
+func (p *parser) skip() {
+	for p.pos < len(p.s) {
+		switch c := p.s[p.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			p.pos++
+		case c == '#':
+			for p.pos < len(p.s) && p.s[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// peek returns the next byte after skipping separators or 0 at the end of the
+// input.
+func (p *parser) peek() byte {
+	p.skip()
+
+	if p.pos >= len(p.s) {
+		return 0
+	}
+
+	return p.s[p.pos]
+}
+
+func (p *parser) expect(c byte) (err error) {
+	if p.peek() != c {
+		return p.errorf("expected %q", c)
+	}
+
+	p.pos++
+
+	return nil
+}
+
+// word returns the next run of letters, digits and underscores.
+func (p *parser) word() string {
+	p.skip()
+
+	start := p.pos
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		if !(c >= 'a' && c <= 'z' ||
+			c >= 'A' && c <= 'Z' ||
+			c >= '0' && c <= '9' ||
+			c == '_') {
+
+			break
+		}
+
+		p.pos++
+	}
+
+	return p.s[start:p.pos]
+}
+
+// number parses a decimal or hex number.
+func (p *parser) number() (n *big.Int, digits int, isHex bool, err error) {
+	w := strings.ReplaceAll(p.word(), "_", "")
+
+	n = new(big.Int)
+
+	if strings.HasPrefix(w, "0x") || strings.HasPrefix(w, "0X") {
+		w = w[2:]
+
+		_, ok := n.SetString(w, 16)
+		if !ok {
+			return nil, 0, false, p.errorf("invalid hex number: %q", w)
+		}
+
+		return n, len(w), true, nil
+	}
+
+	_, ok := n.SetString(w, 10)
+	if !ok {
+		return nil, 0, false, p.errorf("invalid number: %q", w)
+	}
+
+	return n, len(w), false, nil
+}
+
+// width parses an optional ":N" suffix.
+func (p *parser) width() (width int, err error) {
+	if p.pos >= len(p.s) || p.s[p.pos] != ':' {
+		return 0, nil
+	}
+
+	p.pos++
+
+	n, _, _, err := p.number()
+	if err != nil {
+		return 0, err
+	}
+
+	if !n.IsInt64() || n.Int64() < 1 || n.Int64() > 8 {
+		return 0, p.errorf("invalid width: %s", n)
+	}
+
+	return int(n.Int64()), nil
+}
+
+// fixed parses a number that must fit in the data bits of a block of size
+// bytes.
+func (p *parser) fixed(t control.Type, size int) (data []byte, err error) {
+	n, _, _, err := p.number()
+	if err != nil {
+		return nil, err
+	}
+
+	limit := new(big.Int).Lsh(big.NewInt(int64(t.Mask)+1), uint(8*(size-1)))
+	if n.Sign() < 0 || n.Cmp(limit) >= 0 {
+		return nil, p.errorf("value does not fit in %s: %s", t.Abbr, n)
+	}
+
+	return n.FillBytes(make([]byte, size)), nil
+}
+
+// bytes parses hex, decimal or a quoted string.
+func (p *parser) bytes() (data []byte, err error) {
+	if p.peek() == '"' {
+		end := p.pos + 1
+		for end < len(p.s) && p.s[end] != '"' {
+			if p.s[end] == '\\' {
+				end++
+			}
+
+			end++
+		}
+
+		if end >= len(p.s) {
+			return nil, p.errorf("unterminated string")
+		}
+
+		str, err := strconv.Unquote(p.s[p.pos : end+1])
+		if err != nil {
+			return nil, p.errorf("invalid string: %v", err)
+		}
+
+		p.pos = end + 1
+
+		return []byte(str), nil
+	}
+
+	start := p.pos
+
+	n, digits, isHex, err := p.number()
+	if err != nil {
+		return nil, err
+	}
+
+	if !isHex {
+		data = n.Bytes()
+		if len(data) == 0 {
+			data = []byte{0}
+		}
+
+		return data, nil
+	}
+
+	if digits%2 != 0 {
+		p.pos = start
+
+		return nil, p.errorf("hex data must have an even number of digits")
+	}
+
+	data, err = hex.DecodeString(strings.ReplaceAll(p.s[start+2:p.pos], "_", ""))
+	if err != nil {
+		return nil, p.errorf("invalid hex: %v", err)
+	}
+
+	return data, nil
+}
+
+// fields parses fields until the closing byte (or the end of the input if
+// closing is 0).
+func (p *parser) fields(closing byte) (nodes []*node, err error) {
+	for {
+		c := p.peek()
+		if c == closing {
+			return nodes, nil
+		}
+
+		if c == 0 {
+			return nil, p.errorf("expected %q", closing)
+		}
+
+		n, err := p.field()
+		if err != nil {
+			return nil, err
+		}
+
+		nodes = append(nodes, n)
+	}
+}
+
+func (p *parser) field() (n *node, err error) {
+	start := p.pos
+	abbr := p.word()
+
+	n = &node{}
+
+	switch abbr {
+	case "d":
+		n.t = control.Data
+		n.data, err = p.fixed(n.t, 1)
+	case "d1":
+		n.t = control.Data1
+		n.data, err = p.fixed(n.t, 2)
+	case "d2":
+		n.t = control.Data2
+		n.data, err = p.fixed(n.t, 3)
+	case "dz":
+		n.t = control.DataSize
+		n.data, err = p.bytes()
+		if err == nil && (len(n.data) == 0 || len(n.data) > 64) {
+			err = p.errorf("dz data must be 1 to 64 bytes: %d", len(n.data))
+		}
+	case "dzz":
+		n.t = control.DataSizeSize
+
+		n.width, err = p.width()
+		if err != nil {
+			return nil, err
+		}
+
+		n.data, err = p.bytes()
+		if err == nil && len(n.data) == 0 {
+			err = p.errorf("dzz data must not be empty")
+		}
+	case "sz":
+		n.t = control.SkipSize
+
+		n.width, err = p.width()
+		if err != nil {
+			return nil, err
+		}
+
+		if n.width > 2 {
+			return nil, p.errorf("sz width must be 1 or 2: %d", n.width)
+		}
+
+		var amount *big.Int
+		amount, _, _, err = p.number()
+		if err == nil {
+			if !amount.IsUint64() || amount.Uint64() == 0 || amount.Uint64() > 1<<16 {
+				return nil, p.errorf("invalid skip amount: %s", amount)
+			}
+
+			n.amount = amount.Uint64()
+
+			if n.width != 0 && n.width < minimalAmount(n.amount) {
+				return nil, p.errorf("skip amount %d does not fit in %d byte", n.amount, n.width)
+			}
+		}
+	case "e":
+		n.t = control.Empty
+	case "n":
+		n.t = control.Null
+	case "cs":
+		n.t = control.ContainerSymmetric
+
+		err = p.expect('(')
+		if err != nil {
+			return nil, err
+		}
+
+		var child *node
+
+		child, err = p.field()
+		if err != nil {
+			return nil, err
+		}
+
+		n.children = []*node{child}
+
+		err = p.expect(')')
+	case "cb":
+		n.t = control.ContainerBounded
+
+		if p.peek() == '(' {
+			p.pos++
+
+			n.size, err = p.field()
+			if err != nil {
+				return nil, err
+			}
+
+			err = p.expect(')')
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		err = p.expect('[')
+		if err != nil {
+			return nil, err
+		}
+
+		n.children, err = p.fields(']')
+		if err != nil {
+			return nil, err
+		}
+
+		p.pos++
+
+		if len(n.children) == 0 {
+			return nil, p.errorf("cb must not be empty")
+		}
+	case "cu":
+		n.t = control.ContainerUnbounded
+
+		err = p.expect('[')
+		if err != nil {
+			return nil, err
+		}
+
+		n.children, err = p.fields(']')
+		if err != nil {
+			return nil, err
+		}
+
+		p.pos++
+	default:
+		p.pos = start
+
+		return nil, p.errorf("unknown field: %q", abbr)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+// Parse returns the BSV encoding of the fields in s.
+func Parse(s string) (bsv []byte, err error) {
+	p := &parser{s: s}
+
+	nodes, err := p.fields(0)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+
+	for _, n := range nodes {
+		err = encode(buf, n)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// policy returns the encoder policy that produces the node's data block.
+func policy(n *node) control.Policy {
+	switch n.t {
+	case control.DataSize:
+		return control.FixedDataSize
+	case control.DataSizeSize:
+		return control.Policy{Type: control.DataSizeSize, SizeSize: n.width}
+	default:
+		return control.Compact
+	}
+}
+
+// minimalAmount returns the fewest bytes needed for a skip amount.
+func minimalAmount(amount uint64) int {
+	if amount-1 > 0xff {
+		return 2
+	}
+
+	return 1
+}
+
+// encodable returns true if the encoder produces the node's blocks directly.
+// The remaining nodes (explicit sizes, wider than needed amounts and fields
+// explicitly wrapped with symmetric containers) are written block by block.
+func encodable(n *node) bool {
+	switch n.t {
+	case control.SkipSize:
+		return n.width == 0 || n.width == minimalAmount(n.amount)
+	case control.ContainerBounded:
+		return n.size == nil
+	}
+
+	return true
+}
+
+// encodeWith writes the encodable field n with e.
+func encodeWith(e control.Encoder, n *node) (err error) {
+	switch n.t {
+	case control.Data, control.Data1, control.Data2, control.DataSize, control.DataSizeSize:
+		return e.DataPolicy(n.data, policy(n))
+	case control.SkipSize:
+		return e.Skip(n.amount)
+	case control.Empty:
+		return e.Empty()
+	case control.Null:
+		return e.Null()
+	case control.ContainerBounded:
+		bsv, err := encodeChildren(n)
+		if err != nil {
+			return err
+		}
+
+		return e.Bound(bsv)
+	}
+
+	return Error.New("unencodable field: %s", n.t.Abbr)
+}
+
+func encodeChildren(n *node) (bsv []byte, err error) {
+	buf := &bytes.Buffer{}
+
+	for _, child := range n.children {
+		err = encode(buf, child)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// checkSize verifies the explicit size field of a bounded container matches
+// the size of the embedded BSV.
+func checkSize(size *node, bsv []byte) (err error) {
+	field := size
+	if field.t == control.ContainerSymmetric {
+		field = field.children[0]
+	}
+
+	switch field.t {
+	case control.Data, control.Data1, control.Data2, control.DataSize, control.DataSizeSize:
+	default:
+		return Error.New("cb size must be a data field: %s", field.t.Abbr)
+	}
+
+	s := new(big.Int).SetBytes(field.data)
+	s.Add(s, big.NewInt(1))
+
+	if !s.IsUint64() || s.Uint64() != uint64(len(bsv)) {
+		return Error.New("cb size %s does not match embedded size %d", s, len(bsv))
+	}
+
+	return nil
+}
+
+// encode writes the field n to w.
+func encode(w *bytes.Buffer, n *node) (err error) {
+	if encodable(n) && n.t != control.ContainerSymmetric && n.t != control.ContainerUnbounded {
+		return encodeWith(control.NewEncoder(w), n)
+	}
+
+	switch n.t {
+	case control.SkipSize:
+		w.Write([]byte{control.SkipSize.Prefix | 1})
+		w.Write(new(big.Int).SetUint64(n.amount - 1).FillBytes(make([]byte, 2)))
+	case control.ContainerBounded:
+		bsv, err := encodeChildren(n)
+		if err != nil {
+			return err
+		}
+
+		err = checkSize(n.size, bsv)
+		if err != nil {
+			return err
+		}
+
+		w.WriteByte(control.ContainerBounded.Prefix)
+
+		err = encode(w, n.size)
+		if err != nil {
+			return err
+		}
+
+		w.Write(bsv)
+	case control.ContainerUnbounded:
+		e := control.NewEncoder(w)
+
+		return e.Unbound(func(control.Encoder) error {
+			for _, child := range n.children {
+				err := encode(w, child)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	case control.ContainerSymmetric:
+		return encodeSymmetric(w, n.children[0])
+	}
+
+	return nil
+}
+
+// encodeSymmetric writes the field n wrapped in a symmetric container.
+func encodeSymmetric(w *bytes.Buffer, n *node) (err error) {
+	switch n.t {
+	case control.Data, control.Empty, control.Null:
+		// These are already symmetric so the encoder doesn't wrap them.
+	case control.ContainerSymmetric, control.ContainerUnbounded:
+		// These are written with their own (symmetric) end blocks.
+	default:
+		if encodable(n) {
+			e := control.NewEncoder(w)
+
+			return e.Symmetric(func(se control.Encoder) error {
+				return encodeWith(se, n)
+			})
+		}
+	}
+
+	w.WriteByte(control.ContainerSymmetric.Prefix)
+
+	switch n.t {
+	case control.SkipSize:
+		w.Write([]byte{control.SkipSize.Prefix | 1})
+		w.Write(new(big.Int).SetUint64(n.amount - 1).FillBytes(make([]byte, 2)))
+		w.Write([]byte{control.SkipSize.Prefix | 1})
+	case control.ContainerBounded:
+		bsv, err := encodeChildren(n)
+		if err != nil {
+			return err
+		}
+
+		err = checkSize(n.size, bsv)
+		if err != nil {
+			return err
+		}
+
+		size := &bytes.Buffer{}
+
+		err = encode(size, n.size)
+		if err != nil {
+			return err
+		}
+
+		w.WriteByte(control.ContainerBounded.Prefix)
+		w.Write(size.Bytes())
+		w.Write(bsv)
+		w.Write(size.Bytes())
+		w.WriteByte(control.ContainerBounded.Prefix)
+	default:
+		err = encode(w, n)
+		if err != nil {
+			return err
+		}
+	}
+
+	w.WriteByte(control.ContainerSymmetric.Prefix)
+
+	return nil
+}
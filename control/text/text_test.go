@@ -0,0 +1,287 @@
+package text_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calebcase/bsv/control"
+	"github.com/calebcase/bsv/control/text"
+	"github.com/calebcase/oops"
+)
+
+func TestText(t *testing.T) {
+	type TC struct {
+		Text string
+		BSV  []byte
+		Mark error
+	}
+
+	tcs := []TC{
+		{
+			Text: "d 1",
+			BSV:  []byte{0b_1000_0001},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Text: "d1 0x1234",
+			BSV:  []byte{0b_0011_0010, 0b_0011_0100},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Text: "d2 0x012345",
+			BSV:  []byte{0b_0001_0001, 0b_0010_0011, 0b_0100_0101},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Text: "dz 0x05",
+			BSV:  []byte{0b_0100_0000, 0b_0000_0101},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Text: "dzz 0x010203",
+			BSV:  []byte{0b_0000_1000, 0b_0000_0010, 1, 2, 3},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Text: "dzz:2 0x01",
+			BSV:  []byte{0b_0000_1001, 0, 0, 1},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Text: "sz 16",
+			BSV:  []byte{0b_0000_0010, 0b_0000_1111},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Text: "sz:2 16",
+			BSV:  []byte{0b_0000_0011, 0, 0b_0000_1111},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Text: "e\nn",
+			BSV:  []byte{0b_0000_0001, 0b_0000_0000},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Text: "cb[ d 1, e, n ]",
+			BSV:  []byte{0b_0000_0101, 0b_1000_0010, 0b_1000_0001, 0b_0000_0001, 0b_0000_0000},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Text: "cb(dz 0x0000000000000000)[ n ]",
+			BSV: []byte{
+				0b_0000_0101,
+				0b_0100_0111, 0, 0, 0, 0, 0, 0, 0, 0,
+				0b_0000_0000,
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Text: "cu[ d 1, cu[] ]",
+			BSV: []byte{
+				0b_0000_0110,
+				0b_1000_0001,
+				0b_0000_0110, 0b_0000_0100,
+				0b_0000_0100,
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Text: "cs(d1 0x1234)",
+			BSV: []byte{
+				0b_0000_0111,
+				0b_0011_0010, 0b_0011_0100, 0b_0011_0010,
+				0b_0000_0111,
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Text: "cs(d 1)",
+			BSV:  []byte{0b_0000_0111, 0b_1000_0001, 0b_0000_0111},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Text: "cs(cs(dz 0x05))",
+			BSV: []byte{
+				0b_0000_0111, 0b_0000_0111,
+				0b_0100_0000, 0b_0000_0101, 0b_0100_0000,
+				0b_0000_0111, 0b_0000_0111,
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Text: "cs(dzz:2 0x01)",
+			BSV: []byte{
+				0b_0000_0111,
+				0b_0000_1001, 0, 0, 1, 0, 0, 0b_0000_1001,
+				0b_0000_0111,
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Text: "cs(sz:2 16)",
+			BSV: []byte{
+				0b_0000_0111,
+				0b_0000_0011, 0, 0b_0000_1111, 0b_0000_0011,
+				0b_0000_0111,
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Text: "cs(cb[ d 1 ])",
+			BSV: []byte{
+				0b_0000_0111,
+				0b_0000_0101, 0b_1000_0000, 0b_1000_0001, 0b_1000_0000, 0b_0000_0101,
+				0b_0000_0111,
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Text: "cs(cb(cs(dz 0x00))[ d 1 ])",
+			BSV: []byte{
+				0b_0000_0111,
+				0b_0000_0101,
+				0b_0000_0111, 0b_0100_0000, 0, 0b_0100_0000, 0b_0000_0111,
+				0b_1000_0001,
+				0b_0000_0111, 0b_0100_0000, 0, 0b_0100_0000, 0b_0000_0111,
+				0b_0000_0101,
+				0b_0000_0111,
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Text: "cs(cu[ d 1, cs(dz 0x05) ])",
+			BSV: []byte{
+				0b_0000_0111,
+				0b_0000_0110,
+				0b_1000_0001,
+				0b_0000_0111, 0b_0100_0000, 0b_0000_0101, 0b_0100_0000, 0b_0000_0111,
+				0b_0000_0100,
+				0b_0000_0111,
+			},
+			Mark: oops.New("unexpected"),
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.Text, func(t *testing.T) {
+			bsv, err := text.Parse(tc.Text)
+			require.NoError(t, err, tc.Mark)
+			require.Equal(t, tc.BSV, bsv, tc.Mark)
+
+			s, err := text.Format(tc.BSV)
+			require.NoError(t, err, tc.Mark)
+			require.Equal(t, tc.Text, s, tc.Mark)
+		})
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		output := &bytes.Buffer{}
+		e := control.NewEncoder(output)
+		require.NoError(t, e.Data([]byte{0xff}))
+		require.NoError(t, e.Data(bytes.Repeat([]byte{1}, 1024)))
+		require.NoError(t, e.Bound(bytes.Repeat([]byte{0b_1000_0001}, 200)))
+		require.NoError(t, e.Skip(1000))
+		require.NoError(t, e.Symmetric(func(e control.Encoder) error {
+			return e.Bound(bytes.Repeat([]byte{0b_1000_0001}, 200))
+		}))
+		require.NoError(t, e.Symmetric(func(e control.Encoder) error {
+			return e.Data(bytes.Repeat([]byte{2}, 100))
+		}))
+		require.NoError(t, e.Symmetric(func(e control.Encoder) error {
+			return e.Skip(3)
+		}))
+
+		seeker := &seekBuffer{}
+		e = control.NewEncoderWithOptions(seeker, control.EncoderOptions{
+			Policy: control.FixedDataSizeSize,
+		})
+		require.NoError(t, e.Symmetric(func(e control.Encoder) error {
+			return e.BoundFunc(func(e control.Encoder) error {
+				return e.Data([]byte{1})
+			})
+		}))
+		require.NoError(t, e.BoundFunc(func(e control.Encoder) error {
+			return e.Data([]byte{1})
+		}))
+
+		for _, input := range [][]byte{output.Bytes(), seeker.data} {
+			s, err := text.Format(input)
+			require.NoError(t, err)
+
+			bsv, err := text.Parse(s)
+			require.NoError(t, err)
+			require.Equal(t, input, bsv, s)
+		}
+	})
+
+	t.Run("parse", func(t *testing.T) {
+		bsv, err := text.Parse(`
+			# Comments and alternate value forms.
+			dz "hi" dzz 258 d 0x7f d1 1
+		`)
+		require.NoError(t, err)
+
+		s, err := text.Format(bsv)
+		require.NoError(t, err)
+		require.Equal(t, "dz 0x6869\ndzz 0x0102\nd 127\nd1 0x0001", s)
+
+		invalid := []string{
+			"x",
+			"d 128",
+			"d1 0x2000",
+			"d2 0x100000",
+			"dz 0x",
+			"dz 0x123",
+			"dzz:9 0x01",
+			"dzz:1 " + "0x" + string(bytes.Repeat([]byte("00"), 257)),
+			"sz 0",
+			"sz:1 257",
+			"cb[]",
+			"cb[ n",
+			"cb(dz 0x01)[ n ]",
+			"cb(cu[])[ n ]",
+			"cs(n",
+			"cu[ n ] ]",
+			`dz "hi`,
+		}
+
+		for _, s := range invalid {
+			_, err := text.Parse(s)
+			require.Error(t, err, s)
+		}
+	})
+}
+
+// seekBuffer is an in memory io.WriteSeeker.
+type seekBuffer struct {
+	data []byte
+	pos  int
+}
+
+func (sb *seekBuffer) Write(p []byte) (n int, err error) {
+	if end := sb.pos + len(p); end > len(sb.data) {
+		sb.data = append(sb.data, make([]byte, end-len(sb.data))...)
+	}
+
+	n = copy(sb.data[sb.pos:], p)
+	sb.pos += n
+
+	return n, nil
+}
+
+func (sb *seekBuffer) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+		sb.pos = int(offset)
+	case io.SeekCurrent:
+		sb.pos += int(offset)
+	case io.SeekEnd:
+		sb.pos = len(sb.data) + int(offset)
+	}
+
+	return int64(sb.pos), nil
+}
@@ -0,0 +1,330 @@
+package control
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// ErrNonCanonical is wrapped by the errors of problems reporting valid but
+// non-canonical fields.
+var ErrNonCanonical = Error.New("non-canonical")
+
+// ValidateOptions configures Validate.
+type ValidateOptions struct {
+	DecoderOptions
+
+	// Canonical also reports fields that are valid, but not encoded with
+	// the minimal block.
+	Canonical bool
+}
+
+// Problem is a field reported by Validate.
+type Problem struct {
+	// Offset of the field's control block.
+	Offset uint64
+
+	// Type is the block type used.
+	Type Type
+
+	// Minimal is the block type that should have been used. It is Unknown
+	// for structurally invalid fields.
+	Minimal Type
+
+	Err error
+}
+
+func (p Problem) Error() string {
+	if p.Minimal == Unknown {
+		return fmt.Sprintf("offset %d: %s: %v", p.Offset, p.Type.Abbr, p.Err)
+	}
+
+	return fmt.Sprintf("offset %d: %s (minimal %s): %v", p.Offset, p.Type.Abbr, p.Minimal.Abbr, p.Err)
+}
+
+func (p Problem) Unwrap() error {
+	return p.Err
+}
+
+// recorder passes reads through to r, optionally keeping a copy of the bytes
+// read. Errors from r other than io.EOF are kept so they can be told apart
+// from invalid input.
+type recorder struct {
+	r io.Reader
+
+	record bool
+	buf    []byte
+
+	err error
+}
+
+func (rr *recorder) Read(p []byte) (n int, err error) {
+	n, err = rr.r.Read(p)
+
+	if rr.record {
+		rr.buf = append(rr.buf, p[:n]...)
+	}
+
+	if err != nil && !errors.Is(err, io.EOF) {
+		rr.err = err
+	}
+
+	return n, err
+}
+
+// minimalData returns the smallest block type able to hold data of the given
+// size with the given first byte.
+func minimalData(size uint64, first byte) Type {
+	switch {
+	case size == 1 && first&Data.Mask == first:
+		return Data
+	case size == 2 && first&Data1.Mask == first:
+		return Data1
+	case size == 3 && first&Data2.Mask == first:
+		return Data2
+	case size <= 64:
+		return DataSize
+	default:
+		return DataSizeSize
+	}
+}
+
+// minimalSize returns the minimal big-endian encoding of the size (which is
+// stored indexed from 1).
+func minimalSize(size uint64) []byte {
+	sb := new(big.Int).SetUint64(size - 1).Bytes()
+	if len(sb) == 0 {
+		sb = []byte{0b_0000_0000}
+	}
+
+	return sb
+}
+
+// Validate walks the BSV in r and reports the fields that are structurally
+// invalid. Decoding can't continue past a structurally invalid field, so at
+// most one is reported and it is always the last problem. If opts.Canonical
+// is set it also reports the fields that are valid, but not canonical. A
+// non-nil error is only returned if reading from r fails.
+func Validate(r io.Reader, opts ValidateOptions) (problems []Problem, err error) {
+	rr := &recorder{r: r}
+	d := NewDecoderWithOptions(rr, opts.DecoderOptions)
+
+	// offset is the start of the field being validated. Fields are read in
+	// full so that a failing Next is at the start of the next field.
+	var offset uint64
+
+	for {
+		offset = d.Consumed()
+		if !d.Next() {
+			break
+		}
+
+		offset = d.Consumed() - 1
+		t := d.Type()
+
+		var p *Problem
+
+		switch t {
+		case ContainerSymmetric, ContainerUnbounded:
+			err = d.Enter()
+		case ContainerBounded:
+			rr.record = true
+			rr.buf = rr.buf[:0]
+
+			_, err = d.Size()
+
+			rr.record = false
+
+			if err == nil && opts.Canonical {
+				p, err = canonicalBound(rr.buf)
+			}
+			if err == nil {
+				err = d.Enter()
+			}
+		case DataSize, Data1, Data2, DataSizeSize:
+			if opts.Canonical {
+				p, err = canonicalData(d)
+			}
+			if err == nil {
+				err = drain(d)
+			}
+		case SkipSize:
+			if opts.Canonical {
+				p, err = canonicalSkip(d)
+			} else {
+				_, err = d.Amount()
+			}
+		}
+
+		if err != nil {
+			break
+		}
+
+		if p != nil {
+			p.Offset = offset
+			problems = append(problems, *p)
+		}
+	}
+
+	if err == nil {
+		err = d.Err()
+	}
+
+	if err != nil {
+		if rr.err != nil {
+			return problems, Error.Trace(rr.err)
+		}
+
+		problems = append(problems, Problem{
+			Offset: offset,
+			Type:   d.Type(),
+			Err:    err,
+		})
+	}
+
+	return problems, nil
+}
+
+// drain reads the rest of the current data field.
+func drain(d Decoder) (err error) {
+	r, _, err := d.DataReader()
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(io.Discard, r)
+
+	return err
+}
+
+func canonicalData(d Decoder) (p *Problem, err error) {
+	t := d.Type()
+
+	before := d.Consumed()
+
+	size, err := d.Size()
+	if err != nil {
+		return nil, err
+	}
+
+	var first byte
+	if size <= 3 {
+		data, err := d.Data()
+		if err != nil {
+			return nil, err
+		}
+
+		first = data[0]
+	}
+
+	minimal := minimalData(size, first)
+	if minimal != t {
+		return &Problem{
+			Type:    t,
+			Minimal: minimal,
+			Err:     Error.New("data size=%d: %w", size, ErrNonCanonical),
+		}, nil
+	}
+
+	if t == DataSizeSize {
+		sizeSize := d.Consumed() - before
+		minimalSizeSize := uint64(len(minimalSize(size)))
+
+		if sizeSize != minimalSizeSize {
+			return &Problem{
+				Type:    t,
+				Minimal: minimal,
+				Err: Error.New(
+					"size uses %d bytes instead of %d: %w",
+					sizeSize, minimalSizeSize, ErrNonCanonical,
+				),
+			}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func canonicalSkip(d Decoder) (p *Problem, err error) {
+	amountSize, err := d.Size()
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := d.Amount()
+	if err != nil {
+		return nil, err
+	}
+
+	minimalAmountSize := uint64(len(minimalSize(amount)))
+	if amountSize != minimalAmountSize {
+		return &Problem{
+			Type:    SkipSize,
+			Minimal: SkipSize,
+			Err: Error.New(
+				"amount uses %d bytes instead of %d: %w",
+				amountSize, minimalAmountSize, ErrNonCanonical,
+			),
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// canonicalBound checks the encoded size field of a bounded container.
+func canonicalBound(field []byte) (p *Problem, err error) {
+	zd := NewBytesDecoder(field)
+
+	if !zd.Next() {
+		return nil, zd.Err()
+	}
+
+	if zd.Type() == ContainerSymmetric {
+		err = zd.Enter()
+		if err != nil {
+			return nil, err
+		}
+
+		if !zd.Next() {
+			return nil, zd.Err()
+		}
+	}
+
+	t := zd.Type()
+
+	sizeBytes, err := zd.Data()
+	if err != nil {
+		return nil, err
+	}
+
+	size := new(big.Int).SetBytes(sizeBytes)
+	size.Add(size, big.NewInt(1))
+
+	minimalBytes := minimalSize(size.Uint64())
+	minimal := minimalData(uint64(len(minimalBytes)), minimalBytes[0])
+
+	if len(sizeBytes) != len(minimalBytes) {
+		return &Problem{
+			Type:    t,
+			Minimal: minimal,
+			Err: Error.New(
+				"container bounded size=%d uses %d bytes instead of %d: %w",
+				size, len(sizeBytes), len(minimalBytes), ErrNonCanonical,
+			),
+		}, nil
+	}
+
+	if t != minimal {
+		return &Problem{
+			Type:    t,
+			Minimal: minimal,
+			Err: Error.New(
+				"container bounded size=%d uses %s instead of %s: %w",
+				size, t.Abbr, minimal.Abbr, ErrNonCanonical,
+			),
+		}, nil
+	}
+
+	return nil, nil
+}
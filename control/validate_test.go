@@ -0,0 +1,216 @@
+package control_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calebcase/bsv/control"
+	"github.com/calebcase/oops"
+)
+
+func TestValidate(t *testing.T) {
+	type Expected struct {
+		Offset  uint64
+		Type    control.Type
+		Minimal control.Type
+	}
+
+	type TC struct {
+		Input    []byte
+		Expected []Expected
+		Mark     error
+	}
+
+	canonical := &bytes.Buffer{}
+	e := control.NewEncoder(canonical)
+	require.NoError(t, e.Data([]byte{1}))
+	require.NoError(t, e.Data([]byte{0xff}))
+	require.NoError(t, e.Data([]byte{1, 2}))
+	require.NoError(t, e.Data([]byte{0xff, 2}))
+	require.NoError(t, e.Data([]byte{1, 2, 3}))
+	require.NoError(t, e.Data(bytes.Repeat([]byte{1}, 64)))
+	require.NoError(t, e.Data(bytes.Repeat([]byte{1}, 1024)))
+	require.NoError(t, e.Bound(bytes.Repeat([]byte{0b_1000_0001}, 200)))
+	require.NoError(t, e.Skip(10))
+	require.NoError(t, e.Skip(1000))
+	require.NoError(t, e.Symmetric(func(e control.Encoder) error {
+		return e.Bound([]byte{0b_1000_0001})
+	}))
+	require.NoError(t, e.Unbound(func(e control.Encoder) error {
+		return e.Data([]byte{0xff, 0xff, 0xff})
+	}))
+
+	bound := &seekBuffer{}
+	e = control.NewEncoder(bound)
+	require.NoError(t, e.BoundFunc(func(e control.Encoder) error {
+		return e.Null()
+	}))
+
+	tcs := []TC{
+		{
+			Input: canonical.Bytes(),
+			Mark:  oops.New("unexpected"),
+		},
+		{
+			Input: []byte{0b_0100_0000, 0b_0000_0101},
+			Expected: []Expected{
+				{0, control.DataSize, control.Data},
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Input: []byte{0b_0100_0001, 0b_0000_0001, 0b_0000_0010},
+			Expected: []Expected{
+				{0, control.DataSize, control.Data1},
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Input: []byte{0b_0000_1000, 0b_0000_0010, 0b_0000_0001, 0b_0000_0010, 0b_0000_0011},
+			Expected: []Expected{
+				{0, control.DataSizeSize, control.Data2},
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Input: append(
+				[]byte{0b_0000_1001, 0b_0000_0000, 0b_0100_0000},
+				bytes.Repeat([]byte{1}, 65)...,
+			),
+			Expected: []Expected{
+				{0, control.DataSizeSize, control.DataSizeSize},
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Input: []byte{0b_0000_0011, 0b_0000_0000, 0b_0000_0101},
+			Expected: []Expected{
+				{0, control.SkipSize, control.SkipSize},
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Input: bound.Bytes(),
+			Expected: []Expected{
+				{0, control.DataSize, control.Data},
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Input: []byte{
+				0b_0000_0110,
+				0b_1000_0001,
+				0b_0100_0000, 0b_0000_0101,
+				0b_0000_0100,
+			},
+			Expected: []Expected{
+				{2, control.DataSize, control.Data},
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Input: []byte{
+				0b_0000_0111,
+				0b_0100_0000, 0b_0000_0101, 0b_0100_0000,
+				0b_0000_0111,
+			},
+			Expected: []Expected{
+				{1, control.DataSize, control.Data},
+			},
+			Mark: oops.New("unexpected"),
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(shortName(i, tc.Input), func(t *testing.T) {
+			problems, err := control.Validate(bytes.NewReader(tc.Input), control.ValidateOptions{})
+			require.NoError(t, err, tc.Mark)
+			require.Empty(t, problems, tc.Mark)
+
+			problems, err = control.Validate(bytes.NewReader(tc.Input), control.ValidateOptions{
+				Canonical: true,
+			})
+			require.NoError(t, err, tc.Mark)
+			require.Len(t, problems, len(tc.Expected), tc.Mark)
+
+			for j, p := range problems {
+				require.Equal(t, tc.Expected[j].Offset, p.Offset, tc.Mark)
+				require.Equal(t, tc.Expected[j].Type, p.Type, tc.Mark)
+				require.Equal(t, tc.Expected[j].Minimal, p.Minimal, tc.Mark)
+				require.True(t, errors.Is(p, control.ErrNonCanonical), tc.Mark)
+			}
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		type TC struct {
+			Input  []byte
+			Offset uint64
+			Type   control.Type
+		}
+
+		tcs := []TC{
+			{
+				Input:  []byte{0b_0000_0100},
+				Offset: 2,
+				Type:   control.Unknown,
+			},
+			{
+				Input:  []byte{0b_0100_0011, 0b_0000_0001},
+				Offset: 2,
+				Type:   control.DataSize,
+			},
+			{
+				Input:  []byte{0b_0000_0101, 0b_1000_0000, 0b_0100_0001, 0b_0000_0001},
+				Offset: 4,
+				Type:   control.DataSize,
+			},
+			{
+				Input:  []byte{0b_0000_0011, 0b_0000_0001},
+				Offset: 2,
+				Type:   control.SkipSize,
+			},
+		}
+
+		for _, tc := range tcs {
+			mark := oops.New("unexpected: %x", tc.Input)
+
+			for _, canonical := range []bool{false, true} {
+				problems, err := control.Validate(
+					bytes.NewReader(append([]byte{0b_0100_0000, 0b_0000_0101}, tc.Input...)),
+					control.ValidateOptions{Canonical: canonical},
+				)
+				require.NoError(t, err, mark)
+
+				p := problems[len(problems)-1]
+				require.Equal(t, tc.Offset, p.Offset, mark)
+				require.Equal(t, tc.Type, p.Type, mark)
+				require.Equal(t, control.Unknown, p.Minimal, mark)
+				require.Error(t, p.Err, mark)
+				require.False(t, errors.Is(p, control.ErrNonCanonical), mark)
+			}
+		}
+	})
+
+	t.Run("bounded size block", func(t *testing.T) {
+		input := []byte{0b_0000_0101, 0b_0100_0000, 0b_0000_0000, 0b_1000_0001}
+
+		problems, err := control.Validate(bytes.NewReader(input), control.ValidateOptions{
+			Canonical: true,
+		})
+		require.NoError(t, err)
+		require.Len(t, problems, 1)
+		require.Contains(t, problems[0].Error(), "uses dz instead of d")
+	})
+
+	t.Run("read error", func(t *testing.T) {
+		readErr := errors.New("read error")
+
+		_, err := control.Validate(iotest.ErrReader(readErr), control.ValidateOptions{})
+		require.True(t, errors.Is(err, readErr))
+	})
+}
@@ -1,6 +1,8 @@
 package decimal
 
 import (
+	"io"
+
 	"github.com/calebcase/bsv/control"
 	"github.com/calebcase/bsv/integer"
 )
@@ -24,11 +26,11 @@ type Schema struct {
 // Decoder is a decoder.
 type Decoder struct {
 	schema Schema
-	cd     *control.Decoder
+	cd     control.Decoder
 }
 
 // NewDecoder returns a new decoder.
-func NewDecoder(schema Schema, cd *control.Decoder) *Decoder {
+func NewDecoder(schema Schema, cd control.Decoder) *Decoder {
 	return &Decoder{
 		schema: schema,
 		cd:     cd,
@@ -39,16 +41,23 @@ func NewDecoder(schema Schema, cd *control.Decoder) *Decoder {
 func (d *Decoder) Decode(b *Block) (err error) {
 	defer Error.WrapP(&err)
 
-	cb := &control.Block{}
+	if !d.cd.Next() {
+		err = d.cd.Err()
+		if err != nil {
+			return err
+		}
+
+		return io.EOF
+	}
 
-	err = d.cd.Decode(cb)
+	raw, err := d.cd.Data()
 	if err != nil {
 		return err
 	}
 
-	b.ScaleSize = cb.Data[len(cb.Data)-1] & 0b0000_0011
+	b.ScaleSize = raw[len(raw)-1] & 0b0000_0011
 
-	data := cb.Data[:len(cb.Data)-1]
+	data := raw[:len(raw)-1]
 
 	switch {
 	case b.ScaleSize == 0b00:
@@ -126,11 +135,11 @@ func (d *Decoder) DecodeBigInt(i *big.Int) (err error) {
 // Encoder is an encoder.
 type Encoder struct {
 	schema Schema
-	blk    *control.Encoder
+	blk    control.Encoder
 }
 
 // NewEncoder returns a new encoder.
-func NewEncoder(schema Schema, blk *control.Encoder) *Encoder {
+func NewEncoder(schema Schema, blk control.Encoder) *Encoder {
 	return &Encoder{
 		schema: schema,
 		blk:    blk,
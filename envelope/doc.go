@@ -0,0 +1,24 @@
+// Package envelope provides a self-describing file format for BSV.
+//
+// An envelope is itself BSV. It starts with a header identifying the file and
+// describing its contents followed by the body:
+//
+//	dz 0x89425356         magic ("\x89BSV")
+//	d  major              version
+//	d  minor
+//	cb[ key value ... ]   metadata (n if there is none)
+//	cu[ column ... ]      schema (n if there is none)
+//	...                   body
+//
+// The high bit of the magic catches files that have passed through 7-bit
+// channels. Metadata keys and values are data fields (e for the empty
+// string). Keys are written in sorted order so that the same header always
+// encodes to the same bytes.
+//
+// A sync marker (see control.Recover) is stored in the metadata with the
+// reserved key "bsv.sync". Readers skip the marker fields in the body.
+//
+// Readers reject envelopes with a major version they don't know. Minor
+// versions only add metadata keys and so can be read by any reader of the
+// same major version.
+package envelope
@@ -0,0 +1,402 @@
+package envelope
+
+import (
+	"bytes"
+	"io"
+	"sort"
+
+	"github.com/calebcase/bsv/control"
+	"github.com/calebcase/bsv/schema"
+)
+
+// Magic is the data of the first field of every envelope.
+var Magic = []byte{0x89, 'B', 'S', 'V'}
+
+var (
+	// ErrMagic is returned when the input doesn't start with Magic.
+	ErrMagic = Error.New("not a bsv envelope")
+
+	// ErrUnsupportedVersion is returned when the envelope's major version
+	// is unknown.
+	ErrUnsupportedVersion = Error.New("unsupported version")
+)
+
+// Version is the version of the envelope format.
+type Version struct {
+	Major uint8
+	Minor uint8
+}
+
+// Current is the version written by this package.
+var Current = Version{Major: 1, Minor: 1}
+
+// SyncKey is the reserved metadata key holding the sync marker.
+const SyncKey = "bsv.sync"
+
+// DefaultSyncInterval is the number of body fields between sync markers when
+// the header doesn't set one.
+const DefaultSyncInterval = 1024
+
+// Header describes the body of an envelope.
+type Header struct {
+	// Version is the version of the envelope. It is set by the reader and
+	// ignored by the writer (which always writes Current).
+	Version Version
+
+	// Metadata is a set of application defined key/value pairs.
+	Metadata map[string]string
+
+	// Schema is the optional schema of the body.
+	Schema *schema.Schema
+
+	// Sync is the optional sync marker (see control.NewSyncMarker). When
+	// it is set the writer writes it after every SyncInterval body fields
+	// and the reader skips it. It is stored in the metadata with SyncKey.
+	Sync []byte
+
+	// SyncInterval is the number of body fields between sync markers. It
+	// is only used by the writer and defaults to DefaultSyncInterval.
+	SyncInterval int
+}
+
+// Writer writes the body of an envelope.
+type Writer struct {
+	control.Encoder
+
+	Header Header
+}
+
+// NewWriter writes the envelope header to w and returns a writer for the body.
+func NewWriter(w io.Writer, h Header) (*Writer, error) {
+	return NewWriterWithOptions(w, h, control.EncoderOptions{})
+}
+
+// NewWriterWithOptions is like NewWriter, but the body is encoded with opts.
+// The header is always encoded compactly.
+func NewWriterWithOptions(w io.Writer, h Header, opts control.EncoderOptions) (_ *Writer, err error) {
+	defer Error.WrapP(&err)
+
+	h.Version = Current
+
+	if _, ok := h.Metadata[SyncKey]; ok {
+		return nil, Error.New("invalid metadata: reserved key %q", SyncKey)
+	}
+
+	err = writeHeader(control.NewEncoder(w), h)
+	if err != nil {
+		return nil, err
+	}
+
+	e := control.NewEncoderWithOptions(w, opts)
+
+	if len(h.Sync) != 0 {
+		if h.SyncInterval <= 0 {
+			h.SyncInterval = DefaultSyncInterval
+		}
+
+		e = control.NewSyncEncoder(e, h.Sync, h.SyncInterval)
+	}
+
+	return &Writer{
+		Encoder: e,
+		Header:  h,
+	}, nil
+}
+
+// writeData writes data as a data field or an empty field if there is no
+// data.
+func writeData(e control.Encoder, data []byte) (err error) {
+	if len(data) == 0 {
+		return e.Empty()
+	}
+
+	return e.Data(data)
+}
+
+func writeHeader(e control.Encoder, h Header) (err error) {
+	err = e.Data(Magic)
+	if err != nil {
+		return err
+	}
+
+	err = e.Data([]byte{h.Version.Major})
+	if err != nil {
+		return err
+	}
+
+	err = e.Data([]byte{h.Version.Minor})
+	if err != nil {
+		return err
+	}
+
+	metadata := h.Metadata
+	if len(h.Sync) != 0 {
+		if len(h.Sync) != control.SyncMarkerSize {
+			return Error.New("invalid sync marker size: %d", len(h.Sync))
+		}
+
+		metadata = map[string]string{
+			SyncKey: string(h.Sync),
+		}
+		for k, v := range h.Metadata {
+			metadata[k] = v
+		}
+	}
+
+	if len(metadata) == 0 {
+		err = e.Null()
+	} else {
+		keys := make([]string, 0, len(metadata))
+		for k := range metadata {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		buf := &bytes.Buffer{}
+		me := control.NewEncoder(buf)
+
+		for _, k := range keys {
+			err = writeData(me, []byte(k))
+			if err != nil {
+				return err
+			}
+
+			err = writeData(me, []byte(metadata[k]))
+			if err != nil {
+				return err
+			}
+		}
+
+		err = e.Bound(buf.Bytes())
+	}
+	if err != nil {
+		return err
+	}
+
+	if h.Schema == nil {
+		return e.Null()
+	}
+
+	return h.Schema.Encode(e)
+}
+
+// Reader reads the body of an envelope.
+type Reader struct {
+	control.Decoder
+
+	Header Header
+}
+
+// NewReader reads the envelope header from r and returns a reader for the
+// body.
+func NewReader(r io.Reader) (*Reader, error) {
+	return NewReaderWithOptions(r, control.DecoderOptions{})
+}
+
+// NewReaderWithOptions is like NewReader, but the envelope is decoded with the
+// limits in opts.
+func NewReaderWithOptions(r io.Reader, opts control.DecoderOptions) (_ *Reader, err error) {
+	defer Error.WrapP(&err)
+
+	d := control.NewDecoderWithOptions(r, opts)
+
+	h, err := readHeader(d)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(h.Sync) != 0 {
+		d = control.NewSyncDecoder(d, h.Sync)
+	}
+
+	return &Reader{
+		Decoder: d,
+		Header:  h,
+	}, nil
+}
+
+// Recover reads the envelope header from r and returns a control.Recovery for
+// the body. The header must be intact and have a sync marker.
+func Recover(r io.Reader) (h Header, rc *control.Recovery, err error) {
+	defer Error.WrapP(&err)
+
+	h, err = readHeader(control.NewDecoder(r))
+	if err != nil {
+		return h, nil, err
+	}
+
+	if len(h.Sync) == 0 {
+		return h, nil, Error.New("envelope has no sync marker")
+	}
+
+	return h, control.Recover(r, h.Sync), nil
+}
+
+// next moves to the next header field.
+func next(d control.Decoder, name string) (err error) {
+	if d.Next() {
+		return nil
+	}
+
+	err = d.Err()
+	if err != nil {
+		return err
+	}
+
+	return Error.New("unexpected end of input (missing %s)", name)
+}
+
+// readData reads a data or empty field.
+func readData(d control.Decoder) (data []byte, err error) {
+	if d.Type() == control.Empty {
+		return []byte{}, nil
+	}
+
+	data, err = d.Data()
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte{}, data...), nil
+}
+
+// readUint8 reads a data field holding a value less than 256.
+func readUint8(d control.Decoder, name string) (v uint8, err error) {
+	err = next(d, name)
+	if err != nil {
+		return 0, err
+	}
+
+	data, err := d.Data()
+	if err != nil {
+		return 0, err
+	}
+
+	for len(data) > 1 && data[0] == 0 {
+		data = data[1:]
+	}
+
+	if len(data) != 1 {
+		return 0, Error.New("invalid %s: %x", name, data)
+	}
+
+	return data[0], nil
+}
+
+func readHeader(d control.Decoder) (h Header, err error) {
+	if !d.Next() || d.Type() != control.DataSize {
+		return h, ErrMagic
+	}
+
+	data, err := d.Data()
+	if err != nil || !bytes.Equal(data, Magic) {
+		return h, ErrMagic
+	}
+
+	h.Version.Major, err = readUint8(d, "major version")
+	if err != nil {
+		return h, err
+	}
+
+	h.Version.Minor, err = readUint8(d, "minor version")
+	if err != nil {
+		return h, err
+	}
+
+	if h.Version.Major != Current.Major {
+		return h, Error.New("%w: %d.%d", ErrUnsupportedVersion, h.Version.Major, h.Version.Minor)
+	}
+
+	err = next(d, "metadata")
+	if err != nil {
+		return h, err
+	}
+
+	switch d.Type() {
+	case control.Null:
+	case control.ContainerBounded:
+		h.Metadata, err = readMetadata(d)
+		if err != nil {
+			return h, err
+		}
+
+		if sync, ok := h.Metadata[SyncKey]; ok {
+			if len(sync) != control.SyncMarkerSize {
+				return h, Error.New("invalid sync marker size: %d", len(sync))
+			}
+
+			h.Sync = []byte(sync)
+
+			delete(h.Metadata, SyncKey)
+			if len(h.Metadata) == 0 {
+				h.Metadata = nil
+			}
+		}
+	default:
+		return h, Error.New("invalid metadata: %s", d.Type().Abbr)
+	}
+
+	err = next(d, "schema")
+	if err != nil {
+		return h, err
+	}
+
+	if d.Type() == control.Null {
+		return h, nil
+	}
+
+	s := schema.Schema{}
+
+	err = s.Decode(d)
+	if err != nil {
+		return h, err
+	}
+
+	h.Schema = &s
+
+	return h, nil
+}
+
+func readMetadata(d control.Decoder) (metadata map[string]string, err error) {
+	bsv, err := d.BSV()
+	if err != nil {
+		return nil, err
+	}
+
+	md := control.NewBytesDecoder(bsv)
+
+	metadata = map[string]string{}
+
+	for md.Next() {
+		key, err := readData(md)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := metadata[string(key)]; ok {
+			return nil, Error.New("invalid metadata: duplicate key %q", key)
+		}
+
+		if !md.Next() {
+			if md.Err() != nil {
+				return nil, md.Err()
+			}
+
+			return nil, Error.New("invalid metadata: missing value for key %q", key)
+		}
+
+		value, err := readData(md)
+		if err != nil {
+			return nil, err
+		}
+
+		metadata[string(key)] = string(value)
+	}
+
+	err = md.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return metadata, nil
+}
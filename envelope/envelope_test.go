@@ -0,0 +1,284 @@
+package envelope_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calebcase/bsv/control"
+	"github.com/calebcase/bsv/envelope"
+	"github.com/calebcase/bsv/schema"
+	"github.com/calebcase/oops"
+)
+
+func TestRoundTrip(t *testing.T) {
+	type TC struct {
+		Header envelope.Header
+		Mark   error
+	}
+
+	tcs := []TC{
+		{
+			Header: envelope.Header{},
+			Mark:   oops.New("unexpected"),
+		},
+		{
+			Header: envelope.Header{
+				Metadata: map[string]string{
+					"created": "2022-01-02T03:04:05Z",
+					"a":       "1",
+					"":        "empty key",
+					"empty":   "",
+				},
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Header: envelope.Header{
+				Schema: &schema.Schema{},
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Header: envelope.Header{
+				Metadata: map[string]string{
+					"name": "events",
+				},
+				Schema: &schema.Schema{{}, {}, {}},
+			},
+			Mark: oops.New("unexpected"),
+		},
+	}
+
+	for i, tc := range tcs {
+		tc := tc
+
+		t.Run(string(rune('a'+i)), func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			w, err := envelope.NewWriter(buf, tc.Header)
+			require.NoError(t, err, tc.Mark)
+
+			err = w.Data([]byte("body"))
+			require.NoError(t, err, tc.Mark)
+
+			err = w.Null()
+			require.NoError(t, err, tc.Mark)
+
+			// The envelope is valid BSV.
+			problems, err := control.Validate(bytes.NewReader(buf.Bytes()), control.ValidateOptions{Canonical: true})
+			require.NoError(t, err, tc.Mark)
+			require.Empty(t, problems, tc.Mark)
+
+			r, err := envelope.NewReader(buf)
+			require.NoError(t, err, tc.Mark)
+
+			expected := tc.Header
+			expected.Version = envelope.Current
+			require.Equal(t, expected, r.Header, tc.Mark)
+
+			require.True(t, r.Next(), tc.Mark)
+			data, err := r.Data()
+			require.NoError(t, err, tc.Mark)
+			require.Equal(t, []byte("body"), data, tc.Mark)
+
+			require.True(t, r.Next(), tc.Mark)
+			require.Equal(t, control.Null, r.Type(), tc.Mark)
+
+			require.False(t, r.Next(), tc.Mark)
+			require.NoError(t, r.Err(), tc.Mark)
+		})
+	}
+}
+
+func TestDeterministic(t *testing.T) {
+	h := envelope.Header{
+		Metadata: map[string]string{},
+	}
+	for _, k := range []string{"z", "y", "x", "w", "v", "u"} {
+		h.Metadata[k] = k
+	}
+
+	var expected []byte
+	for i := 0; i < 10; i++ {
+		buf := &bytes.Buffer{}
+
+		_, err := envelope.NewWriter(buf, h)
+		require.NoError(t, err)
+
+		if expected == nil {
+			expected = buf.Bytes()
+		}
+
+		require.Equal(t, expected, buf.Bytes())
+	}
+}
+
+func TestWriterOptions(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	w, err := envelope.NewWriterWithOptions(buf, envelope.Header{}, control.EncoderOptions{
+		Policy: control.FixedDataSizeSize,
+	})
+	require.NoError(t, err)
+
+	err = w.Data([]byte{1})
+	require.NoError(t, err)
+
+	r, err := envelope.NewReader(buf)
+	require.NoError(t, err)
+
+	require.True(t, r.Next())
+	require.Equal(t, control.DataSizeSize, r.Type())
+}
+
+func TestReaderErrors(t *testing.T) {
+	header := func(h envelope.Header) []byte {
+		buf := &bytes.Buffer{}
+
+		_, err := envelope.NewWriter(buf, h)
+		require.NoError(t, err)
+
+		return buf.Bytes()
+	}
+
+	valid := header(envelope.Header{})
+
+	type TC struct {
+		Input []byte
+		Is    error
+		Mark  error
+	}
+
+	tcs := []TC{
+		{
+			Input: nil,
+			Is:    envelope.ErrMagic,
+			Mark:  oops.New("unexpected"),
+		},
+		{
+			Input: []byte("PK\x03\x04"),
+			Is:    envelope.ErrMagic,
+			Mark:  oops.New("unexpected"),
+		},
+		{
+			Input: []byte{0b_0100_0011, 0x89, 'B', 'S', 'X'},
+			Is:    envelope.ErrMagic,
+			Mark:  oops.New("unexpected"),
+		},
+		{
+			// Major version 2.
+			Input: []byte{0b_0100_0011, 0x89, 'B', 'S', 'V', 0b_1000_0010, 0b_1000_0000, 0, 0},
+			Is:    envelope.ErrUnsupportedVersion,
+			Mark:  oops.New("unexpected"),
+		},
+		{
+			// Truncated.
+			Input: valid[:len(valid)-1],
+			Mark:  oops.New("unexpected"),
+		},
+		{
+			// Metadata key without a value.
+			Input: append(append([]byte{}, valid[:7]...), 0b_0000_0101, 0b_1000_0000, 0b_1110_0001, 0),
+			Mark:  oops.New("unexpected"),
+		},
+	}
+
+	for _, tc := range tcs {
+		_, err := envelope.NewReader(bytes.NewReader(tc.Input))
+		require.Error(t, err, tc.Mark)
+
+		if tc.Is != nil {
+			require.True(t, errors.Is(err, tc.Is), tc.Mark)
+		}
+	}
+
+	// A newer minor version can be read.
+	minor := append([]byte{}, valid...)
+	minor[6] = 0b_1000_0111
+
+	r, err := envelope.NewReader(bytes.NewReader(minor))
+	require.NoError(t, err)
+	require.Equal(t, envelope.Version{Major: 1, Minor: 7}, r.Header.Version)
+}
+
+func TestSync(t *testing.T) {
+	marker, err := control.NewSyncMarker()
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+
+	w, err := envelope.NewWriter(buf, envelope.Header{
+		Metadata:     map[string]string{"name": "events"},
+		Sync:         marker,
+		SyncInterval: 2,
+	})
+	require.NoError(t, err)
+
+	start := buf.Len()
+
+	for i := 0; i < 5; i++ {
+		err = w.Data(bytes.Repeat([]byte{byte(i)}, 10))
+		require.NoError(t, err)
+	}
+
+	input := buf.Bytes()
+
+	r, err := envelope.NewReader(bytes.NewReader(input))
+	require.NoError(t, err)
+	require.Equal(t, marker, r.Header.Sync)
+	require.Equal(t, map[string]string{"name": "events"}, r.Header.Metadata)
+
+	for i := 0; i < 5; i++ {
+		require.True(t, r.Next())
+
+		data, err := r.Data()
+		require.NoError(t, err)
+		require.Equal(t, bytes.Repeat([]byte{byte(i)}, 10), data)
+	}
+	require.False(t, r.Next())
+	require.NoError(t, r.Err())
+
+	// Corrupt the first body field and recover the rest.
+	corrupt := append([]byte{}, input...)
+	corrupt[start] = 0b_0000_0100
+
+	h, rc, err := envelope.Recover(bytes.NewReader(corrupt))
+	require.NoError(t, err)
+	require.Equal(t, marker, h.Sync)
+
+	var values []byte
+	for rc.Next() {
+		d := rc.Decoder()
+		require.True(t, d.Next())
+
+		data, err := d.Data()
+		require.NoError(t, err)
+
+		values = append(values, data[0])
+	}
+	require.NoError(t, rc.Err())
+	require.Equal(t, []byte{2, 3, 4}, values)
+	require.Len(t, rc.Skipped(), 1)
+
+	// Recovery needs a sync marker.
+	plain := &bytes.Buffer{}
+
+	_, err = envelope.NewWriter(plain, envelope.Header{})
+	require.NoError(t, err)
+
+	_, _, err = envelope.Recover(plain)
+	require.Error(t, err)
+
+	_, err = envelope.NewWriter(&bytes.Buffer{}, envelope.Header{
+		Metadata: map[string]string{envelope.SyncKey: "x"},
+	})
+	require.Error(t, err)
+
+	_, err = envelope.NewWriter(&bytes.Buffer{}, envelope.Header{
+		Sync: []byte{1, 2, 3},
+	})
+	require.Error(t, err)
+}
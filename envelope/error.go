@@ -0,0 +1,6 @@
+package envelope
+
+import "github.com/zeebo/errs"
+
+// Error is the class for this package's errors.
+var Error = errs.Class("envelope")
@@ -0,0 +1,530 @@
+// Package footer provides a trailer for files of records with statistics and
+// an index of the records.
+//
+// Each record is written as a bounded container whose top level fields are the
+// record's columns. The footer is written last as a symmetric bounded
+// container so it can be read from the end of the file without scanning the
+// records:
+//
+//	1                            version
+//	rows
+//	cb[ index entries ... ]      control.Index.WriteTo (n if there are no rows)
+//	cb[ cb[ count nulls min max ] ... ]
+//	                             column statistics (n if there are no columns)
+//
+// The version, rows, count and nulls are minimal big-endian integers written
+// as data fields with whichever data block fits them best. The min and max of
+// a column are n if the column has no data values.
+package footer
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/calebcase/bsv/control"
+	"github.com/calebcase/bsv/internal/wire"
+)
+
+const version = 1
+
+// Column holds the statistics of a column.
+type Column struct {
+	// Count is the number of records with the column, including those
+	// where it is null.
+	Count uint64
+
+	// Nulls is the number of null values in the column.
+	Nulls uint64
+
+	// Min and Max are the smallest and largest data values in the column.
+	// Empty fields are empty data values. They are nil if the column has
+	// no data values.
+	Min []byte
+	Max []byte
+}
+
+// Footer describes the records in a file.
+type Footer struct {
+	// Offset is the position of the footer in the file (and so the end of
+	// the records). It is set by Open.
+	Offset uint64
+
+	// Rows is the number of records.
+	Rows uint64
+
+	// Index has an entry for each record.
+	Index control.Index
+
+	// Columns has the statistics for each column.
+	Columns []Column
+}
+
+// Writer writes records and accumulates the statistics for the footer.
+type Writer struct {
+	// Compare orders data values to find the min and max of each column.
+	// It defaults to bytes.Compare.
+	Compare func(a, b []byte) int
+
+	cw *wire.CountingWriter
+	e  control.Encoder
+
+	offset uint64
+
+	buf    bytes.Buffer
+	footer Footer
+	closed bool
+}
+
+// NewWriter returns a writer that writes records to w. The offset is the
+// position in the file of the first byte written to w and is added to the
+// offsets in the index.
+func NewWriter(w io.Writer, offset uint64) *Writer {
+	cw := &wire.CountingWriter{W: w}
+
+	return &Writer{
+		Compare: bytes.Compare,
+		cw:      cw,
+		e:       control.NewEncoder(cw),
+		offset:  offset,
+	}
+}
+
+// Record writes a record with the fields fn encodes. Each top level field is a
+// column.
+func (fw *Writer) Record(fn func(e control.Encoder) error) (err error) {
+	defer Error.WrapP(&err)
+
+	if fw.closed {
+		return Error.New("writer closed")
+	}
+
+	fw.buf.Reset()
+
+	err = fn(control.NewEncoder(&fw.buf))
+	if err != nil {
+		return err
+	}
+
+	if fw.buf.Len() == 0 {
+		return Error.New("empty record")
+	}
+
+	var columns []Column
+
+	d := control.NewBytesDecoder(fw.buf.Bytes())
+	for i := 0; d.Next(); i++ {
+		var c Column
+		if i < len(fw.footer.Columns) {
+			c = fw.footer.Columns[i]
+		}
+
+		c.Count++
+
+		switch d.Type() {
+		case control.Null:
+			c.Nulls++
+		case control.Empty:
+			fw.observe(&c, []byte{})
+		case control.Data,
+			control.Data1,
+			control.Data2,
+			control.DataSize,
+			control.DataSizeSize:
+
+			data, err := d.Data()
+			if err != nil {
+				return err
+			}
+
+			fw.observe(&c, data)
+		}
+
+		columns = append(columns, c)
+	}
+
+	err = d.Err()
+	if err != nil {
+		return err
+	}
+
+	start := uint64(fw.cw.N)
+
+	err = fw.e.Bound(fw.buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	// Only update the statistics once the record has been written.
+	for i, c := range columns {
+		if i < len(fw.footer.Columns) {
+			fw.footer.Columns[i] = c
+		} else {
+			fw.footer.Columns = append(fw.footer.Columns, c)
+		}
+	}
+
+	fw.footer.Rows++
+	fw.footer.Index = append(fw.footer.Index, control.Entry{
+		Offset: fw.offset + start,
+		Type:   control.ContainerBounded,
+		Length: uint64(fw.cw.N) - start,
+	})
+
+	return nil
+}
+
+// observe updates the min and max of the column with the value.
+func (fw *Writer) observe(c *Column, value []byte) {
+	if c.Min == nil || fw.Compare(value, c.Min) < 0 {
+		c.Min = append([]byte{}, value...)
+	}
+
+	if c.Max == nil || fw.Compare(value, c.Max) > 0 {
+		c.Max = append([]byte{}, value...)
+	}
+}
+
+// Footer returns the footer for the records written so far.
+func (fw *Writer) Footer() Footer {
+	return fw.footer
+}
+
+// Close writes the footer. No more records can be written after it is closed.
+func (fw *Writer) Close() (err error) {
+	defer Error.WrapP(&err)
+
+	if fw.closed {
+		return Error.New("writer closed")
+	}
+
+	fw.closed = true
+
+	fw.footer.Offset = fw.offset + uint64(fw.cw.N)
+
+	bsv, err := encode(fw.footer)
+	if err != nil {
+		return err
+	}
+
+	return fw.e.Symmetric(func(se control.Encoder) error {
+		return se.Bound(bsv)
+	})
+}
+
+// writeValue writes a min or max value.
+func writeValue(e control.Encoder, value []byte) (err error) {
+	switch {
+	case value == nil:
+		return e.Null()
+	case len(value) == 0:
+		return e.Empty()
+	}
+
+	return e.Data(value)
+}
+
+func encodeColumn(e control.Encoder, c Column) (err error) {
+	err = e.Data(wire.UintBytes(c.Count))
+	if err != nil {
+		return err
+	}
+
+	err = e.Data(wire.UintBytes(c.Nulls))
+	if err != nil {
+		return err
+	}
+
+	err = writeValue(e, c.Min)
+	if err != nil {
+		return err
+	}
+
+	return writeValue(e, c.Max)
+}
+
+func encode(f Footer) (bsv []byte, err error) {
+	buf := &bytes.Buffer{}
+	e := control.NewEncoder(buf)
+
+	err = e.Data([]byte{version})
+	if err != nil {
+		return nil, err
+	}
+
+	err = e.Data(wire.UintBytes(f.Rows))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(f.Index) == 0 {
+		err = e.Null()
+	} else {
+		idx := &bytes.Buffer{}
+
+		_, err = f.Index.WriteTo(idx)
+		if err != nil {
+			return nil, err
+		}
+
+		err = e.Bound(idx.Bytes())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(f.Columns) == 0 {
+		err = e.Null()
+	} else {
+		columns := &bytes.Buffer{}
+		ce := control.NewEncoder(columns)
+
+		column := &bytes.Buffer{}
+
+		for _, c := range f.Columns {
+			column.Reset()
+
+			err = encodeColumn(control.NewEncoder(column), c)
+			if err != nil {
+				return nil, err
+			}
+
+			err = ce.Bound(column.Bytes())
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		err = e.Bound(columns.Bytes())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Open reads the footer from the end of the file in r.
+func Open(r io.ReaderAt, size int64) (f *Footer, err error) {
+	defer Error.WrapP(&err)
+
+	// Find the start of the footer by skipping it in reverse.
+	rd := control.NewReverseDecoder(r, size)
+	if !rd.Next() {
+		if rd.Err() != nil {
+			return nil, rd.Err()
+		}
+
+		return nil, Error.New("missing footer")
+	}
+
+	if rd.Type() != control.ContainerSymmetric {
+		return nil, Error.New("invalid footer: %s", rd.Type().Abbr)
+	}
+
+	err = rd.Seek()
+	if err != nil {
+		return nil, err
+	}
+
+	offset := uint64(size) - rd.Consumed()
+
+	field := make([]byte, rd.Consumed())
+
+	_, err = r.ReadAt(field, int64(offset))
+	if err != nil {
+		return nil, err
+	}
+
+	d := control.NewBytesDecoder(field)
+	if !d.Next() || d.Type() != control.ContainerSymmetric {
+		return nil, Error.New("invalid footer")
+	}
+
+	err = d.Enter()
+	if err != nil {
+		return nil, err
+	}
+
+	if !d.Next() || d.Type() != control.ContainerBounded {
+		if d.Err() != nil {
+			return nil, d.Err()
+		}
+
+		return nil, Error.New("invalid footer: expected cb")
+	}
+
+	bsv, err := d.BSV()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err = decode(bsv)
+	if err != nil {
+		return nil, err
+	}
+
+	f.Offset = offset
+
+	return f, nil
+}
+
+// field is a decoded footer field. The data of a bounded container is its
+// embedded bsv.
+type field struct {
+	t    control.Type
+	data []byte
+}
+
+// fields returns the top level fields of the bsv.
+func fields(bsv []byte) (fs []field, err error) {
+	d := control.NewBytesDecoder(bsv)
+
+	for d.Next() {
+		f := field{t: d.Type()}
+
+		switch f.t {
+		case control.Null, control.Empty:
+		case control.ContainerBounded:
+			f.data, err = d.BSV()
+		case control.Data,
+			control.Data1,
+			control.Data2,
+			control.DataSize,
+			control.DataSizeSize:
+
+			var data []byte
+
+			data, err = d.Data()
+			f.data = append([]byte{}, data...)
+		default:
+			return nil, Error.New("unexpected field: %s", f.t.Abbr)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		fs = append(fs, f)
+	}
+
+	err = d.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return fs, nil
+}
+
+// readUint reads a data field as an unsigned integer.
+func readUint(f field) (v uint64, err error) {
+	if f.data == nil || f.t == control.ContainerBounded {
+		return 0, Error.New("expected data: %s", f.t.Abbr)
+	}
+
+	return wire.BytesUint(f.data)
+}
+
+// readValue reads a min or max value.
+func readValue(f field) (value []byte, err error) {
+	switch f.t {
+	case control.Null:
+		return nil, nil
+	case control.Empty:
+		return []byte{}, nil
+	case control.ContainerBounded:
+		return nil, Error.New("expected data: %s", f.t.Abbr)
+	}
+
+	return f.data, nil
+}
+
+func decode(bsv []byte) (f *Footer, err error) {
+	fs, err := fields(bsv)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fs) < 4 {
+		return nil, Error.New("invalid footer: %d fields", len(fs))
+	}
+
+	v, err := readUint(fs[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if v != version {
+		return nil, Error.New("unsupported footer version: %d", v)
+	}
+
+	f = &Footer{}
+
+	f.Rows, err = readUint(fs[1])
+	if err != nil {
+		return nil, err
+	}
+
+	switch fs[2].t {
+	case control.Null:
+	case control.ContainerBounded:
+		f.Index, err = control.ReadIndex(bytes.NewReader(fs[2].data))
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, Error.New("invalid footer index: %s", fs[2].t.Abbr)
+	}
+
+	switch fs[3].t {
+	case control.Null:
+		return f, nil
+	case control.ContainerBounded:
+	default:
+		return nil, Error.New("invalid footer columns: %s", fs[3].t.Abbr)
+	}
+
+	columns, err := fields(fs[3].data)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cf := range columns {
+		if cf.t != control.ContainerBounded {
+			return nil, Error.New("invalid footer column: %s", cf.t.Abbr)
+		}
+
+		values, err := fields(cf.data)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(values) != 4 {
+			return nil, Error.New("invalid footer column: %d fields", len(values))
+		}
+
+		var c Column
+
+		c.Count, err = readUint(values[0])
+		if err != nil {
+			return nil, err
+		}
+
+		c.Nulls, err = readUint(values[1])
+		if err != nil {
+			return nil, err
+		}
+
+		c.Min, err = readValue(values[2])
+		if err != nil {
+			return nil, err
+		}
+
+		c.Max, err = readValue(values[3])
+		if err != nil {
+			return nil, err
+		}
+
+		f.Columns = append(f.Columns, c)
+	}
+
+	return f, nil
+}
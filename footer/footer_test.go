@@ -0,0 +1,211 @@
+package footer_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calebcase/bsv/control"
+	"github.com/calebcase/bsv/envelope"
+	"github.com/calebcase/bsv/footer"
+	"github.com/calebcase/oops"
+)
+
+func TestFooter(t *testing.T) {
+	type TC struct {
+		Records []func(control.Encoder) error
+		Columns []footer.Column
+		Mark    error
+	}
+
+	tcs := []TC{
+		{
+			Records: nil,
+			Columns: nil,
+			Mark:    oops.New("unexpected"),
+		},
+		{
+			Records: []func(control.Encoder) error{
+				func(e control.Encoder) (err error) {
+					err = e.Data([]byte("b"))
+					if err != nil {
+						return err
+					}
+
+					return e.Null()
+				},
+				func(e control.Encoder) (err error) {
+					err = e.Data([]byte("c"))
+					if err != nil {
+						return err
+					}
+
+					err = e.Null()
+					if err != nil {
+						return err
+					}
+
+					return e.Data(bytes.Repeat([]byte{0xff}, 100))
+				},
+				func(e control.Encoder) (err error) {
+					err = e.Empty()
+					if err != nil {
+						return err
+					}
+
+					return e.Data([]byte{0b_0000_0001})
+				},
+			},
+			Columns: []footer.Column{
+				{Count: 3, Min: []byte{}, Max: []byte("c")},
+				{Count: 3, Nulls: 2, Min: []byte{0b_0000_0001}, Max: []byte{0b_0000_0001}},
+				{Count: 1, Min: bytes.Repeat([]byte{0xff}, 100), Max: bytes.Repeat([]byte{0xff}, 100)},
+			},
+			Mark: oops.New("unexpected"),
+		},
+		{
+			Records: []func(control.Encoder) error{
+				func(e control.Encoder) (err error) {
+					return e.Null()
+				},
+				func(e control.Encoder) (err error) {
+					return e.Unbound(func(ue control.Encoder) error {
+						return ue.Null()
+					})
+				},
+			},
+			Columns: []footer.Column{
+				{Count: 2, Nulls: 1},
+			},
+			Mark: oops.New("unexpected"),
+		},
+	}
+
+	for i, tc := range tcs {
+		tc := tc
+
+		t.Run(string(rune('a'+i)), func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			// Write the records after an envelope header so the
+			// index offsets don't start at zero.
+			_, err := envelope.NewWriter(buf, envelope.Header{})
+			require.NoError(t, err, tc.Mark)
+
+			start := buf.Len()
+
+			fw := footer.NewWriter(buf, uint64(start))
+
+			for _, fn := range tc.Records {
+				err = fw.Record(fn)
+				require.NoError(t, err, tc.Mark)
+			}
+
+			err = fw.Close()
+			require.NoError(t, err, tc.Mark)
+
+			err = fw.Close()
+			require.Error(t, err, tc.Mark)
+
+			err = fw.Record(func(e control.Encoder) error {
+				return e.Null()
+			})
+			require.Error(t, err, tc.Mark)
+
+			input := buf.Bytes()
+
+			f, err := footer.Open(bytes.NewReader(input), int64(len(input)))
+			require.NoError(t, err, tc.Mark)
+
+			require.Equal(t, fw.Footer(), *f, tc.Mark)
+			require.Equal(t, uint64(len(tc.Records)), f.Rows, tc.Mark)
+			require.Equal(t, tc.Columns, f.Columns, tc.Mark)
+			require.Len(t, f.Index, len(tc.Records), tc.Mark)
+
+			// The index locates each record in the file.
+			offset := uint64(start)
+			for i, entry := range f.Index {
+				require.Equal(t, offset, entry.Offset, tc.Mark)
+
+				d := control.NewDecoder(bytes.NewReader(input))
+
+				err = control.SeekToField(d, f.Index, i)
+				require.NoError(t, err, tc.Mark)
+
+				require.True(t, d.Next(), tc.Mark)
+				require.Equal(t, control.ContainerBounded, d.Type(), tc.Mark)
+
+				bsv, err := d.BSV()
+				require.NoError(t, err, tc.Mark)
+
+				expected := &bytes.Buffer{}
+				err = tc.Records[i](control.NewEncoder(expected))
+				require.NoError(t, err, tc.Mark)
+				require.Equal(t, expected.Bytes(), bsv, tc.Mark)
+
+				offset += entry.Length
+			}
+
+			require.Equal(t, offset, f.Offset, tc.Mark)
+		})
+	}
+}
+
+func TestWriterCompare(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	fw := footer.NewWriter(buf, 0)
+
+	// Order by length then bytes (big-endian unsigned integers).
+	fw.Compare = func(a, b []byte) int {
+		if len(a) != len(b) {
+			return len(a) - len(b)
+		}
+
+		return bytes.Compare(a, b)
+	}
+
+	for _, v := range [][]byte{{0x02}, {0x01, 0x00}, {0x7f}} {
+		err := fw.Record(func(e control.Encoder) error {
+			return e.Data(v)
+		})
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, fw.Close())
+
+	f, err := footer.Open(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x02}, f.Columns[0].Min)
+	require.Equal(t, []byte{0x01, 0x00}, f.Columns[0].Max)
+}
+
+func TestOpenErrors(t *testing.T) {
+	type TC struct {
+		Input []byte
+		Mark  error
+	}
+
+	tcs := []TC{
+		{
+			Input: nil,
+			Mark:  oops.New("unexpected"),
+		},
+		{
+			// Not symmetric.
+			Input: []byte{0b_1000_0001},
+			Mark:  oops.New("unexpected"),
+		},
+		{
+			// Symmetric, but not a bounded container.
+			Input: []byte{0b_0000_0111, 0b_0100_0000, 0x01, 0b_0100_0000, 0b_0000_0111},
+			Mark:  oops.New("unexpected"),
+		},
+	}
+
+	for _, tc := range tcs {
+		_, err := footer.Open(bytes.NewReader(tc.Input), int64(len(tc.Input)))
+		require.Error(t, err, tc.Mark)
+	}
+}
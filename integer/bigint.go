@@ -0,0 +1,73 @@
+package integer
+
+import (
+	"math/big"
+)
+
+// FromBigInt returns the block for i. Zero is a single zero byte and is never
+// negative. A nil i returns a null block (a nil Value).
+func FromBigInt(i *big.Int) *Block {
+	if i == nil {
+		return &Block{}
+	}
+
+	value := new(big.Int).Abs(i).Bytes()
+
+	// Note: big.Int encodes zero as an empty byte array, but we
+	// desire zero to be an actual zero byte.
+	if len(value) == 0 {
+		value = []byte{0}
+	}
+
+	return &Block{
+		Value:    value,
+		Negative: i.Sign() < 0,
+	}
+}
+
+// BigInt returns the block's value. Negative zero (a zero Value with Negative
+// set) is returned as zero. A null block (a nil Value) returns nil.
+func (b Block) BigInt() *big.Int {
+	if b.Value == nil {
+		return nil
+	}
+
+	i := new(big.Int).SetBytes(b.Value)
+	if b.Negative {
+		i.Neg(i)
+	}
+
+	return i
+}
+
+// EncodeBigInt writes i. It writes the same bytes as Encode with
+// FromBigInt(i): zero is written as a single byte Data block and negative
+// values require a signed schema. A nil i is written as a null. The value is
+// written in the smallest data block that holds it, up to the 2^64 byte limit
+// of a Data Size Size block. i isn't modified.
+func (e *Encoder) EncodeBigInt(i *big.Int) (err error) {
+	defer wrapP(&err)
+
+	return e.Encode(FromBigInt(i))
+}
+
+// DecodeBigInt reads an integer of any size. Negative zero is decoded as zero.
+// A null is decoded as nil. At the end of the input it returns io.EOF. The
+// size of the value is only limited by the control decoder (see
+// control.DecoderOptions.MaxFieldSize).
+func (d *Decoder) DecodeBigInt() (i *big.Int, err error) {
+	defer wrapP(&err)
+
+	if d.delta != nil {
+		return d.delta.DecodeBigInt()
+	}
+
+	b := Block{}
+
+	err = d.Decode(&b)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.BigInt(), nil
+}
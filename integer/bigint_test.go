@@ -0,0 +1,122 @@
+package integer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/big"
+	"testing"
+
+	"github.com/calebcase/bsv/control"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBigIntBlock(t *testing.T) {
+	require.Equal(t, &Block{Value: []byte{0}}, FromBigInt(big.NewInt(0)))
+	require.Equal(t, &Block{Value: []byte{1}, Negative: true}, FromBigInt(big.NewInt(-1)))
+	require.Equal(t, &Block{Value: []byte{1, 0}}, FromBigInt(big.NewInt(256)))
+	require.Equal(t, &Block{}, FromBigInt(nil))
+
+	require.Equal(t, big.NewInt(-256), Block{Value: []byte{1, 0}, Negative: true}.BigInt())
+	require.Nil(t, Block{}.BigInt())
+
+	// Negative zero is zero.
+	require.Equal(t, 0, Block{Value: []byte{0}, Negative: true}.BigInt().Sign())
+
+	// Leading zero bytes don't change the value.
+	require.Equal(t, big.NewInt(5), Block{Value: []byte{0, 0, 5}}.BigInt())
+}
+
+func TestEncodeDecodeBigInt(t *testing.T) {
+	huge := new(big.Int).Lsh(big.NewInt(1), 8*300)
+
+	values := []*big.Int{
+		big.NewInt(0),
+		big.NewInt(1),
+		big.NewInt(-1),
+		big.NewInt(-524287),
+		new(big.Int).Sub(huge, big.NewInt(1)),
+		huge,
+		new(big.Int).Neg(huge),
+		nil,
+	}
+
+	for _, signed := range []bool{false, true} {
+		schema := Schema{
+			Signed:   signed,
+			Nullable: true,
+		}
+
+		buf := bytes.NewBuffer(nil)
+		enc := NewEncoder(schema, control.NewEncoder(buf))
+
+		var want []*big.Int
+		for _, v := range values {
+			if !signed && v != nil && v.Sign() < 0 {
+				require.Error(t, enc.EncodeBigInt(v))
+
+				continue
+			}
+
+			var before *big.Int
+			if v != nil {
+				before = new(big.Int).Set(v)
+			}
+
+			start := buf.Len()
+			require.NoError(t, enc.EncodeBigInt(v))
+			require.Equal(t, before.String(), v.String())
+
+			// The same bytes as the block path.
+			require.Equal(t, encodeBlock(t, schema, FromBigInt(v)), buf.Bytes()[start:])
+
+			want = append(want, v)
+		}
+
+		dec := NewDecoder(schema, control.NewBytesDecoder(buf.Bytes()))
+		for _, v := range want {
+			got, err := dec.DecodeBigInt()
+			require.NoError(t, err)
+
+			if v == nil {
+				require.Nil(t, got)
+			} else {
+				require.NotNil(t, got)
+				require.Equal(t, 0, v.Cmp(got), got.String())
+			}
+		}
+
+		_, err := dec.DecodeBigInt()
+		require.True(t, errors.Is(err, io.EOF))
+	}
+}
+
+func TestBigIntSizes(t *testing.T) {
+	schema := Schema{Signed: true}
+
+	// Zero is a single Data block.
+	require.Equal(t, []byte{0b_1000_0000}, encodeBlock(t, schema, FromBigInt(big.NewInt(0))))
+	require.Equal(t, []byte{0b_1000_0000}, encodeBlock(t, Schema{}, FromBigInt(big.NewInt(0))))
+
+	// Values over 64 bytes use a Data Size Size block.
+	data := encodeBlock(t, schema, FromBigInt(new(big.Int).Lsh(big.NewInt(1), 8*64)))
+
+	d := control.NewBytesDecoder(data)
+	require.True(t, d.Next())
+	require.Equal(t, control.DataSizeSize, d.Type())
+
+	// Negative zero is written as zero.
+	negZero := &Block{Value: []byte{0}, Negative: true}
+	require.Equal(t, []byte{0b_1000_0000}, encodeBlock(t, schema, negZero))
+	require.Equal(t, []byte{0b_1000_0000}, encodeBlock(t, Schema{}, negZero))
+	require.Equal(t, []byte{0b_1000_0000}, encodeBlock(t, schema, &Block{Value: []byte{0, 0}, Negative: true}))
+
+	// Negative zero is read as zero.
+	blk := &Block{}
+	require.NoError(t, NewDecoder(schema, control.NewBytesDecoder([]byte{0b_1000_0001})).Decode(blk))
+	require.Equal(t, &Block{Value: []byte{0}}, blk)
+
+	i, err := NewDecoder(schema, control.NewBytesDecoder([]byte{0b_1000_0001})).DecodeBigInt()
+	require.NoError(t, err)
+	require.Equal(t, 0, i.Sign())
+}
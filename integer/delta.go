@@ -0,0 +1,284 @@
+package integer
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"math/big"
+
+	"github.com/calebcase/bsv/control"
+)
+
+// DeltaEncoder writes integers as the difference from the previous integer.
+// Differences are always written with a trailing sign bit (zigzag), so small
+// steps in either direction fit in Data and Data + 1 blocks. The first integer
+// and the first after a reset are written as the difference from zero. Nulls
+// are written as is and don't change the previous integer.
+type DeltaEncoder struct {
+	schema Schema
+	enc    *Encoder
+
+	prev big.Int
+	cur  big.Int
+}
+
+// NewDeltaEncoder returns a new delta encoder. The schema's Delta flag is
+// set.
+func NewDeltaEncoder(schema Schema, ce control.Encoder) *DeltaEncoder {
+	schema.Delta = true
+
+	return &DeltaEncoder{
+		schema: schema,
+		enc: NewEncoder(Schema{
+			Signed:   true,
+			Nullable: schema.Nullable,
+		}, ce),
+	}
+}
+
+// Reset makes the next integer be written as the difference from zero. This
+// makes the integers from here on decodable without the preceding ones.
+func (e *DeltaEncoder) Reset() {
+	e.prev.SetInt64(0)
+}
+
+// encode writes the difference between cur and the previous integer.
+func (e *DeltaEncoder) encode() (err error) {
+	if e.cur.Sign() < 0 && !e.schema.Signed {
+		return Error.New("negative value for unsigned integer")
+	}
+
+	err = e.schema.check(&e.cur, -1)
+	if err != nil {
+		return err
+	}
+
+	diff := new(big.Int).Sub(&e.cur, &e.prev)
+
+	err = e.enc.EncodeBigInt(diff)
+	if err != nil {
+		return err
+	}
+
+	e.prev.Set(&e.cur)
+
+	return nil
+}
+
+// Encode writes a block. A block with a nil Value is written as a null.
+func (e *DeltaEncoder) Encode(b *Block) (err error) {
+	defer wrapP(&err)
+
+	if b.Value == nil {
+		return e.enc.Encode(b)
+	}
+
+	e.cur.Set(b.BigInt())
+
+	return e.encode()
+}
+
+// EncodeBigInt writes i. A nil i is written as a null.
+func (e *DeltaEncoder) EncodeBigInt(i *big.Int) (err error) {
+	defer wrapP(&err)
+
+	if i == nil {
+		return e.enc.EncodeBigInt(nil)
+	}
+
+	e.cur.Set(i)
+
+	return e.encode()
+}
+
+// EncodeInt64 writes v.
+func (e *DeltaEncoder) EncodeInt64(v int64) (err error) {
+	defer wrapP(&err)
+
+	e.cur.SetInt64(v)
+
+	return e.encode()
+}
+
+// EncodeUint64 writes v.
+func (e *DeltaEncoder) EncodeUint64(v uint64) (err error) {
+	defer wrapP(&err)
+
+	e.cur.SetUint64(v)
+
+	return e.encode()
+}
+
+// Bound writes a bounded container holding the integers fn encodes. The
+// integers in the container start from zero so that it can be decoded on its
+// own (see DeltaDecoder.Bound). The previous integer outside the container is
+// unchanged.
+func (e *DeltaEncoder) Bound(fn func(de *DeltaEncoder) error) (err error) {
+	defer wrapP(&err)
+
+	buf := &bytes.Buffer{}
+
+	err = fn(NewDeltaEncoder(e.schema, control.NewEncoder(buf)))
+	if err != nil {
+		return err
+	}
+
+	return e.enc.ce.Bound(buf.Bytes())
+}
+
+// DeltaDecoder reads integers written by a DeltaEncoder.
+type DeltaDecoder struct {
+	schema Schema
+	dec    *Decoder
+
+	prev big.Int
+}
+
+// NewDeltaDecoder returns a new delta decoder. The schema's Delta flag is
+// set.
+func NewDeltaDecoder(schema Schema, cd control.Decoder) *DeltaDecoder {
+	schema.Delta = true
+
+	return &DeltaDecoder{
+		schema: schema,
+		dec: NewDecoder(Schema{
+			Signed:   true,
+			Nullable: schema.Nullable,
+		}, cd),
+	}
+}
+
+// Reset makes the next integer be read as the difference from zero. It must
+// be called at the same points as DeltaEncoder.Reset.
+func (d *DeltaDecoder) Reset() {
+	d.prev.SetInt64(0)
+}
+
+// DecodeBigInt reads an integer. A null is decoded as nil. At the end of the
+// input it returns io.EOF.
+func (d *DeltaDecoder) DecodeBigInt() (i *big.Int, err error) {
+	defer wrapP(&err)
+
+	diff, err := d.dec.DecodeBigInt()
+	if err != nil {
+		return nil, err
+	}
+
+	if diff == nil {
+		return nil, nil
+	}
+
+	i = diff.Add(diff, &d.prev)
+
+	if i.Sign() < 0 && !d.schema.Signed {
+		return nil, Error.New("negative value for unsigned integer")
+	}
+
+	err = d.schema.check(i, int64(d.dec.offset))
+	if err != nil {
+		return nil, err
+	}
+
+	d.prev.Set(i)
+
+	return i, nil
+}
+
+// Decode reads a block. A null is decoded as a block with a nil Value.
+func (d *DeltaDecoder) Decode(b *Block) (err error) {
+	i, err := d.DecodeBigInt()
+	if err != nil {
+		return err
+	}
+
+	*b = *FromBigInt(i)
+
+	return nil
+}
+
+// decodeRange reads an integer that must be within the bounds b. A null
+// returns ErrNull and a value that isn't returns a RangeError that also
+// matches ErrOverflow.
+func (d *DeltaDecoder) decodeRange(b bounds) (i *big.Int, err error) {
+	i, err = d.DecodeBigInt()
+	if err != nil {
+		return nil, err
+	}
+
+	if i == nil {
+		return nil, ErrNull
+	}
+
+	min, max := b.ints()
+
+	if i.Cmp(min) < 0 || i.Cmp(max) > 0 {
+		return nil, overflow(i, b, int64(d.dec.offset))
+	}
+
+	return i, nil
+}
+
+// DecodeInt64 reads an integer that must fit in an int64. A null returns
+// ErrNull and a value that doesn't fit returns a RangeError that also matches
+// ErrOverflow.
+func (d *DeltaDecoder) DecodeInt64() (v int64, err error) {
+	i, err := d.decodeRange(bounds{math.MinInt64, math.MaxInt64})
+	if err != nil {
+		return 0, err
+	}
+
+	return i.Int64(), nil
+}
+
+// DecodeUint64 reads an integer that must fit in a uint64. A null returns
+// ErrNull and a value that doesn't fit returns a RangeError that also matches
+// ErrOverflow.
+func (d *DeltaDecoder) DecodeUint64() (v uint64, err error) {
+	i, err := d.decodeRange(bounds{0, math.MaxUint64})
+	if err != nil {
+		return 0, err
+	}
+
+	return i.Uint64(), nil
+}
+
+// Bound reads a bounded container written by DeltaEncoder.Bound and calls fn
+// with a decoder for the integers in it. At the end of the input it returns
+// io.EOF. The previous integer outside the container is unchanged.
+func (d *DeltaDecoder) Bound(fn func(dd *DeltaDecoder) error) (err error) {
+	defer wrapP(&err)
+
+	cd := d.dec.cd
+
+	if !cd.Next() {
+		err = cd.Err()
+		if err != nil {
+			return err
+		}
+
+		return io.EOF
+	}
+
+	if cd.Type() != control.ContainerBounded {
+		return Error.New("unexpected field: %s", cd.Type().Abbr)
+	}
+
+	// The offsets of the integers in the container are relative to the
+	// start of its contents.
+	_, err = cd.Size()
+	if err != nil {
+		return err
+	}
+
+	base := d.dec.base + cd.Consumed()
+
+	bsv, err := cd.BSV()
+	if err != nil {
+		return err
+	}
+
+	dd := NewDeltaDecoder(d.schema, control.NewBytesDecoder(bsv))
+	dd.dec.base = base
+
+	return fn(dd)
+}
@@ -0,0 +1,322 @@
+package integer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/calebcase/bsv/control"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDelta(t *testing.T) {
+	ids := []int64{1_000_000, 1_000_001, 1_000_003, 1_000_002, 1_000_010, 1_000_500}
+
+	buf := bytes.NewBuffer(nil)
+	enc := NewDeltaEncoder(Schema{}, control.NewEncoder(buf))
+
+	for _, id := range ids {
+		require.NoError(t, enc.EncodeInt64(id))
+	}
+
+	// 1_000_000 takes a Data Size block and 3 bytes, the small steps a Data
+	// block each and the last step a Data + 1 block.
+	require.Equal(t, 4+4+2, buf.Len())
+
+	plain := bytes.NewBuffer(nil)
+	penc := NewEncoder(Schema{}, control.NewEncoder(plain))
+
+	for _, id := range ids {
+		require.NoError(t, penc.EncodeInt64(id))
+	}
+	require.Less(t, buf.Len(), plain.Len())
+
+	dec := NewDeltaDecoder(Schema{}, control.NewBytesDecoder(buf.Bytes()))
+
+	for _, id := range ids {
+		v, err := dec.DecodeInt64()
+		require.NoError(t, err)
+		require.Equal(t, id, v)
+	}
+
+	_, err := dec.DecodeInt64()
+	require.True(t, errors.Is(err, io.EOF))
+}
+
+func TestDeltaValues(t *testing.T) {
+	huge := new(big.Int).Lsh(big.NewInt(1), 100)
+
+	schema := Schema{
+		Signed:   true,
+		Nullable: true,
+	}
+
+	buf := bytes.NewBuffer(nil)
+	enc := NewDeltaEncoder(schema, control.NewEncoder(buf))
+
+	require.NoError(t, enc.EncodeInt64(math.MaxInt64))
+	require.NoError(t, enc.EncodeInt64(math.MinInt64))
+	require.NoError(t, enc.Encode(&Block{}))
+	require.NoError(t, enc.EncodeBigInt(huge))
+	require.NoError(t, enc.EncodeBigInt(nil))
+	require.NoError(t, enc.Encode(&Block{Value: []byte{7}, Negative: true}))
+	require.NoError(t, enc.EncodeUint64(math.MaxUint64))
+
+	dec := NewDeltaDecoder(schema, control.NewBytesDecoder(buf.Bytes()))
+
+	i64, err := dec.DecodeInt64()
+	require.NoError(t, err)
+	require.Equal(t, int64(math.MaxInt64), i64)
+
+	i64, err = dec.DecodeInt64()
+	require.NoError(t, err)
+	require.Equal(t, int64(math.MinInt64), i64)
+
+	_, err = dec.DecodeInt64()
+	require.True(t, errors.Is(err, ErrNull))
+
+	_, err = dec.DecodeInt64()
+	require.True(t, errors.Is(err, ErrOverflow))
+
+	blk := &Block{}
+	require.NoError(t, dec.Decode(blk))
+	require.Equal(t, &Block{}, blk)
+
+	require.NoError(t, dec.Decode(blk))
+	require.Equal(t, &Block{Value: []byte{7}, Negative: true}, blk)
+
+	u64, err := dec.DecodeUint64()
+	require.NoError(t, err)
+	require.Equal(t, uint64(math.MaxUint64), u64)
+
+	// Unsigned schemas reject negative values.
+	uenc := NewDeltaEncoder(Schema{}, control.NewEncoder(bytes.NewBuffer(nil)))
+	require.NoError(t, uenc.EncodeInt64(5))
+	require.Error(t, uenc.EncodeInt64(-1))
+
+	// Decreasing values are fine as long as they aren't negative.
+	require.NoError(t, uenc.EncodeInt64(0))
+
+	// Nulls require a nullable schema.
+	require.True(t, errors.Is(uenc.Encode(&Block{}), ErrNull))
+}
+
+func TestDeltaReset(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	enc := NewDeltaEncoder(Schema{}, control.NewEncoder(buf))
+
+	require.NoError(t, enc.EncodeInt64(500))
+	require.NoError(t, enc.EncodeInt64(501))
+
+	offset := buf.Len()
+
+	enc.Reset()
+	require.NoError(t, enc.EncodeInt64(502))
+	require.NoError(t, enc.EncodeInt64(503))
+
+	// The integers after the reset decode on their own.
+	dec := NewDeltaDecoder(Schema{}, control.NewBytesDecoder(buf.Bytes()[offset:]))
+
+	for _, want := range []uint64{502, 503} {
+		v, err := dec.DecodeUint64()
+		require.NoError(t, err)
+		require.Equal(t, want, v)
+	}
+
+	// Reading from the start requires resetting at the same point.
+	dec = NewDeltaDecoder(Schema{}, control.NewBytesDecoder(buf.Bytes()))
+
+	for i, want := range []uint64{500, 501, 502, 503} {
+		if i == 2 {
+			dec.Reset()
+		}
+
+		v, err := dec.DecodeUint64()
+		require.NoError(t, err)
+		require.Equal(t, want, v)
+	}
+}
+
+func TestDeltaBound(t *testing.T) {
+	chunks := [][]int64{
+		{100, 101, 102},
+		{200, 199, 250},
+	}
+
+	buf := bytes.NewBuffer(nil)
+	enc := NewDeltaEncoder(Schema{}, control.NewEncoder(buf))
+
+	require.NoError(t, enc.EncodeInt64(1000))
+
+	for _, chunk := range chunks {
+		err := enc.Bound(func(de *DeltaEncoder) error {
+			for _, v := range chunk {
+				err := de.EncodeInt64(v)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+		require.NoError(t, err)
+	}
+
+	// The chunks don't change the previous integer outside of them.
+	require.NoError(t, enc.EncodeInt64(1001))
+
+	// decodeChunk reads the integers of a chunk.
+	decodeChunk := func(dd *DeltaDecoder) (values []int64, err error) {
+		for {
+			v, err := dd.DecodeInt64()
+			if errors.Is(err, io.EOF) {
+				return values, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, v)
+		}
+	}
+
+	dec := NewDeltaDecoder(Schema{}, control.NewBytesDecoder(buf.Bytes()))
+
+	v, err := dec.DecodeInt64()
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), v)
+
+	for _, chunk := range chunks {
+		err = dec.Bound(func(dd *DeltaDecoder) (err error) {
+			values, err := decodeChunk(dd)
+			require.Equal(t, chunk, values)
+
+			return err
+		})
+		require.NoError(t, err)
+	}
+
+	v, err = dec.DecodeInt64()
+	require.NoError(t, err)
+	require.Equal(t, int64(1001), v)
+
+	require.True(t, errors.Is(dec.Bound(func(dd *DeltaDecoder) error { return nil }), io.EOF))
+
+	// The second chunk decodes without reading the first.
+	cd := control.NewBytesDecoder(buf.Bytes())
+	for i := 0; i < 3; i++ {
+		require.True(t, cd.Next())
+	}
+	require.Equal(t, control.ContainerBounded, cd.Type())
+
+	bsv, err := cd.BSV()
+	require.NoError(t, err)
+
+	values, err := decodeChunk(NewDeltaDecoder(Schema{}, control.NewBytesDecoder(bsv)))
+	require.NoError(t, err)
+	require.Equal(t, chunks[1], values)
+}
+
+func TestDeltaSchema(t *testing.T) {
+	schema := Schema{Delta: true, Nullable: true}
+
+	values := []int64{1_000_000, 1_000_001, 999_999, 1_000_500}
+
+	want := bytes.NewBuffer(nil)
+	denc := NewDeltaEncoder(schema, control.NewEncoder(want))
+
+	buf := bytes.NewBuffer(nil)
+	enc := NewEncoder(schema, control.NewEncoder(buf))
+
+	// Every encoding method of a Delta schema writes differences.
+	require.NoError(t, denc.EncodeInt64(values[0]))
+	require.NoError(t, enc.EncodeInt64(values[0]))
+
+	require.NoError(t, denc.EncodeUint64(uint64(values[1])))
+	require.NoError(t, enc.EncodeUint32(uint32(values[1])))
+
+	require.NoError(t, denc.EncodeBigInt(nil))
+	require.NoError(t, enc.Encode(&Block{}))
+
+	require.NoError(t, denc.EncodeBigInt(big.NewInt(values[2])))
+	require.NoError(t, enc.EncodeBigInt(big.NewInt(values[2])))
+
+	denc.Reset()
+	enc.Reset()
+
+	require.NoError(t, denc.Encode(FromBigInt(big.NewInt(values[3]))))
+	require.NoError(t, enc.Encode(FromBigInt(big.NewInt(values[3]))))
+
+	require.Equal(t, want.Bytes(), buf.Bytes())
+
+	dec := NewDecoder(schema, control.NewBytesDecoder(buf.Bytes()))
+
+	v, err := dec.DecodeInt64()
+	require.NoError(t, err)
+	require.Equal(t, values[0], v)
+
+	u, err := dec.DecodeUint32()
+	require.NoError(t, err)
+	require.Equal(t, uint32(values[1]), u)
+
+	blk := &Block{}
+	require.NoError(t, dec.Decode(blk))
+	require.Nil(t, blk.Value)
+
+	i, err := dec.DecodeBigInt()
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(values[2]), i)
+
+	dec.Reset()
+
+	require.NoError(t, dec.Decode(blk))
+	require.Equal(t, FromBigInt(big.NewInt(values[3])), blk)
+}
+
+func TestDeltaBoundOffset(t *testing.T) {
+	schema := Schema{Max: big.NewInt(10)}
+
+	buf := bytes.NewBuffer(nil)
+	enc := NewDeltaEncoder(Schema{}, control.NewEncoder(buf))
+
+	require.NoError(t, enc.EncodeInt64(5))
+
+	// The second integer in the container follows the container's control
+	// block, its one byte size and the first integer.
+	offset := int64(buf.Len()) + 3
+
+	err := enc.Bound(func(de *DeltaEncoder) error {
+		err := de.EncodeInt64(6)
+		if err != nil {
+			return err
+		}
+
+		return de.EncodeInt64(11)
+	})
+	require.NoError(t, err)
+
+	dec := NewDeltaDecoder(schema, control.NewBytesDecoder(buf.Bytes()))
+
+	_, err = dec.DecodeInt64()
+	require.NoError(t, err)
+
+	err = dec.Bound(func(dd *DeltaDecoder) error {
+		_, err := dd.DecodeInt64()
+		if err != nil {
+			return err
+		}
+
+		_, err = dd.DecodeInt64()
+
+		return err
+	})
+	require.ErrorIs(t, err, ErrRange)
+
+	// The offset is in the outer input and not the container.
+	var re *RangeError
+	require.True(t, errors.As(err, &re))
+	require.Equal(t, offset, re.Offset)
+}
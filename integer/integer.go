@@ -1,12 +1,16 @@
 package integer
 
 import (
+	"io"
 	"math/big"
+	"math/bits"
 
 	"github.com/calebcase/bsv/control"
 )
 
-// Block is a signed integer number.
+// Block is a signed integer number. Value is the big-endian magnitude and a
+// nil Value is a null. A zero Value with Negative set is negative zero. It is
+// encoded as zero and decoding never returns it.
 type Block struct {
 	Value    []byte
 	Negative bool
@@ -56,46 +60,192 @@ func (b *Block) UnmarshalBinary(data []byte) (err error) {
 type Schema struct {
 	Signed bool
 
+	// Delta integers are written as the difference from the previous
+	// integer. Encoders and decoders for the schema use a DeltaEncoder or
+	// DeltaDecoder.
+	Delta bool
+
+	// Bits limits integers to those that fit in a signed or unsigned
+	// integer of this width. It is 8, 16, 32 or 64. Zero is unbounded.
+	Bits int
+
+	// Min and Max limit integers to those in [Min, Max]. A nil bound is
+	// unbounded. Values out of range are rejected when encoding and
+	// decoding with a RangeError.
+	Min *big.Int
+	Max *big.Int
+
 	Nullable    bool
 	Key         bool
 	ContentType string
 }
 
+// ErrNull is returned when a null is encoded or decoded for a schema that
+// isn't nullable. It is also returned when a null is decoded into a type that
+// can't represent it (see DecodeInt64).
+var ErrNull = Error.New("unexpected null")
+
+// zero reports if the big-endian bytes are zero.
+func zero(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// normalize returns the minimal big-endian bytes of data. Zero is a single
+// zero byte.
+func normalize(data []byte) []byte {
+	data = new(big.Int).SetBytes(data).Bytes()
+
+	// Note: big.Int encodes zero as an empty byte array, but we
+	// desire zero to be an actual zero byte.
+	if len(data) == 0 {
+		data = []byte{0}
+	}
+
+	return data
+}
+
+// blockData returns the minimal data padded to the size of the block for its
+// bit length: d up to 7 bits, d1 up to 13 bits and d2 up to 20 bits. Longer
+// data is returned as is. The control encoder then writes the padded data with
+// that block.
+func blockData(data []byte) []byte {
+	size := len(data)
+
+	switch n := (len(data)-1)*8 + bits.Len8(data[0]); {
+	case n <= 7:
+		size = 1
+	case n <= 13: // 5+8
+		size = 2
+	case n <= 20: // 4+8+8
+		size = 3
+	}
+
+	if size <= len(data) {
+		return data
+	}
+
+	padded := make([]byte, size)
+	copy(padded[size-len(data):], data)
+
+	return padded
+}
+
+// data writes the integer's data with the block for its bit length.
+func (e *Encoder) data(data []byte) error {
+	return e.ce.Data(blockData(data))
+}
+
 // Decoder is a decoder.
 type Decoder struct {
 	schema Schema
-	cd     *control.Decoder
+	cd     control.Decoder
+
+	// delta reads the integers of a Delta schema.
+	delta *DeltaDecoder
+
+	// base is the offset of cd's input and offset is the offset of the
+	// last field read.
+	base   uint64
+	offset uint64
 }
 
 // NewDecoder returns a new decoder.
-func NewDecoder(schema Schema, cd *control.Decoder) *Decoder {
-	return &Decoder{
+func NewDecoder(schema Schema, cd control.Decoder) *Decoder {
+	d := &Decoder{
 		schema: schema,
 		cd:     cd,
 	}
+
+	if schema.Delta {
+		d.delta = NewDeltaDecoder(schema, cd)
+	}
+
+	return d
 }
 
-// Decode parses a block from the reader.
+// Reset makes the next integer of a Delta schema be read as the difference
+// from zero (see DeltaDecoder.Reset). It does nothing for other schemas.
+func (d *Decoder) Reset() {
+	if d.delta != nil {
+		d.delta.Reset()
+	}
+}
+
+// next reads the data of the next integer. A null is returned as nil data.
+func (d *Decoder) next() (data []byte, err error) {
+	d.offset = d.base + d.cd.Consumed()
+
+	if !d.cd.Next() {
+		err = d.cd.Err()
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, io.EOF
+	}
+
+	switch d.cd.Type() {
+	case control.Null:
+		if !d.schema.Nullable {
+			return nil, ErrNull
+		}
+
+		return nil, nil
+	case control.Data, control.DataSize, control.Data1, control.Data2, control.DataSizeSize:
+	default:
+		return nil, Error.New("unexpected field: %s", d.cd.Type().Abbr)
+	}
+
+	return d.cd.Data()
+}
+
+// Decode parses a block from the reader. A null is decoded as a block with a
+// nil Value. At the end of the input it returns io.EOF.
 func (d *Decoder) Decode(b *Block) (err error) {
-	defer Error.WrapP(&err)
+	defer wrapP(&err)
 
-	cb := &control.Block{}
+	if d.delta != nil {
+		return d.delta.Decode(b)
+	}
 
-	err = d.cd.Decode(cb)
+	data, err := d.next()
 	if err != nil {
 		return err
 	}
 
+	if data == nil {
+		b.Value = nil
+		b.Negative = false
+
+		return nil
+	}
+
 	if d.schema.Signed {
 		// TODO: Use schema information to optimize this choice (e.g.
 		// don't use big.Int if the value is small enough to be
 		// directly encoded to a fixed int format like uint64).
-		i := new(big.Int).SetBytes(cb.Data)
-		b.Negative = i.Bit(0) == 1
-		i.Rsh(i, 1)
-		b.Value = i.Bytes()
+		err = b.UnmarshalBinary(data)
+		if err != nil {
+			return err
+		}
+
+		// Negative zero is zero.
+		if zero(b.Value) {
+			b.Negative = false
+		}
 	} else {
-		b.Value = cb.Data
+		b.Value = normalize(data)
+		b.Negative = false
+	}
+
+	if d.schema.constrained() {
+		return d.schema.check(b.BigInt(), int64(d.offset))
 	}
 
 	return nil
@@ -104,74 +254,76 @@ func (d *Decoder) Decode(b *Block) (err error) {
 // Encoder is an encoder.
 type Encoder struct {
 	schema Schema
-	ce     *control.Encoder
+	ce     control.Encoder
+
+	// delta writes the integers of a Delta schema.
+	delta *DeltaEncoder
 }
 
 // NewEncoder returns a new encoder.
-func NewEncoder(schema Schema, ce *control.Encoder) *Encoder {
-	return &Encoder{
+func NewEncoder(schema Schema, ce control.Encoder) *Encoder {
+	e := &Encoder{
 		schema: schema,
 		ce:     ce,
 	}
+
+	if schema.Delta {
+		e.delta = NewDeltaEncoder(schema, ce)
+	}
+
+	return e
 }
 
-// Encode write a block to the writer.
+// Reset makes the next integer of a Delta schema be written as the
+// difference from zero (see DeltaEncoder.Reset). It does nothing for other
+// schemas.
+func (e *Encoder) Reset() {
+	if e.delta != nil {
+		e.delta.Reset()
+	}
+}
+
+// Encode write a block to the writer. A block with a nil Value is encoded as a
+// null.
 func (e *Encoder) Encode(b *Block) (err error) {
-	defer Error.WrapP(&err)
-
-	var bits int
-	var bytes []byte
-
-	if b.Value != nil {
-		i := new(big.Int).SetBytes(b.Value)
-		if e.schema.Signed {
-			i.Lsh(i, 1)
-			if b.Negative {
-				i.SetBit(i, 0, 1)
-			}
+	defer wrapP(&err)
+
+	if e.delta != nil {
+		return e.delta.Encode(b)
+	}
+
+	if b.Value == nil {
+		if !e.schema.Nullable {
+			return ErrNull
 		}
 
-		bits = i.BitLen()
-		bytes = i.Bytes()
+		return e.ce.Null()
+	}
+
+	// Negative zero is written as zero.
+	negative := b.Negative && !zero(b.Value)
+
+	var data []byte
+
+	if e.schema.Signed {
+		data, err = Block{Value: b.Value, Negative: negative}.MarshalBinary()
+		if err != nil {
+			return err
+		}
+	} else {
+		if negative {
+			return Error.New("negative value for unsigned integer")
+		}
+
+		data = normalize(b.Value)
+	}
 
-		// Note: big.Int encodes zero as an empty byte array, but we
-		// desire zero to be an actual zero byte.
-		if bytes == nil || len(bytes) == 0 {
-			bytes = []byte{0}
+	if e.schema.constrained() {
+		err = e.schema.check(b.BigInt(), -1)
+		if err != nil {
+			return err
 		}
 	}
 
-	switch {
-	case bytes == nil:
-		return e.ce.Encode(&control.Block{
-			Type: control.Null,
-		})
-	case bits <= 7:
-		return e.ce.Encode(&control.Block{
-			Type: control.Data,
-			Data: bytes,
-		})
-	case bits <= 13: // 5+8
-		return e.ce.Encode(&control.Block{
-			Type: control.Data1,
-			Data: bytes,
-		})
-	case bits <= 20: // 4+8+8
-		return e.ce.Encode(&control.Block{
-			Type: control.Data2,
-			Data: bytes,
-		})
-	case len(bytes) <= 64: // 2^6
-		return e.ce.Encode(&control.Block{
-			Type: control.DataSize,
-			Data: bytes,
-		})
-	case len(bytes) <= 4294967296: // 2^(4 * 8)
-		return e.ce.Encode(&control.Block{
-			Type: control.DataSizeSize,
-			Data: bytes,
-		})
-	}
-
-	return Error.New("too large")
+	return e.data(data)
 }
@@ -2,7 +2,9 @@ package integer
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"math/big"
 	"testing"
 
@@ -129,212 +131,256 @@ func TestMarshalUnmarshal(t *testing.T) {
 	}
 }
 
-func TestEncodeDecode(t *testing.T) {
-	type TC struct {
-		name   string
-		schema Schema
-		blk    *Block
-		data   []byte
-	}
+// encodeDecodeTC is an integer and its encoding.
+type encodeDecodeTC struct {
+	name   string
+	schema Schema
+	blk    *Block
+	data   []byte
+}
 
-	tcs := []TC{
-		{
-			name:   "0",
-			schema: Schema{},
-			blk: &Block{
-				Value: []byte{
-					0b0000_0000,
-				},
-				Negative: false,
-			},
-			data: []byte{
-				0b1000_0000,
+var encodeDecodeTCs = []encodeDecodeTC{
+	{
+		name:   "0",
+		schema: Schema{},
+		blk: &Block{
+			Value: []byte{
+				0b0000_0000,
 			},
+			Negative: false,
 		},
-		{
-			name:   "1",
-			schema: Schema{},
-			blk: &Block{
-				Value: []byte{
-					0b0000_0001,
-				},
-				Negative: false,
-			},
-			data: []byte{
-				0b1000_0001,
-			},
+		data: []byte{
+			0b1000_0000,
 		},
-		{
-			name: "+1",
-			schema: Schema{
-				Signed: true,
-			},
-			blk: &Block{
-				Value: []byte{
-					0b0000_0001,
-				},
-				Negative: false,
-			},
-			data: []byte{
-				0b1000_0010,
-			},
+	},
+	{
+		name:   "1",
+		schema: Schema{},
+		blk: &Block{
+			Value: []byte{
+				0b0000_0001,
+			},
+			Negative: false,
 		},
-		{
-			name: "-1",
-			schema: Schema{
-				Signed: true,
-			},
-			blk: &Block{
-				Value: []byte{
-					0b0000_0001,
-				},
-				Negative: true,
+		data: []byte{
+			0b1000_0001,
+		},
+	},
+	{
+		name: "+1",
+		schema: Schema{
+			Signed: true,
+		},
+		blk: &Block{
+			Value: []byte{
+				0b0000_0001,
 			},
-			data: []byte{
-				0b1000_0011,
+			Negative: false,
+		},
+		data: []byte{
+			0b1000_0010,
+		},
+	},
+	{
+		name: "-1",
+		schema: Schema{
+			Signed: true,
+		},
+		blk: &Block{
+			Value: []byte{
+				0b0000_0001,
 			},
+			Negative: true,
 		},
-		{
-			name: "-63",
-			schema: Schema{
-				Signed: true,
+		data: []byte{
+			0b1000_0011,
+		},
+	},
+	{
+		name: "-63",
+		schema: Schema{
+			Signed: true,
+		},
+		blk: &Block{
+			Value: []byte{
+				0b0011_1111,
 			},
-			blk: &Block{
-				Value: []byte{
-					0b0011_1111,
-				},
-				Negative: true,
+			Negative: true,
+		},
+		data: []byte{
+			0b1111_1111,
+		},
+	},
+	{
+		name: "+63",
+		schema: Schema{
+			Signed: true,
+		},
+		blk: &Block{
+			Value: []byte{
+				0b0011_1111,
 			},
-			data: []byte{
+			Negative: false,
+		},
+		data: []byte{
+			0b1111_1110,
+		},
+	},
+	{
+		name: "+4095",
+		schema: Schema{
+			Signed: true,
+		},
+		blk: &Block{
+			Value: []byte{
+				0b0000_1111,
 				0b1111_1111,
 			},
+			Negative: false,
 		},
-		{
-			name: "+63",
-			schema: Schema{
-				Signed: true,
-			},
-			blk: &Block{
-				Value: []byte{
-					0b0011_1111,
-				},
-				Negative: false,
-			},
-			data: []byte{
-				0b1111_1110,
-			},
+		data: []byte{
+			0b0011_1111,
+			0b1111_1110,
 		},
-		{
-			name: "+4095",
-			schema: Schema{
-				Signed: true,
+	},
+	{
+		name: "-524287",
+		schema: Schema{
+			Signed: true,
+		},
+		blk: &Block{
+			Value: []byte{
+				0b0000_0111,
+				0b1111_1111,
+				0b1111_1111,
 			},
-			blk: &Block{
-				Value: []byte{
-					0b0000_1111,
-					0b1111_1111,
-				},
-				Negative: false,
+			Negative: true,
+		},
+		data: []byte{
+			0b0001_1111,
+			0b1111_1111,
+			0b1111_1111,
+		},
+	},
+	{
+		name:   "128",
+		schema: Schema{},
+		blk: &Block{
+			Value: []byte{
+				0b1000_0000,
 			},
-			data: []byte{
-				0b0011_1111,
-				0b1111_1110,
+			Negative: false,
+		},
+		data: []byte{
+			0b0010_0000,
+			0b1000_0000,
+		},
+	},
+	{
+		name: "+64",
+		schema: Schema{
+			Signed: true,
+		},
+		blk: &Block{
+			Value: []byte{
+				0b0100_0000,
 			},
+			Negative: false,
 		},
-		{
-			name: "-524287",
-			schema: Schema{
-				Signed: true,
+		data: []byte{
+			0b0010_0000,
+			0b1000_0000,
+		},
+	},
+	{
+		name:   "8191",
+		schema: Schema{},
+		blk: &Block{
+			Value: []byte{
+				0b0001_1111,
+				0b1111_1111,
 			},
-			blk: &Block{
-				Value: []byte{
-					0b0000_0111,
-					0b1111_1111,
-					0b1111_1111,
-				},
-				Negative: true,
+			Negative: false,
+		},
+		data: []byte{
+			0b0011_1111,
+			0b1111_1111,
+		},
+	},
+	{
+		name:   "8192",
+		schema: Schema{},
+		blk: &Block{
+			Value: []byte{
+				0b0010_0000,
+				0b0000_0000,
 			},
-			data: []byte{
-				0b0001_1111,
+			Negative: false,
+		},
+		data: []byte{
+			0b0001_0000,
+			0b0010_0000,
+			0b0000_0000,
+		},
+	},
+	{
+		name:   "65535",
+		schema: Schema{},
+		blk: &Block{
+			Value: []byte{
 				0b1111_1111,
 				0b1111_1111,
 			},
+			Negative: false,
 		},
-		{
-			name: "-6703903964971298549787012499102923063739682910296196688861780721860882015036773488400937149083451713845015929093243025426876941405973284973216824503042047",
-			schema: Schema{
-				Signed: true,
+		data: []byte{
+			0b0001_0000,
+			0b1111_1111,
+			0b1111_1111,
+		},
+	},
+	{
+		name:   "1048575",
+		schema: Schema{},
+		blk: &Block{
+			Value: []byte{
+				0b0000_1111,
+				0b1111_1111,
+				0b1111_1111,
 			},
-			blk: &Block{
-				Value: []byte{
-					0b0111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-					0b1111_1111,
-				},
-				Negative: true,
+			Negative: false,
+		},
+		data: []byte{
+			0b0001_1111,
+			0b1111_1111,
+			0b1111_1111,
+		},
+	},
+	{
+		name:   "1048576",
+		schema: Schema{},
+		blk: &Block{
+			Value: []byte{
+				0b0001_0000,
+				0b0000_0000,
+				0b0000_0000,
 			},
-			data: []byte{
+			Negative: false,
+		},
+		data: []byte{
+			0b0100_0010,
+			0b0001_0000,
+			0b0000_0000,
+			0b0000_0000,
+		},
+	},
+	{
+		name: "-6703903964971298549787012499102923063739682910296196688861780721860882015036773488400937149083451713845015929093243025426876941405973284973216824503042047",
+		schema: Schema{
+			Signed: true,
+		},
+		blk: &Block{
+			Value: []byte{
 				0b0111_1111,
 				0b1111_1111,
 				0b1111_1111,
@@ -399,10 +445,81 @@ func TestEncodeDecode(t *testing.T) {
 				0b1111_1111,
 				0b1111_1111,
 				0b1111_1111,
-				0b1111_1111,
 			},
+			Negative: true,
 		},
-	}
+		data: []byte{
+			0b0111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+			0b1111_1111,
+		},
+	},
+}
+
+func TestEncodeDecode(t *testing.T) {
+	tcs := encodeDecodeTCs
 
 	for i, tc := range tcs {
 		t.Run(fmt.Sprintf("[%d]%s", i, tc.name), func(t *testing.T) {
@@ -443,6 +560,94 @@ func TestEncodeDecode(t *testing.T) {
 	}
 }
 
+func TestRoundTrip(t *testing.T) {
+	for _, nullable := range []bool{false, true} {
+		for _, signed := range []bool{false, true} {
+			name := fmt.Sprintf("signed=%t/nullable=%t", signed, nullable)
+
+			t.Run(name, func(t *testing.T) {
+				schema := Schema{
+					Signed:   signed,
+					Nullable: nullable,
+				}
+
+				// Every vector for the schema is written to a single
+				// stream with nulls between them when allowed.
+				var blks []*Block
+				for _, tc := range encodeDecodeTCs {
+					if tc.schema.Signed != signed {
+						continue
+					}
+
+					blks = append(blks, tc.blk)
+					if nullable {
+						blks = append(blks, &Block{})
+					}
+				}
+
+				buf := bytes.NewBuffer(nil)
+				enc := NewEncoder(schema, control.NewEncoder(buf))
+
+				for _, blk := range blks {
+					require.NoError(t, enc.Encode(blk))
+				}
+
+				decoders := map[string]control.Decoder{
+					"reader": control.NewDecoder(bytes.NewReader(buf.Bytes())),
+					"bytes":  control.NewBytesDecoder(buf.Bytes()),
+				}
+
+				for dname, cd := range decoders {
+					dec := NewDecoder(schema, cd)
+
+					for j, want := range blks {
+						blk := &Block{}
+						require.NoError(t, dec.Decode(blk), dname, j)
+						require.Equal(t, want, blk, dname, j)
+					}
+
+					err := dec.Decode(&Block{})
+					require.True(t, errors.Is(err, io.EOF), dname)
+				}
+			})
+		}
+	}
+}
+
+func TestEncodeDecodeErrors(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+
+	// Nulls require a nullable schema.
+	enc := NewEncoder(Schema{}, control.NewEncoder(buf))
+	require.True(t, errors.Is(enc.Encode(&Block{}), ErrNull))
+
+	// Negative values require a signed schema.
+	err := enc.Encode(&Block{Value: []byte{1}, Negative: true})
+	require.Error(t, err)
+
+	require.Equal(t, 0, buf.Len())
+
+	dec := NewDecoder(Schema{}, control.NewDecoder(bytes.NewReader([]byte{
+		0b_0000_0000,
+	})))
+	require.True(t, errors.Is(dec.Decode(&Block{}), ErrNull))
+
+	// Only data fields hold integers.
+	dec = NewDecoder(Schema{}, control.NewDecoder(bytes.NewReader([]byte{
+		0b_0000_0001,
+	})))
+	require.Error(t, dec.Decode(&Block{}))
+
+	// Leading zero bytes are removed when decoding.
+	dec = NewDecoder(Schema{}, control.NewDecoder(bytes.NewReader([]byte{
+		0b_0100_0001, 0x00, 0x05,
+	})))
+
+	blk := &Block{}
+	require.NoError(t, dec.Decode(blk))
+	require.Equal(t, &Block{Value: []byte{5}}, blk)
+}
+
 func BenchmarkEncode(b *testing.B) {
 	buf := bytes.NewBuffer(nil)
 	ce := control.NewEncoder(buf)
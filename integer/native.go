@@ -0,0 +1,339 @@
+package integer
+
+import (
+	"encoding/binary"
+	"math"
+	"math/big"
+)
+
+// ErrOverflow is matched by the RangeError returned when a decoded integer
+// doesn't fit in the requested type.
+var ErrOverflow = Error.New("integer overflow")
+
+// trim removes the leading zero bytes from data leaving at least one byte.
+func trim(data []byte) []byte {
+	for len(data) > 1 && data[0] == 0 {
+		data = data[1:]
+	}
+
+	return data
+}
+
+// unsignedBytes returns the encoding of v for an unsigned schema.
+func unsignedBytes(v uint64) []byte {
+	var buf [8]byte
+
+	binary.BigEndian.PutUint64(buf[:], v)
+
+	return trim(buf[:])
+}
+
+// signedBytes returns the encoding of the magnitude m with a trailing sign
+// bit. The result can be up to 65 bits long.
+func signedBytes(m uint64, negative bool) []byte {
+	var buf [9]byte
+
+	buf[0] = byte(m >> 63)
+
+	z := m << 1
+	if negative {
+		z |= 1
+	}
+
+	binary.BigEndian.PutUint64(buf[1:], z)
+
+	return trim(buf[:])
+}
+
+// EncodeUint64 writes v. It writes the same bytes as Encode with a Block
+// holding v.
+func (e *Encoder) EncodeUint64(v uint64) (err error) {
+	defer wrapP(&err)
+
+	if e.delta != nil {
+		return e.delta.EncodeUint64(v)
+	}
+
+	err = e.schema.checkUint64(v, -1)
+	if err != nil {
+		return err
+	}
+
+	if e.schema.Signed {
+		return e.data(signedBytes(v, false))
+	}
+
+	return e.data(unsignedBytes(v))
+}
+
+// EncodeInt64 writes v. It writes the same bytes as Encode with a Block
+// holding v. Negative values require a signed schema.
+func (e *Encoder) EncodeInt64(v int64) (err error) {
+	defer wrapP(&err)
+
+	if e.delta != nil {
+		return e.delta.EncodeInt64(v)
+	}
+
+	if v >= 0 {
+		return e.EncodeUint64(uint64(v))
+	}
+
+	if !e.schema.Signed {
+		return Error.New("negative value for unsigned integer")
+	}
+
+	// Negate in uint64 so that math.MinInt64 doesn't overflow.
+	m := -uint64(v)
+
+	err = e.schema.checkNative(m, true, -1)
+	if err != nil {
+		return err
+	}
+
+	return e.data(signedBytes(m, true))
+}
+
+// EncodeUint32 writes v (see EncodeUint64).
+func (e *Encoder) EncodeUint32(v uint32) error {
+	return e.EncodeUint64(uint64(v))
+}
+
+// EncodeUint16 writes v (see EncodeUint64).
+func (e *Encoder) EncodeUint16(v uint16) error {
+	return e.EncodeUint64(uint64(v))
+}
+
+// EncodeUint8 writes v (see EncodeUint64).
+func (e *Encoder) EncodeUint8(v uint8) error {
+	return e.EncodeUint64(uint64(v))
+}
+
+// EncodeInt32 writes v (see EncodeInt64).
+func (e *Encoder) EncodeInt32(v int32) error {
+	return e.EncodeInt64(int64(v))
+}
+
+// EncodeInt16 writes v (see EncodeInt64).
+func (e *Encoder) EncodeInt16(v int16) error {
+	return e.EncodeInt64(int64(v))
+}
+
+// EncodeInt8 writes v (see EncodeInt64).
+func (e *Encoder) EncodeInt8(v int8) error {
+	return e.EncodeInt64(int64(v))
+}
+
+// overflow returns the error for data that doesn't fit in the type with the
+// bounds b. It is a RangeError for the schema's range if the data is also
+// outside of that.
+func (d *Decoder) overflow(data []byte, b bounds) (err error) {
+	blk := Block{
+		Value: normalize(data),
+	}
+
+	if d.schema.Signed {
+		err = blk.UnmarshalBinary(data)
+		if err != nil {
+			return err
+		}
+	}
+
+	i := blk.BigInt()
+
+	err = d.schema.check(i, int64(d.offset))
+	if err != nil {
+		return err
+	}
+
+	return overflow(i, b, int64(d.offset))
+}
+
+// decodeNative reads the next integer as a magnitude and sign. Nulls can't be
+// represented and are returned as ErrNull. Integers that don't fit in 64 bits
+// are returned as a RangeError for the type with the bounds b.
+func (d *Decoder) decodeNative(b bounds) (m uint64, negative bool, err error) {
+	data, err := d.next()
+	if err != nil {
+		return 0, false, err
+	}
+
+	if data == nil {
+		return 0, false, ErrNull
+	}
+
+	data = trim(data)
+
+	if !d.schema.Signed {
+		if len(data) > 8 {
+			return 0, false, d.overflow(data, b)
+		}
+
+		for _, b := range data {
+			m = m<<8 | uint64(b)
+		}
+
+		return m, false, d.schema.checkUint64(m, int64(d.offset))
+	}
+
+	// The magnitude plus the sign bit may use up to 65 bits.
+	if len(data) > 9 || (len(data) == 9 && data[0] > 1) {
+		return 0, false, d.overflow(data, b)
+	}
+
+	var hi uint64
+	if len(data) == 9 {
+		hi = uint64(data[0])
+		data = data[1:]
+	}
+
+	var z uint64
+	for _, b := range data {
+		z = z<<8 | uint64(b)
+	}
+
+	m, negative = z>>1|hi<<63, z&1 == 1
+
+	return m, negative, d.schema.checkNative(m, negative, int64(d.offset))
+}
+
+// native returns the magnitude and sign as a big.Int.
+func native(m uint64, negative bool) *big.Int {
+	i := new(big.Int).SetUint64(m)
+	if negative {
+		i.Neg(i)
+	}
+
+	return i
+}
+
+// DecodeUint64 reads an integer that must fit in a uint64. At the end of the
+// input it returns io.EOF. A negative value or one that is too large results
+// in a RangeError that also matches ErrOverflow.
+func (d *Decoder) DecodeUint64() (v uint64, err error) {
+	defer wrapP(&err)
+
+	return d.decodeUint(math.MaxUint64)
+}
+
+// DecodeInt64 reads an integer that must fit in an int64. At the end of the
+// input it returns io.EOF. A value that is too large or too small results in
+// a RangeError that also matches ErrOverflow.
+func (d *Decoder) DecodeInt64() (v int64, err error) {
+	defer wrapP(&err)
+
+	return d.decodeInt(math.MinInt64, math.MaxInt64)
+}
+
+// decodeUint reads an unsigned integer no larger than max.
+func (d *Decoder) decodeUint(max uint64) (v uint64, err error) {
+	b := bounds{0, max}
+
+	if d.delta != nil {
+		i, err := d.delta.decodeRange(b)
+		if err != nil {
+			return 0, err
+		}
+
+		return i.Uint64(), nil
+	}
+
+	m, negative, err := d.decodeNative(b)
+	if err != nil {
+		return 0, err
+	}
+
+	if (negative && m != 0) || m > max {
+		return 0, overflow(native(m, negative), b, int64(d.offset))
+	}
+
+	return m, nil
+}
+
+// decodeInt reads a signed integer between min and max.
+func (d *Decoder) decodeInt(min, max int64) (v int64, err error) {
+	b := bounds{min, uint64(max)}
+
+	if d.delta != nil {
+		i, err := d.delta.decodeRange(b)
+		if err != nil {
+			return 0, err
+		}
+
+		return i.Int64(), nil
+	}
+
+	m, negative, err := d.decodeNative(b)
+	if err != nil {
+		return 0, err
+	}
+
+	// Compare in uint64 so that math.MinInt64 doesn't overflow.
+	if negative && m != 0 {
+		if m > -uint64(min) {
+			return 0, overflow(native(m, negative), b, int64(d.offset))
+		}
+
+		return int64(-m), nil
+	}
+
+	if m > uint64(max) {
+		return 0, overflow(native(m, negative), b, int64(d.offset))
+	}
+
+	return int64(m), nil
+}
+
+// DecodeUint32 reads an integer that must fit in a uint32 (see DecodeUint64).
+func (d *Decoder) DecodeUint32() (v uint32, err error) {
+	defer wrapP(&err)
+
+	u, err := d.decodeUint(math.MaxUint32)
+
+	return uint32(u), err
+}
+
+// DecodeUint16 reads an integer that must fit in a uint16 (see DecodeUint64).
+func (d *Decoder) DecodeUint16() (v uint16, err error) {
+	defer wrapP(&err)
+
+	u, err := d.decodeUint(math.MaxUint16)
+
+	return uint16(u), err
+}
+
+// DecodeUint8 reads an integer that must fit in a uint8 (see DecodeUint64).
+func (d *Decoder) DecodeUint8() (v uint8, err error) {
+	defer wrapP(&err)
+
+	u, err := d.decodeUint(math.MaxUint8)
+
+	return uint8(u), err
+}
+
+// DecodeInt32 reads an integer that must fit in an int32 (see DecodeInt64).
+func (d *Decoder) DecodeInt32() (v int32, err error) {
+	defer wrapP(&err)
+
+	i, err := d.decodeInt(math.MinInt32, math.MaxInt32)
+
+	return int32(i), err
+}
+
+// DecodeInt16 reads an integer that must fit in an int16 (see DecodeInt64).
+func (d *Decoder) DecodeInt16() (v int16, err error) {
+	defer wrapP(&err)
+
+	i, err := d.decodeInt(math.MinInt16, math.MaxInt16)
+
+	return int16(i), err
+}
+
+// DecodeInt8 reads an integer that must fit in an int8 (see DecodeInt64).
+func (d *Decoder) DecodeInt8() (v int8, err error) {
+	defer wrapP(&err)
+
+	i, err := d.decodeInt(math.MinInt8, math.MaxInt8)
+
+	return int8(i), err
+}
@@ -0,0 +1,300 @@
+package integer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/calebcase/bsv/control"
+	"github.com/stretchr/testify/require"
+)
+
+// nativeValues cover the d, d1, d2 and dz thresholds with and without the
+// sign bit.
+var nativeValues = []int64{
+	0, 1, 2,
+	63, 64, 127, 128,
+	4095, 4096, 8191, 8192,
+	524287, 524288, 1048575, 1048576,
+	math.MaxInt32, math.MaxInt32 + 1,
+	math.MaxInt64 - 1, math.MaxInt64,
+}
+
+// bigBlock returns the block for i.
+func bigBlock(i *big.Int) *Block {
+	value := new(big.Int).Abs(i).Bytes()
+	if len(value) == 0 {
+		value = []byte{0}
+	}
+
+	return &Block{
+		Value:    value,
+		Negative: i.Sign() < 0,
+	}
+}
+
+// encodeBlock returns the encoding of blk.
+func encodeBlock(t *testing.T, schema Schema, blk *Block) []byte {
+	buf := bytes.NewBuffer(nil)
+
+	err := NewEncoder(schema, control.NewEncoder(buf)).Encode(blk)
+	require.NoError(t, err)
+
+	return buf.Bytes()
+}
+
+func TestNativeInt64(t *testing.T) {
+	for _, signed := range []bool{false, true} {
+		schema := Schema{Signed: signed}
+
+		var values []int64
+		for _, v := range nativeValues {
+			values = append(values, v)
+			if signed && v != 0 {
+				values = append(values, -v)
+			}
+		}
+		if signed {
+			values = append(values, math.MinInt64)
+		}
+
+		for _, v := range values {
+			name := fmt.Sprintf("signed=%t/%d", signed, v)
+
+			t.Run(name, func(t *testing.T) {
+				want := encodeBlock(t, schema, bigBlock(big.NewInt(v)))
+
+				buf := bytes.NewBuffer(nil)
+				enc := NewEncoder(schema, control.NewEncoder(buf))
+				require.NoError(t, enc.EncodeInt64(v))
+				require.Equal(t, want, buf.Bytes())
+
+				dec := NewDecoder(schema, control.NewBytesDecoder(buf.Bytes()))
+				got, err := dec.DecodeInt64()
+				require.NoError(t, err)
+				require.Equal(t, v, got)
+
+				_, err = dec.DecodeInt64()
+				require.True(t, errors.Is(err, io.EOF))
+			})
+		}
+	}
+}
+
+func TestNativeUint64(t *testing.T) {
+	values := []uint64{math.MaxInt64 + 1, math.MaxUint64 - 1, math.MaxUint64}
+	for _, v := range nativeValues {
+		values = append(values, uint64(v))
+	}
+
+	for _, signed := range []bool{false, true} {
+		schema := Schema{Signed: signed}
+
+		for _, v := range values {
+			name := fmt.Sprintf("signed=%t/%d", signed, v)
+
+			t.Run(name, func(t *testing.T) {
+				want := encodeBlock(t, schema, bigBlock(new(big.Int).SetUint64(v)))
+
+				buf := bytes.NewBuffer(nil)
+				enc := NewEncoder(schema, control.NewEncoder(buf))
+				require.NoError(t, enc.EncodeUint64(v))
+				require.Equal(t, want, buf.Bytes())
+
+				dec := NewDecoder(schema, control.NewBytesDecoder(buf.Bytes()))
+				got, err := dec.DecodeUint64()
+				require.NoError(t, err)
+				require.Equal(t, v, got)
+			})
+		}
+	}
+}
+
+func TestNativeSmall(t *testing.T) {
+	schema := Schema{Signed: true}
+
+	buf := bytes.NewBuffer(nil)
+	enc := NewEncoder(schema, control.NewEncoder(buf))
+
+	require.NoError(t, enc.EncodeInt8(math.MinInt8))
+	require.NoError(t, enc.EncodeInt16(math.MinInt16))
+	require.NoError(t, enc.EncodeInt32(math.MinInt32))
+	require.NoError(t, enc.EncodeUint8(math.MaxUint8))
+	require.NoError(t, enc.EncodeUint16(math.MaxUint16))
+	require.NoError(t, enc.EncodeUint32(math.MaxUint32))
+
+	dec := NewDecoder(schema, control.NewBytesDecoder(buf.Bytes()))
+
+	i8, err := dec.DecodeInt8()
+	require.NoError(t, err)
+	require.Equal(t, int8(math.MinInt8), i8)
+
+	i16, err := dec.DecodeInt16()
+	require.NoError(t, err)
+	require.Equal(t, int16(math.MinInt16), i16)
+
+	i32, err := dec.DecodeInt32()
+	require.NoError(t, err)
+	require.Equal(t, int32(math.MinInt32), i32)
+
+	u8, err := dec.DecodeUint8()
+	require.NoError(t, err)
+	require.Equal(t, uint8(math.MaxUint8), u8)
+
+	u16, err := dec.DecodeUint16()
+	require.NoError(t, err)
+	require.Equal(t, uint16(math.MaxUint16), u16)
+
+	u32, err := dec.DecodeUint32()
+	require.NoError(t, err)
+	require.Equal(t, uint32(math.MaxUint32), u32)
+}
+
+func TestNativeErrors(t *testing.T) {
+	type TC struct {
+		name   string
+		schema Schema
+		blk    *Block
+		decode func(d *Decoder) error
+		err    error
+	}
+
+	maxUint64 := new(big.Int).SetUint64(math.MaxUint64)
+
+	tcs := []TC{
+		{
+			name:   "uint64 too large",
+			blk:    bigBlock(new(big.Int).Add(maxUint64, big.NewInt(1))),
+			decode: func(d *Decoder) (err error) { _, err = d.DecodeUint64(); return err },
+			err:    ErrOverflow,
+		},
+		{
+			name:   "signed uint64 too large",
+			schema: Schema{Signed: true},
+			blk:    bigBlock(new(big.Int).Add(maxUint64, big.NewInt(1))),
+			decode: func(d *Decoder) (err error) { _, err = d.DecodeUint64(); return err },
+			err:    ErrOverflow,
+		},
+		{
+			name:   "negative uint64",
+			schema: Schema{Signed: true},
+			blk:    bigBlock(big.NewInt(-1)),
+			decode: func(d *Decoder) (err error) { _, err = d.DecodeUint64(); return err },
+			err:    ErrOverflow,
+		},
+		{
+			name:   "int64 too large",
+			blk:    bigBlock(new(big.Int).SetUint64(math.MaxInt64 + 1)),
+			decode: func(d *Decoder) (err error) { _, err = d.DecodeInt64(); return err },
+			err:    ErrOverflow,
+		},
+		{
+			name:   "int64 too small",
+			schema: Schema{Signed: true},
+			blk:    bigBlock(new(big.Int).Sub(big.NewInt(math.MinInt64), big.NewInt(1))),
+			decode: func(d *Decoder) (err error) { _, err = d.DecodeInt64(); return err },
+			err:    ErrOverflow,
+		},
+		{
+			name:   "uint8 too large",
+			blk:    bigBlock(big.NewInt(math.MaxUint8 + 1)),
+			decode: func(d *Decoder) (err error) { _, err = d.DecodeUint8(); return err },
+			err:    ErrOverflow,
+		},
+		{
+			name:   "int8 too small",
+			schema: Schema{Signed: true},
+			blk:    bigBlock(big.NewInt(math.MinInt8 - 1)),
+			decode: func(d *Decoder) (err error) { _, err = d.DecodeInt8(); return err },
+			err:    ErrOverflow,
+		},
+		{
+			name:   "int32 too large",
+			schema: Schema{Signed: true},
+			blk:    bigBlock(big.NewInt(math.MaxInt32 + 1)),
+			decode: func(d *Decoder) (err error) { _, err = d.DecodeInt32(); return err },
+			err:    ErrOverflow,
+		},
+		{
+			name:   "null",
+			schema: Schema{Nullable: true},
+			blk:    &Block{},
+			decode: func(d *Decoder) (err error) { _, err = d.DecodeInt64(); return err },
+			err:    ErrNull,
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(fmt.Sprintf("[%d]%s", i, tc.name), func(t *testing.T) {
+			// A zero precedes the value so that its offset is 1.
+			data := append([]byte{0b_1000_0000}, encodeBlock(t, tc.schema, tc.blk)...)
+
+			dec := NewDecoder(tc.schema, control.NewBytesDecoder(data))
+
+			_, err := dec.DecodeBigInt()
+			require.NoError(t, err)
+
+			err = tc.decode(dec)
+			require.Error(t, err)
+			require.True(t, errors.Is(err, tc.err), err)
+
+			if tc.err == ErrOverflow {
+				require.True(t, errors.Is(err, ErrRange), err)
+
+				var re *RangeError
+				require.True(t, errors.As(err, &re), err)
+				require.True(t, re.Overflow, err)
+				require.Equal(t, int64(1), re.Offset, err)
+				require.Equal(t, tc.blk.BigInt(), re.Value, err)
+			}
+		})
+	}
+
+	// Negative values require a signed schema.
+	enc := NewEncoder(Schema{}, control.NewEncoder(bytes.NewBuffer(nil)))
+	require.Error(t, enc.EncodeInt64(-1))
+}
+
+func BenchmarkEncodeInt64(b *testing.B) {
+	buf := bytes.NewBuffer(nil)
+	ce := control.NewEncoder(buf)
+
+	schema := Schema{
+		Signed: true,
+	}
+	enc := NewEncoder(schema, ce)
+
+	for n := 0; n < b.N; n++ {
+		buf.Reset()
+
+		err := enc.EncodeInt64(-524287)
+		if err != nil {
+			b.Fatalf("%+v", err)
+		}
+	}
+}
+
+func BenchmarkDecodeInt64(b *testing.B) {
+	data := []byte{
+		0b0001_1111,
+		0b1111_1111,
+		0b1111_1111,
+	}
+
+	schema := Schema{
+		Signed: true,
+	}
+
+	for n := 0; n < b.N; n++ {
+		dec := NewDecoder(schema, control.NewBytesDecoder(data))
+
+		_, err := dec.DecodeInt64()
+		if err != nil {
+			b.Fatalf("%+v", err)
+		}
+	}
+}
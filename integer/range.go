@@ -0,0 +1,186 @@
+package integer
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// ErrRange is matched by a RangeError.
+var ErrRange = Error.New("integer out of range")
+
+// RangeError is returned when an integer is outside of the range allowed by
+// the schema (see Schema.Range). It matches ErrRange with errors.Is.
+type RangeError struct {
+	// Value is the out of range integer.
+	Value *big.Int
+
+	// Min and Max are the bounds of the range. A nil bound is unbounded.
+	Min *big.Int
+	Max *big.Int
+
+	// Offset is the offset of the field in the input (see
+	// control.Decoder.Consumed). Encoders don't know their offset and set
+	// it to -1.
+	Offset int64
+
+	// Overflow is set when the integer doesn't fit in the type it is
+	// decoded into and Min and Max are the bounds of that type. The error
+	// then also matches ErrOverflow.
+	Overflow bool
+}
+
+func (re *RangeError) Error() string {
+	bound := func(b *big.Int, inf string) string {
+		if b == nil {
+			return inf
+		}
+
+		return b.String()
+	}
+
+	kind := ErrRange
+	if re.Overflow {
+		kind = ErrOverflow
+	}
+
+	msg := fmt.Sprintf(
+		"%v: %s not in [%s, %s]",
+		kind,
+		re.Value,
+		bound(re.Min, "-inf"),
+		bound(re.Max, "+inf"),
+	)
+
+	if re.Offset >= 0 {
+		msg += fmt.Sprintf(" (offset=%d)", re.Offset)
+	}
+
+	return msg
+}
+
+func (re *RangeError) Is(target error) bool {
+	return target == ErrRange || (re.Overflow && target == ErrOverflow)
+}
+
+// wrapP is like Error.WrapP, but returns a RangeError as is. Its message
+// already starts with the class.
+func wrapP(err *error) {
+	if _, ok := (*err).(*RangeError); ok {
+		return
+	}
+
+	Error.WrapP(err)
+}
+
+// bounds are the bounds of a native integer type.
+type bounds struct {
+	min int64
+	max uint64
+}
+
+// ints returns the bounds as big.Ints.
+func (b bounds) ints() (min, max *big.Int) {
+	return big.NewInt(b.min), new(big.Int).SetUint64(b.max)
+}
+
+// overflow returns the RangeError for i not fitting in the type with the
+// bounds b.
+func overflow(i *big.Int, b bounds, offset int64) error {
+	min, max := b.ints()
+
+	return &RangeError{
+		Value:    new(big.Int).Set(i),
+		Min:      min,
+		Max:      max,
+		Offset:   offset,
+		Overflow: true,
+	}
+}
+
+// Range returns the bounds on integers allowed by the schema. The bounds are
+// the tightest of those set by Signed, Bits, Min and Max. A nil bound is
+// unbounded. An error is returned if Bits isn't 0, 8, 16, 32 or 64.
+func (s Schema) Range() (min, max *big.Int, err error) {
+	switch s.Bits {
+	case 0:
+	case 8, 16, 32, 64:
+		bits := uint(s.Bits)
+		if s.Signed {
+			bits--
+
+			min = new(big.Int).Lsh(big.NewInt(1), bits)
+			min.Neg(min)
+		}
+
+		max = new(big.Int).Lsh(big.NewInt(1), bits)
+		max.Sub(max, big.NewInt(1))
+	default:
+		return nil, nil, Error.New("invalid bits: %d", s.Bits)
+	}
+
+	if !s.Signed {
+		min = new(big.Int)
+	}
+
+	if s.Min != nil && (min == nil || s.Min.Cmp(min) > 0) {
+		min = s.Min
+	}
+
+	if s.Max != nil && (max == nil || s.Max.Cmp(max) < 0) {
+		max = s.Max
+	}
+
+	return min, max, nil
+}
+
+// constrained reports if the schema limits integers beyond their sign.
+func (s Schema) constrained() bool {
+	return s.Bits != 0 || s.Min != nil || s.Max != nil
+}
+
+// check returns a RangeError if i is outside of the schema's range. A nil i
+// (a null) is always in range.
+func (s Schema) check(i *big.Int, offset int64) (err error) {
+	if i == nil || !s.constrained() {
+		return nil
+	}
+
+	min, max, err := s.Range()
+	if err != nil {
+		return err
+	}
+
+	if (min != nil && i.Cmp(min) < 0) || (max != nil && i.Cmp(max) > 0) {
+		return &RangeError{
+			Value:  new(big.Int).Set(i),
+			Min:    min,
+			Max:    max,
+			Offset: offset,
+		}
+	}
+
+	return nil
+}
+
+// checkUint64 is like check, but for a uint64.
+func (s Schema) checkUint64(v uint64, offset int64) error {
+	if !s.constrained() {
+		return nil
+	}
+
+	return s.check(new(big.Int).SetUint64(v), offset)
+}
+
+// checkNative is like check, but for a magnitude and sign.
+func (s Schema) checkNative(m uint64, negative bool, offset int64) error {
+	if !s.constrained() {
+		return nil
+	}
+
+	i := new(big.Int).SetUint64(m)
+	if negative {
+		i.Neg(i)
+	}
+
+	return s.check(i, offset)
+}
@@ -0,0 +1,270 @@
+package integer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/calebcase/bsv/control"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaRange(t *testing.T) {
+	type TC struct {
+		schema Schema
+		min    string
+		max    string
+	}
+
+	tcs := []TC{
+		{Schema{}, "0", "+inf"},
+		{Schema{Signed: true}, "-inf", "+inf"},
+		{Schema{Bits: 8}, "0", "255"},
+		{Schema{Bits: 8, Signed: true}, "-128", "127"},
+		{Schema{Bits: 16}, "0", "65535"},
+		{Schema{Bits: 32, Signed: true}, "-2147483648", "2147483647"},
+		{Schema{Bits: 64}, "0", "18446744073709551615"},
+		{Schema{Bits: 64, Signed: true}, "-9223372036854775808", "9223372036854775807"},
+		{Schema{Min: big.NewInt(-5), Max: big.NewInt(5)}, "0", "5"},
+		{Schema{Min: big.NewInt(-5), Signed: true}, "-5", "+inf"},
+		{Schema{Bits: 8, Min: big.NewInt(10), Max: big.NewInt(1000)}, "10", "255"},
+		{Schema{Bits: 8, Signed: true, Min: big.NewInt(-1000), Max: big.NewInt(-1)}, "-128", "-1"},
+	}
+
+	bound := func(b *big.Int, inf string) string {
+		if b == nil {
+			return inf
+		}
+
+		return b.String()
+	}
+
+	for i, tc := range tcs {
+		mark := fmt.Sprintf("[%d] %+v", i, tc.schema)
+
+		min, max, err := tc.schema.Range()
+		require.NoError(t, err, mark)
+		require.Equal(t, tc.min, bound(min, "-inf"), mark)
+		require.Equal(t, tc.max, bound(max, "+inf"), mark)
+	}
+
+	_, _, err := Schema{Bits: 12}.Range()
+	require.Error(t, err)
+
+	err = NewEncoder(Schema{Bits: 12}, control.NewEncoder(bytes.NewBuffer(nil))).EncodeInt64(1)
+	require.Error(t, err)
+}
+
+func TestRangeEncode(t *testing.T) {
+	type TC struct {
+		schema Schema
+		ok     []int64
+		bad    []int64
+	}
+
+	tcs := []TC{
+		{
+			schema: Schema{Bits: 8},
+			ok:     []int64{0, 255},
+			bad:    []int64{256, math.MaxInt64},
+		},
+		{
+			schema: Schema{Bits: 8, Signed: true},
+			ok:     []int64{-128, 0, 127},
+			bad:    []int64{-129, 128, math.MinInt64},
+		},
+		{
+			schema: Schema{Bits: 32, Signed: true},
+			ok:     []int64{math.MinInt32, math.MaxInt32},
+			bad:    []int64{math.MinInt32 - 1, math.MaxInt32 + 1},
+		},
+		{
+			schema: Schema{Signed: true, Min: big.NewInt(-10), Max: big.NewInt(10)},
+			ok:     []int64{-10, 0, 10},
+			bad:    []int64{-11, 11},
+		},
+	}
+
+	for i, tc := range tcs {
+		enc := NewEncoder(tc.schema, control.NewEncoder(bytes.NewBuffer(nil)))
+
+		for _, v := range tc.ok {
+			mark := fmt.Sprintf("[%d] %d", i, v)
+
+			require.NoError(t, enc.EncodeInt64(v), mark)
+			require.NoError(t, enc.EncodeBigInt(big.NewInt(v)), mark)
+		}
+
+		for _, v := range tc.bad {
+			mark := fmt.Sprintf("[%d] %d", i, v)
+
+			buf := bytes.NewBuffer(nil)
+			enc := NewEncoder(tc.schema, control.NewEncoder(buf))
+
+			err := enc.EncodeInt64(v)
+			require.ErrorIs(t, err, ErrRange, mark)
+
+			var re *RangeError
+			require.True(t, errors.As(err, &re), mark)
+			require.Equal(t, int64(-1), re.Offset, mark)
+			require.Equal(t, big.NewInt(v).String(), re.Value.String(), mark)
+
+			require.ErrorIs(t, enc.EncodeBigInt(big.NewInt(v)), ErrRange, mark)
+			require.Equal(t, 0, buf.Len(), mark)
+		}
+	}
+
+	// Values larger than 64 bits.
+	huge := new(big.Int).Lsh(big.NewInt(1), 320)
+	enc := NewEncoder(Schema{Bits: 64}, control.NewEncoder(bytes.NewBuffer(nil)))
+	require.ErrorIs(t, enc.EncodeBigInt(huge), ErrRange)
+	require.NoError(t, enc.EncodeUint64(math.MaxUint64))
+
+	// Nulls are never out of range.
+	enc = NewEncoder(Schema{Bits: 8, Nullable: true}, control.NewEncoder(bytes.NewBuffer(nil)))
+	require.NoError(t, enc.EncodeBigInt(nil))
+}
+
+func TestRangeDecode(t *testing.T) {
+	huge := new(big.Int).Lsh(big.NewInt(1), 320)
+
+	// Write the values without constraints so that the decoder sees them.
+	buf := bytes.NewBuffer(nil)
+	enc := NewEncoder(Schema{Signed: true}, control.NewEncoder(buf))
+
+	var offsets []int64
+	for _, i := range []*big.Int{big.NewInt(1), big.NewInt(-129), huge, big.NewInt(127), big.NewInt(1000)} {
+		offsets = append(offsets, int64(buf.Len()))
+		require.NoError(t, enc.EncodeBigInt(i))
+	}
+
+	schema := Schema{Bits: 8, Signed: true}
+
+	// check reads all of the values with decode, which returns an error
+	// for the values at the out of range indexes.
+	check := func(decode func(d *Decoder) error) {
+		for _, cd := range []control.Decoder{
+			control.NewDecoder(bytes.NewReader(buf.Bytes())),
+			control.NewBytesDecoder(buf.Bytes()),
+		} {
+			dec := NewDecoder(schema, cd)
+
+			for i, offset := range offsets {
+				mark := fmt.Sprintf("[%d]", i)
+
+				err := decode(dec)
+
+				switch i {
+				case 1, 2, 4:
+					require.ErrorIs(t, err, ErrRange, mark)
+
+					// The RangeError is returned as is.
+					re, ok := err.(*RangeError)
+					require.True(t, ok, mark)
+					require.Equal(t, offset, re.Offset, mark)
+					require.Contains(t, err.Error(), fmt.Sprintf("offset=%d", offset), mark)
+					require.NotContains(t, err.Error(), "integer: integer:", mark)
+				default:
+					require.NoError(t, err, mark)
+				}
+			}
+		}
+	}
+
+	check(func(d *Decoder) error {
+		return d.Decode(&Block{})
+	})
+
+	check(func(d *Decoder) error {
+		_, err := d.DecodeInt64()
+		return err
+	})
+
+	check(func(d *Decoder) error {
+		_, err := d.DecodeInt8()
+		return err
+	})
+
+	check(func(d *Decoder) error {
+		_, err := d.DecodeBigInt()
+		return err
+	})
+
+	// Values in range can still be too large for an int64.
+	buf.Reset()
+	require.NoError(t, enc.EncodeBigInt(huge))
+
+	dec := NewDecoder(Schema{Signed: true, Min: big.NewInt(0)}, control.NewBytesDecoder(buf.Bytes()))
+
+	_, err := dec.DecodeInt64()
+	require.ErrorIs(t, err, ErrOverflow)
+	require.ErrorIs(t, err, ErrRange)
+
+	var re *RangeError
+	require.True(t, errors.As(err, &re))
+	require.True(t, re.Overflow)
+	require.Equal(t, big.NewInt(math.MinInt64), re.Min)
+	require.Equal(t, big.NewInt(math.MaxInt64), re.Max)
+	require.Equal(t, int64(0), re.Offset)
+	require.Contains(t, err.Error(), "integer overflow")
+}
+
+func TestRangeDelta(t *testing.T) {
+	schema := Schema{Bits: 16, Min: big.NewInt(100)}
+
+	buf := bytes.NewBuffer(nil)
+	enc := NewDeltaEncoder(schema, control.NewEncoder(buf))
+
+	require.NoError(t, enc.EncodeInt64(100))
+	require.NoError(t, enc.EncodeInt64(65535))
+	require.ErrorIs(t, enc.EncodeInt64(65536), ErrRange)
+	require.ErrorIs(t, enc.EncodeInt64(99), ErrRange)
+
+	// The rejected values don't change the previous integer.
+	require.NoError(t, enc.EncodeInt64(200))
+
+	offset := int64(buf.Len())
+	require.NoError(t, NewDeltaEncoder(Schema{}, control.NewEncoder(buf)).EncodeInt64(50))
+
+	dec := NewDeltaDecoder(schema, control.NewBytesDecoder(buf.Bytes()))
+
+	for _, want := range []int64{100, 65535, 200} {
+		v, err := dec.DecodeInt64()
+		require.NoError(t, err)
+		require.Equal(t, want, v)
+	}
+
+	// The last value was written from zero by an unconstrained encoder.
+	dec.Reset()
+
+	_, err := dec.DecodeInt64()
+	require.ErrorIs(t, err, ErrRange)
+
+	var re *RangeError
+	require.True(t, errors.As(err, &re))
+	require.Equal(t, offset, re.Offset)
+
+	// Narrowing reports the offset of the difference.
+	buf.Reset()
+	enc = NewDeltaEncoder(Schema{}, control.NewEncoder(buf))
+
+	require.NoError(t, enc.EncodeInt64(100))
+	offset = int64(buf.Len())
+	require.NoError(t, enc.EncodeInt64(200))
+
+	ndec := NewDecoder(Schema{Delta: true}, control.NewBytesDecoder(buf.Bytes()))
+
+	v, err := ndec.DecodeInt8()
+	require.NoError(t, err)
+	require.Equal(t, int8(100), v)
+
+	_, err = ndec.DecodeInt8()
+	require.ErrorIs(t, err, ErrOverflow)
+	require.True(t, errors.As(err, &re))
+	require.True(t, re.Overflow)
+	require.Equal(t, offset, re.Offset)
+	require.Equal(t, big.NewInt(200), re.Value)
+}
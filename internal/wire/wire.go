@@ -0,0 +1,51 @@
+// Package wire holds helpers shared by the packages that write their own
+// structures as BSV.
+package wire
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrRange is returned by BytesUint when the value doesn't fit in a uint64.
+var ErrRange = errors.New("invalid: value >= 2^64")
+
+// CountingWriter counts the bytes written to W.
+type CountingWriter struct {
+	W io.Writer
+	N int64
+}
+
+func (cw *CountingWriter) Write(p []byte) (n int, err error) {
+	n, err = cw.W.Write(p)
+	cw.N += int64(n)
+
+	return n, err
+}
+
+// UintBytes returns the minimal big-endian encoding of v.
+func UintBytes(v uint64) []byte {
+	b := []byte{byte(v)}
+	for v >>= 8; v != 0; v >>= 8 {
+		b = append([]byte{byte(v)}, b...)
+	}
+
+	return b
+}
+
+// BytesUint returns the value of the big-endian bytes.
+func BytesUint(b []byte) (v uint64, err error) {
+	for len(b) > 1 && b[0] == 0 {
+		b = b[1:]
+	}
+
+	if len(b) > 8 {
+		return 0, ErrRange
+	}
+
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+
+	return v, nil
+}
@@ -0,0 +1,26 @@
+package wire
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUint(t *testing.T) {
+	for _, v := range []uint64{0, 1, 0xff, 0x100, 0xffff_ffff, 1<<64 - 1} {
+		b := UintBytes(v)
+		require.True(t, len(b) == 1 || b[0] != 0, v)
+
+		got, err := BytesUint(b)
+		require.NoError(t, err, v)
+		require.Equal(t, v, got)
+	}
+
+	v, err := BytesUint([]byte{0, 0, 1})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), v)
+
+	_, err = BytesUint(bytes.Repeat([]byte{1}, 9))
+	require.ErrorIs(t, err, ErrRange)
+}
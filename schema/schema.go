@@ -1,6 +1,311 @@
 package schema
 
+import (
+	"bytes"
+	"math/big"
+
+	"github.com/calebcase/bsv/control"
+	"github.com/calebcase/bsv/integer"
+)
+
+// Column describes a column.
 type Column struct {
+	// Integer is the schema of an integer column.
+	Integer *integer.Schema
+}
+
+// property writes a bounded container holding the property's name followed by
+// the fields value writes. A nil value writes a flag.
+func property(e control.Encoder, name string, value func(e control.Encoder) error) (err error) {
+	buf := &bytes.Buffer{}
+	be := control.NewEncoder(buf)
+
+	err = be.Data([]byte(name))
+	if err != nil {
+		return err
+	}
+
+	if value != nil {
+		err = value(be)
+		if err != nil {
+			return err
+		}
+	}
+
+	return e.Bound(buf.Bytes())
+}
+
+// properties calls fn with the name of each property in the container d has
+// entered and a decoder positioned after the name. Other fields are skipped.
+// It returns at the end of the container or the input.
+func properties(d control.Decoder, fn func(name string, pd control.Decoder) error) (err error) {
+	depth := d.Depth()
+
+	for d.Next() {
+		if d.Type() == control.ContainerEnd && d.Depth() < depth {
+			return nil
+		}
+
+		if d.Type() != control.ContainerBounded {
+			continue
+		}
+
+		bsv, err := d.BSV()
+		if err != nil {
+			return err
+		}
+
+		pd := control.NewBytesDecoder(bsv)
+		if !pd.Next() {
+			if pd.Err() != nil {
+				return pd.Err()
+			}
+
+			return Error.New("invalid property: empty")
+		}
+
+		name, err := pd.Data()
+		if err != nil {
+			return err
+		}
+
+		err = fn(string(name), pd)
+		if err != nil {
+			return err
+		}
+	}
+
+	return d.Err()
+}
+
+// encodeInteger writes the properties of an integer schema.
+func encodeInteger(e control.Encoder, is integer.Schema) (err error) {
+	flags := []struct {
+		name string
+		set  bool
+	}{
+		{"signed", is.Signed},
+		{"delta", is.Delta},
+		{"nullable", is.Nullable},
+		{"key", is.Key},
+	}
+
+	for _, f := range flags {
+		if !f.set {
+			continue
+		}
+
+		err = property(e, f.name, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	if is.Bits != 0 {
+		err = property(e, "bits", func(pe control.Encoder) error {
+			return integer.NewEncoder(integer.Schema{}, pe).EncodeInt64(int64(is.Bits))
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	bounds := []struct {
+		name  string
+		bound *big.Int
+	}{
+		{"min", is.Min},
+		{"max", is.Max},
+	}
+
+	for _, b := range bounds {
+		if b.bound == nil {
+			continue
+		}
+
+		bound := b.bound
+
+		err = property(e, b.name, func(pe control.Encoder) error {
+			return integer.NewEncoder(integer.Schema{Signed: true}, pe).EncodeBigInt(bound)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if is.ContentType != "" {
+		err = property(e, "content-type", func(pe control.Encoder) error {
+			return pe.Data([]byte(is.ContentType))
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decodeInteger reads the properties of an integer schema.
+func decodeInteger(d control.Decoder) (is *integer.Schema, err error) {
+	is = &integer.Schema{}
+
+	err = properties(d, func(name string, pd control.Decoder) (err error) {
+		switch name {
+		case "signed":
+			is.Signed = true
+		case "delta":
+			is.Delta = true
+		case "nullable":
+			is.Nullable = true
+		case "key":
+			is.Key = true
+		case "bits":
+			bits, err := integer.NewDecoder(integer.Schema{}, pd).DecodeUint8()
+			if err != nil {
+				return err
+			}
+
+			is.Bits = int(bits)
+		case "min":
+			is.Min, err = integer.NewDecoder(integer.Schema{Signed: true}, pd).DecodeBigInt()
+		case "max":
+			is.Max, err = integer.NewDecoder(integer.Schema{Signed: true}, pd).DecodeBigInt()
+		case "content-type":
+			if !pd.Next() {
+				if pd.Err() != nil {
+					return pd.Err()
+				}
+
+				return Error.New("invalid content-type: missing")
+			}
+
+			var data []byte
+
+			data, err = pd.Data()
+			is.ContentType = string(data)
+		}
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_, _, err = is.Range()
+	if err != nil {
+		return nil, err
+	}
+
+	return is, nil
+}
+
+// Encode writes the column as an unbounded container of its properties. Each
+// property is a bounded container holding its name followed by its value. The
+// value of the integer property is the properties of the integer schema.
+func (c Column) Encode(e control.Encoder) (err error) {
+	defer Error.WrapP(&err)
+
+	return e.Unbound(func(ue control.Encoder) error {
+		if c.Integer == nil {
+			return nil
+		}
+
+		return property(ue, "integer", func(pe control.Encoder) error {
+			return encodeInteger(pe, *c.Integer)
+		})
+	})
+}
+
+// Decode reads the column from the current field of the decoder. Properties
+// this version doesn't know about are skipped.
+func (c *Column) Decode(d control.Decoder) (err error) {
+	defer Error.WrapP(&err)
+
+	if d.Type() != control.ContainerUnbounded {
+		return Error.New("invalid column: %s", d.Type().Abbr)
+	}
+
+	err = d.Enter()
+	if err != nil {
+		return err
+	}
+
+	column := Column{}
+
+	err = properties(d, func(name string, pd control.Decoder) (err error) {
+		switch name {
+		case "integer":
+			column.Integer, err = decodeInteger(pd)
+		}
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	*c = column
+
+	return nil
 }
 
 type Schema []Column
+
+// Encode writes the schema as an unbounded container with a field for each
+// column.
+func (s Schema) Encode(e control.Encoder) (err error) {
+	defer Error.WrapP(&err)
+
+	return e.Unbound(func(ue control.Encoder) (err error) {
+		for _, c := range s {
+			err = c.Encode(ue)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Decode reads the schema from the current field of the decoder.
+func (s *Schema) Decode(d control.Decoder) (err error) {
+	defer Error.WrapP(&err)
+
+	if d.Type() != control.ContainerUnbounded {
+		return Error.New("invalid schema: %s", d.Type().Abbr)
+	}
+
+	err = d.Enter()
+	if err != nil {
+		return err
+	}
+
+	depth := d.Depth()
+
+	columns := Schema{}
+
+	for d.Next() {
+		if d.Type() == control.ContainerEnd && d.Depth() == depth-1 {
+			*s = columns
+
+			return nil
+		}
+
+		var c Column
+
+		err = c.Decode(d)
+		if err != nil {
+			return err
+		}
+
+		columns = append(columns, c)
+	}
+
+	err = d.Err()
+	if err != nil {
+		return err
+	}
+
+	return Error.New("unexpected end of input (schema not ended)")
+}
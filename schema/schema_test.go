@@ -0,0 +1,119 @@
+package schema_test
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calebcase/bsv/control"
+	"github.com/calebcase/bsv/integer"
+	"github.com/calebcase/bsv/schema"
+	"github.com/calebcase/oops"
+)
+
+func TestRoundTrip(t *testing.T) {
+	type TC struct {
+		Schema schema.Schema
+		Mark   error
+	}
+
+	tcs := []TC{
+		{
+			Schema: schema.Schema{},
+			Mark:   oops.New("unexpected"),
+		},
+		{
+			Schema: schema.Schema{{}, {Integer: &integer.Schema{}}},
+			Mark:   oops.New("unexpected"),
+		},
+		{
+			Schema: schema.Schema{
+				{
+					Integer: &integer.Schema{
+						Signed:      true,
+						Delta:       true,
+						Bits:        32,
+						Min:         big.NewInt(-5),
+						Max:         big.NewInt(1000),
+						Nullable:    true,
+						Key:         true,
+						ContentType: "application/x-timestamp",
+					},
+				},
+				{
+					Integer: &integer.Schema{
+						Delta: true,
+					},
+				},
+			},
+			Mark: oops.New("unexpected"),
+		},
+	}
+
+	for _, tc := range tcs {
+		buf := &bytes.Buffer{}
+
+		require.NoError(t, tc.Schema.Encode(control.NewEncoder(buf)), tc.Mark)
+
+		d := control.NewDecoder(bytes.NewReader(buf.Bytes()))
+		require.True(t, d.Next(), tc.Mark)
+
+		s := schema.Schema{}
+		require.NoError(t, s.Decode(d), tc.Mark)
+		require.Equal(t, tc.Schema, s, tc.Mark)
+
+		require.False(t, d.Next(), tc.Mark)
+		require.NoError(t, d.Err(), tc.Mark)
+	}
+}
+
+func TestDecodeUnknown(t *testing.T) {
+	buf := &bytes.Buffer{}
+	e := control.NewEncoder(buf)
+
+	// A column with fields and properties from a later version around an
+	// integer property.
+	err := e.Unbound(func(ue control.Encoder) error {
+		return ue.Unbound(func(ce control.Encoder) (err error) {
+			err = ce.BoundFunc(func(pe control.Encoder) (err error) {
+				err = pe.Data([]byte("future"))
+				if err != nil {
+					return err
+				}
+
+				return pe.Data([]byte{0b_0000_0001})
+			})
+			if err != nil {
+				return err
+			}
+
+			err = ce.Unbound(func(fe control.Encoder) error {
+				return fe.Null()
+			})
+			if err != nil {
+				return err
+			}
+
+			return ce.BoundFunc(func(pe control.Encoder) (err error) {
+				err = pe.Data([]byte("integer"))
+				if err != nil {
+					return err
+				}
+
+				return pe.BoundFunc(func(ie control.Encoder) error {
+					return ie.Data([]byte("delta"))
+				})
+			})
+		})
+	})
+	require.NoError(t, err)
+
+	d := control.NewDecoder(bytes.NewReader(buf.Bytes()))
+	require.True(t, d.Next())
+
+	s := schema.Schema{}
+	require.NoError(t, s.Decode(d))
+	require.Equal(t, schema.Schema{{Integer: &integer.Schema{Delta: true}}}, s)
+}